@@ -0,0 +1,551 @@
+package mailpitclient
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// DefaultCacheMaxBytes is the default in-memory size bound of a CachingClient.
+const DefaultCacheMaxBytes = 64 << 20
+
+// Cache entry kinds, used in keys and on-disk file names.
+const (
+	cacheKindMessage    = "message"
+	cacheKindHeaders    = "headers"
+	cacheKindSource     = "source"
+	cacheKindRaw        = "raw"
+	cacheKindPart       = "part"
+	cacheKindThumbnail  = "thumb"
+	cacheKindAttachment = "attachment"
+	cacheKindHTML       = "html"
+	cacheKindText       = "text"
+	cacheKindPartHTML   = "part-html"
+	cacheKindPartText   = "part-text"
+)
+
+// CacheStats reports the activity of a CachingClient.
+type CacheStats struct {
+	Hits      int64
+	DiskHits  int64
+	Misses    int64
+	Evictions int64
+	Entries   int
+	Bytes     int64
+}
+
+// CacheOption configures a CachingClient.
+type CacheOption func(*CachingClient)
+
+// WithCacheMaxBytes bounds the total size of cached responses held in memory.
+// The least recently used entries are evicted first.
+func WithCacheMaxBytes(n int64) CacheOption {
+	return func(c *CachingClient) {
+		c.maxBytes = n
+	}
+}
+
+// WithCacheDir additionally stores cached responses in dir, so they survive
+// the process and are shared by clients using the same directory. Entries
+// evicted from memory are reloaded from disk. The directory must only be
+// shared by clients talking to the same Mailpit instance.
+func WithCacheDir(dir string) CacheOption {
+	return func(c *CachingClient) {
+		c.dir = dir
+	}
+}
+
+type cacheBypassKey struct{}
+
+// WithoutCache returns a context that makes a CachingClient fetch from the
+// server and refresh its cache instead of answering from it.
+func WithoutCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, cacheBypassKey{}, true)
+}
+
+// CachingClient is a Client that caches data Mailpit never changes once a
+// message is stored: message details, headers, source, parts, thumbnails,
+// attachments and rendered HTML and text. Everything else, such as listings,
+// searches, tags, read status, statistics and checks, passes straight through.
+//
+// A message's Read and Tags fields are never served from the cache, so
+// changes made through the web UI or other clients are seen. GetMessage
+// therefore still costs one search request per hit: it saves transferring
+// the message, not the round trip.
+// Note that Mailpit marks a message read when it is fetched, which a cache hit
+// does not do. Deleting messages through the CachingClient invalidates their
+// entries.
+//
+// A CachingClient is safe for concurrent use.
+type CachingClient struct {
+	Client
+
+	entries   map[string]*list.Element
+	byMessage map[string]map[string]struct{}
+	lru       *list.List
+	dir       string
+	stats     CacheStats
+	maxBytes  int64
+	mu        sync.Mutex
+}
+
+type cacheEntry struct {
+	key       string
+	messageID string
+	data      []byte
+}
+
+// NewCachingClient wraps c with a response cache.
+func NewCachingClient(c Client, opts ...CacheOption) (*CachingClient, error) {
+	if c == nil {
+		return nil, NewValidationError("client cannot be nil")
+	}
+
+	cc := &CachingClient{
+		Client:    c,
+		entries:   make(map[string]*list.Element),
+		byMessage: make(map[string]map[string]struct{}),
+		lru:       list.New(),
+		maxBytes:  DefaultCacheMaxBytes,
+	}
+
+	for _, opt := range opts {
+		opt(cc)
+	}
+
+	if cc.maxBytes <= 0 {
+		return nil, NewConfigError("cache size must be positive")
+	}
+
+	if cc.dir != "" {
+		if err := os.MkdirAll(cc.dir, 0o700); err != nil {
+			return nil, &Error{Type: ErrorTypeConfig, Message: "failed to create cache directory", Cause: err}
+		}
+	}
+
+	return cc, nil
+}
+
+// CacheStats returns a snapshot of the cache counters.
+func (c *CachingClient) CacheStats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := c.stats
+	stats.Entries = c.lru.Len()
+
+	return stats
+}
+
+// Invalidate drops every cached entry of a message.
+func (c *CachingClient) Invalidate(messageID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key := range c.byMessage[messageID] {
+		c.removeLocked(c.entries[key])
+	}
+
+	if c.dir != "" {
+		_ = os.RemoveAll(filepath.Join(c.dir, cacheHash(messageID)))
+	}
+}
+
+// Purge drops every cached entry, including those on disk.
+func (c *CachingClient) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries = make(map[string]*list.Element)
+	c.byMessage = make(map[string]map[string]struct{})
+	c.lru.Init()
+	c.stats.Bytes = 0
+
+	if c.dir != "" {
+		entries, _ := os.ReadDir(c.dir)
+		for _, e := range entries {
+			_ = os.RemoveAll(filepath.Join(c.dir, e.Name()))
+		}
+	}
+}
+
+// GetMessage returns the message from the cache, fetching it on a miss. The
+// Read and Tags fields are not cached: every hit looks them up with one
+// search request by the message's Message-ID header, and the message is
+// fetched again if that search does not find it.
+func (c *CachingClient) GetMessage(ctx context.Context, id string) (*Message, error) {
+	var msg Message
+	if c.load(ctx, cacheKindMessage, id, "", &msg) {
+		found, err := c.refreshMessageState(ctx, &msg)
+		if err != nil {
+			return nil, err
+		}
+
+		if found {
+			return &msg, nil
+		}
+	}
+
+	fetched, err := c.Client.GetMessage(ctx, id)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			c.Invalidate(id)
+		}
+
+		return nil, err
+	}
+
+	cached := *fetched
+	cached.Read = false
+	cached.Tags = nil
+	c.storeJSON(cacheKindMessage, id, "", &cached)
+
+	return fetched, nil
+}
+
+// refreshMessageState sets the mutable Read and Tags fields of a cached
+// message from the search results, reporting whether the message was found.
+func (c *CachingClient) refreshMessageState(ctx context.Context, msg *Message) (bool, error) {
+	mid := strings.Trim(msg.MessageID, "<> ")
+	if mid == "" {
+		return false, nil
+	}
+
+	result, err := c.Client.SearchMessages(ctx, fmt.Sprintf("message-id:%q", mid), nil)
+	if err != nil {
+		return false, err
+	}
+
+	for _, m := range result.Messages {
+		if m.ID == msg.ID {
+			msg.Read = m.Read
+			msg.Tags = m.Tags
+
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// OpenEventStream opens the event stream of the wrapped client. It fails with
+// errors.ErrUnsupported if the wrapped client is not an EventStreamer.
+func (c *CachingClient) OpenEventStream(ctx context.Context) (EventStream, error) {
+	if es, ok := c.Client.(EventStreamer); ok {
+		return es.OpenEventStream(ctx)
+	}
+
+	return nil, errors.ErrUnsupported
+}
+
+// GetMessageHeaders returns the message headers from the cache, fetching them
+// on a miss.
+func (c *CachingClient) GetMessageHeaders(ctx context.Context, id string) (map[string][]string, error) {
+	var headers map[string][]string
+	if c.load(ctx, cacheKindHeaders, id, "", &headers) {
+		return headers, nil
+	}
+
+	fetched, err := c.Client.GetMessageHeaders(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	c.storeJSON(cacheKindHeaders, id, "", fetched)
+
+	return fetched, nil
+}
+
+// GetMessageSource returns the message source from the cache, fetching it on
+// a miss.
+func (c *CachingClient) GetMessageSource(ctx context.Context, id string) (string, error) {
+	return c.cachedString(ctx, cacheKindSource, id, "", c.Client.GetMessageSource)
+}
+
+// GetMessageRaw returns the raw message from the cache, fetching it on a miss.
+func (c *CachingClient) GetMessageRaw(ctx context.Context, id string) (string, error) {
+	return c.cachedString(ctx, cacheKindRaw, id, "", c.Client.GetMessageRaw)
+}
+
+// GetMessageHTML returns the rendered HTML from the cache, fetching it on a
+// miss.
+func (c *CachingClient) GetMessageHTML(ctx context.Context, id string) (string, error) {
+	return c.cachedString(ctx, cacheKindHTML, id, "", c.Client.GetMessageHTML)
+}
+
+// GetMessageText returns the rendered text from the cache, fetching it on a
+// miss.
+func (c *CachingClient) GetMessageText(ctx context.Context, id string) (string, error) {
+	return c.cachedString(ctx, cacheKindText, id, "", c.Client.GetMessageText)
+}
+
+// GetMessagePart returns the part from the cache, fetching it on a miss.
+func (c *CachingClient) GetMessagePart(ctx context.Context, messageID, partID string) ([]byte, error) {
+	return c.cachedBytes(ctx, cacheKindPart, messageID, partID, c.Client.GetMessagePart)
+}
+
+// GetMessagePartThumbnail returns the thumbnail from the cache, fetching it on
+// a miss.
+func (c *CachingClient) GetMessagePartThumbnail(ctx context.Context, messageID, partID string) ([]byte, error) {
+	return c.cachedBytes(ctx, cacheKindThumbnail, messageID, partID, c.Client.GetMessagePartThumbnail)
+}
+
+// GetMessageAttachment returns the attachment from the cache, fetching it on a
+// miss.
+func (c *CachingClient) GetMessageAttachment(ctx context.Context, messageID, attachmentID string) ([]byte, error) {
+	return c.cachedBytes(ctx, cacheKindAttachment, messageID, attachmentID, c.Client.GetMessageAttachment)
+}
+
+// GetMessagePartHTML returns the rendered part HTML from the cache, fetching
+// it on a miss.
+func (c *CachingClient) GetMessagePartHTML(ctx context.Context, messageID, partID string) (string, error) {
+	data, err := c.cachedBytes(ctx, cacheKindPartHTML, messageID, partID, stringFetcher(c.Client.GetMessagePartHTML))
+
+	return string(data), err
+}
+
+// GetMessagePartText returns the rendered part text from the cache, fetching
+// it on a miss.
+func (c *CachingClient) GetMessagePartText(ctx context.Context, messageID, partID string) (string, error) {
+	data, err := c.cachedBytes(ctx, cacheKindPartText, messageID, partID, stringFetcher(c.Client.GetMessagePartText))
+
+	return string(data), err
+}
+
+// DeleteMessage deletes the message and drops its cache entries.
+func (c *CachingClient) DeleteMessage(ctx context.Context, id string) error {
+	c.Invalidate(id)
+
+	return c.Client.DeleteMessage(ctx, id)
+}
+
+// DeleteAllMessages deletes all messages and purges the cache.
+func (c *CachingClient) DeleteAllMessages(ctx context.Context) error {
+	c.Purge()
+
+	return c.Client.DeleteAllMessages(ctx)
+}
+
+// DeleteSearchResults deletes the matching messages and purges the cache, as
+// the deleted IDs are not known.
+func (c *CachingClient) DeleteSearchResults(ctx context.Context, query string) error {
+	c.Purge()
+
+	return c.Client.DeleteSearchResults(ctx, query)
+}
+
+func (c *CachingClient) cachedString(
+	ctx context.Context,
+	kind, messageID, partID string,
+	fetch func(context.Context, string) (string, error),
+) (string, error) {
+	data, err := c.cachedBytes(ctx, kind, messageID, partID, func(ctx context.Context, id, _ string) ([]byte, error) {
+		s, err := fetch(ctx, id)
+
+		return []byte(s), err
+	})
+
+	return string(data), err
+}
+
+func (c *CachingClient) cachedBytes(
+	ctx context.Context,
+	kind, messageID, partID string,
+	fetch func(context.Context, string, string) ([]byte, error),
+) ([]byte, error) {
+	if data, ok := c.get(ctx, kind, messageID, partID); ok {
+		return data, nil
+	}
+
+	data, err := fetch(ctx, messageID, partID)
+	if err != nil {
+		return nil, err
+	}
+
+	c.put(kind, messageID, partID, data)
+
+	return data, nil
+}
+
+func stringFetcher(fetch func(context.Context, string, string) (string, error)) func(context.Context, string, string) ([]byte, error) {
+	return func(ctx context.Context, messageID, partID string) ([]byte, error) {
+		s, err := fetch(ctx, messageID, partID)
+
+		return []byte(s), err
+	}
+}
+
+// load decodes a cached JSON value into v.
+func (c *CachingClient) load(ctx context.Context, kind, messageID, partID string, v any) bool {
+	data, ok := c.get(ctx, kind, messageID, partID)
+	if !ok {
+		return false
+	}
+
+	if err := json.Unmarshal(data, v); err != nil {
+		c.drop(cacheKey(kind, messageID, partID), messageID)
+
+		return false
+	}
+
+	return true
+}
+
+func (c *CachingClient) storeJSON(kind, messageID, partID string, v any) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return
+	}
+
+	c.put(kind, messageID, partID, data)
+}
+
+// get returns a copy of a cached value, consulting the disk store on a memory
+// miss. An empty message ID is never cached so the server reports the error.
+func (c *CachingClient) get(ctx context.Context, kind, messageID, partID string) ([]byte, bool) {
+	if messageID == "" || ctx.Value(cacheBypassKey{}) != nil {
+		return nil, false
+	}
+
+	key := cacheKey(kind, messageID, partID)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.entries[key]; ok {
+		c.lru.MoveToFront(el)
+		c.stats.Hits++
+
+		entry, _ := el.Value.(*cacheEntry)
+
+		return append([]byte(nil), entry.data...), true
+	}
+
+	if c.dir != "" {
+		if data, err := os.ReadFile(c.diskPath(key, messageID)); err == nil {
+			c.stats.DiskHits++
+			c.addLocked(key, messageID, data)
+
+			return append([]byte(nil), data...), true
+		}
+	}
+
+	c.stats.Misses++
+
+	return nil, false
+}
+
+func (c *CachingClient) put(kind, messageID, partID string, data []byte) {
+	if messageID == "" {
+		return
+	}
+
+	key := cacheKey(kind, messageID, partID)
+	data = append([]byte(nil), data...)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.entries[key]; ok {
+		c.removeLocked(el)
+	}
+	c.addLocked(key, messageID, data)
+
+	if c.dir != "" {
+		c.writeDisk(key, messageID, data)
+	}
+}
+
+func (c *CachingClient) addLocked(key, messageID string, data []byte) {
+	if int64(len(data)) > c.maxBytes {
+		return
+	}
+
+	c.entries[key] = c.lru.PushFront(&cacheEntry{key: key, messageID: messageID, data: data})
+	if c.byMessage[messageID] == nil {
+		c.byMessage[messageID] = make(map[string]struct{})
+	}
+	c.byMessage[messageID][key] = struct{}{}
+	c.stats.Bytes += int64(len(data))
+
+	for c.stats.Bytes > c.maxBytes {
+		c.removeLocked(c.lru.Back())
+		c.stats.Evictions++
+	}
+}
+
+func (c *CachingClient) removeLocked(el *list.Element) {
+	if el == nil {
+		return
+	}
+
+	entry, _ := el.Value.(*cacheEntry)
+	c.lru.Remove(el)
+	delete(c.entries, entry.key)
+	delete(c.byMessage[entry.messageID], entry.key)
+	if len(c.byMessage[entry.messageID]) == 0 {
+		delete(c.byMessage, entry.messageID)
+	}
+	c.stats.Bytes -= int64(len(entry.data))
+}
+
+// drop removes one entry from memory and disk.
+func (c *CachingClient) drop(key, messageID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.removeLocked(c.entries[key])
+
+	if c.dir != "" {
+		_ = os.Remove(c.diskPath(key, messageID))
+	}
+}
+
+func (c *CachingClient) diskPath(key, messageID string) string {
+	kind, _, _ := strings.Cut(key, "\x00")
+
+	return filepath.Join(c.dir, cacheHash(messageID), kind+"-"+cacheHash(key))
+}
+
+// writeDisk stores data atomically so concurrent readers never see a partial
+// file. Failures are ignored; the disk store is best effort.
+func (c *CachingClient) writeDisk(key, messageID string, data []byte) {
+	path := c.diskPath(key, messageID)
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return
+	}
+
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+	}
+}
+
+func cacheKey(kind, messageID, partID string) string {
+	return kind + "\x00" + messageID + "\x00" + partID
+}
+
+func cacheHash(s string) string {
+	sum := sha256.Sum256([]byte(s))
+
+	return hex.EncodeToString(sum[:16])
+}
@@ -0,0 +1,308 @@
+package mailpitclient
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// cacheServer is a fake Mailpit counting requests per path.
+type cacheServer struct {
+	requests map[string]int
+	tags     []string
+	mu       sync.Mutex
+	read     bool
+}
+
+// setState changes the read status and tags, like the web UI would.
+func (s *cacheServer) setState(read bool, tags ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.read = read
+	s.tags = tags
+}
+
+func (s *cacheServer) count(path string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.requests[path]
+}
+
+func (s *cacheServer) total() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var n int
+	for _, c := range s.requests {
+		n += c
+	}
+
+	return n
+}
+
+func newCacheTestClient(t *testing.T, state *cacheServer) Client {
+	t.Helper()
+
+	state.requests = map[string]int{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		state.mu.Lock()
+		state.requests[r.Method+" "+r.URL.Path]++
+		read, tags := state.read, state.tags
+		state.mu.Unlock()
+
+		path := strings.TrimPrefix(r.URL.Path, "/api/v1")
+		switch {
+		case r.Method == http.MethodDelete, r.Method == http.MethodPut:
+			w.WriteHeader(http.StatusOK)
+		case path == "/messages":
+			_ = json.NewEncoder(w).Encode(MessagesResponse{Total: 1})
+		case path == "/search" && r.URL.Query().Get("query") == `message-id:"msg-1@example.com"`:
+			_ = json.NewEncoder(w).Encode(MessagesResponse{
+				Total:    1,
+				Messages: []MessageSummary{{ID: "msg-1", Read: read, Tags: tags}},
+			})
+		case path == "/search":
+			_ = json.NewEncoder(w).Encode(MessagesResponse{})
+		case path == "/message/msg-1", path == "/message/msg-2":
+			id := strings.TrimPrefix(path, "/message/")
+			_ = json.NewEncoder(w).Encode(Message{
+				ID:        id,
+				MessageID: id + "@example.com",
+				Subject:   "Hello",
+				Read:      read,
+				Tags:      tags,
+			})
+		case path == "/message/msg-1/headers":
+			_ = json.NewEncoder(w).Encode(map[string][]string{"Subject": {"Hello"}})
+		case path == "/message/msg-1/raw":
+			_, _ = w.Write([]byte("Subject: Hello\r\n\r\nHi\r\n"))
+		case path == "/message/msg-1/part/2":
+			_, _ = w.Write([]byte(strings.Repeat("x", 100)))
+		case path == "/message/msg-1/part/3":
+			_, _ = w.Write([]byte(strings.Repeat("y", 100)))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	c, err := NewClient(&Config{
+		BaseURL:    server.URL,
+		APIPath:    "/api/v1",
+		MaxRetries: 0,
+		HTTPClient: &http.Client{Timeout: 5 * time.Second},
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = c.Close() })
+
+	return c
+}
+
+func TestCachingClient_CachesImmutableData(t *testing.T) {
+	t.Parallel()
+
+	state := &cacheServer{}
+	c, err := NewCachingClient(newCacheTestClient(t, state))
+	require.NoError(t, err)
+
+	ctx := t.Context()
+
+	for range 3 {
+		msg, err := c.GetMessage(ctx, "msg-1")
+		require.NoError(t, err)
+		require.Equal(t, "Hello", msg.Subject)
+
+		headers, err := c.GetMessageHeaders(ctx, "msg-1")
+		require.NoError(t, err)
+		require.Equal(t, []string{"Hello"}, headers["Subject"])
+
+		part, err := c.GetMessagePart(ctx, "msg-1", "2")
+		require.NoError(t, err)
+		require.Len(t, part, 100)
+
+		source, err := c.GetMessageSource(ctx, "msg-1")
+		require.NoError(t, err)
+		require.Contains(t, source, "Subject: Hello")
+
+		_, err = c.ListMessages(ctx, nil)
+		require.NoError(t, err)
+	}
+
+	require.Equal(t, 1, state.count("GET /api/v1/message/msg-1"))
+	require.Equal(t, 1, state.count("GET /api/v1/message/msg-1/headers"))
+	require.Equal(t, 1, state.count("GET /api/v1/message/msg-1/part/2"))
+	require.Equal(t, 1, state.count("GET /api/v1/message/msg-1/raw"))
+	require.Equal(t, 3, state.count("GET /api/v1/messages"), "listings are never cached")
+
+	stats := c.CacheStats()
+	require.Equal(t, int64(8), stats.Hits)
+	require.Equal(t, int64(4), stats.Misses)
+	require.Equal(t, 4, stats.Entries)
+
+	// Callers cannot corrupt the cache through returned values.
+	msg, err := c.GetMessage(ctx, "msg-1")
+	require.NoError(t, err)
+	msg.Subject = "changed"
+	msg, err = c.GetMessage(ctx, "msg-1")
+	require.NoError(t, err)
+	require.Equal(t, "Hello", msg.Subject)
+
+	_, err = c.GetMessage(WithoutCache(ctx), "msg-1")
+	require.NoError(t, err)
+	require.Equal(t, 2, state.count("GET /api/v1/message/msg-1"))
+
+	_, err = c.GetMessage(ctx, "missing")
+	require.ErrorIs(t, err, ErrNotFound)
+	_, err = c.GetMessage(ctx, "missing")
+	require.ErrorIs(t, err, ErrNotFound)
+	require.Equal(t, 2, state.count("GET /api/v1/message/missing"), "errors are not cached")
+}
+
+func TestCachingClient_GetMessageHitCost(t *testing.T) {
+	t.Parallel()
+
+	state := &cacheServer{}
+	c, err := NewCachingClient(newCacheTestClient(t, state))
+	require.NoError(t, err)
+
+	_, err = c.GetMessage(t.Context(), "msg-1")
+	require.NoError(t, err)
+	require.Equal(t, 1, state.total())
+
+	_, err = c.GetMessage(t.Context(), "msg-1")
+	require.NoError(t, err)
+	require.Equal(t, 2, state.total(), "a hit costs exactly one request")
+	require.Equal(t, 1, state.count("GET /api/v1/search"), "the request is the Read and Tags search")
+	require.Equal(t, 1, state.count("GET /api/v1/message/msg-1"))
+}
+
+func TestCachingClient_Invalidation(t *testing.T) {
+	t.Parallel()
+
+	state := &cacheServer{}
+	c, err := NewCachingClient(newCacheTestClient(t, state))
+	require.NoError(t, err)
+
+	ctx := t.Context()
+
+	state.setState(false, "a")
+	msg, err := c.GetMessage(ctx, "msg-1")
+	require.NoError(t, err)
+	require.False(t, msg.Read)
+	require.Equal(t, []string{"a"}, msg.Tags)
+
+	// Changes made elsewhere, e.g. in the web UI, are seen on cache hits.
+	state.setState(true, "a", "b")
+	msg, err = c.GetMessage(ctx, "msg-1")
+	require.NoError(t, err)
+	require.True(t, msg.Read)
+	require.Equal(t, []string{"a", "b"}, msg.Tags)
+	require.Equal(t, 1, state.count("GET /api/v1/message/msg-1"))
+	require.Equal(t, 1, state.count("GET /api/v1/search"))
+
+	// A message the search cannot find is fetched again.
+	_, err = c.GetMessage(ctx, "msg-2")
+	require.NoError(t, err)
+	_, err = c.GetMessage(ctx, "msg-2")
+	require.NoError(t, err)
+	require.Equal(t, 2, state.count("GET /api/v1/message/msg-2"))
+
+	_, err = c.GetMessagePart(ctx, "msg-1", "2")
+	require.NoError(t, err)
+	require.NoError(t, c.DeleteMessage(ctx, "msg-1"))
+	require.Equal(t, 1, c.CacheStats().Entries, "only msg-2 is left")
+
+	require.NoError(t, c.DeleteAllMessages(ctx))
+	require.Zero(t, c.CacheStats().Entries)
+}
+
+func TestCachingClient_Eviction(t *testing.T) {
+	t.Parallel()
+
+	state := &cacheServer{}
+	c, err := NewCachingClient(newCacheTestClient(t, state), WithCacheMaxBytes(150))
+	require.NoError(t, err)
+
+	ctx := t.Context()
+
+	_, err = c.GetMessagePart(ctx, "msg-1", "2")
+	require.NoError(t, err)
+	_, err = c.GetMessagePart(ctx, "msg-1", "3")
+	require.NoError(t, err)
+
+	stats := c.CacheStats()
+	require.Equal(t, 1, stats.Entries)
+	require.Equal(t, int64(1), stats.Evictions)
+	require.Equal(t, int64(100), stats.Bytes)
+
+	_, err = c.GetMessagePart(ctx, "msg-1", "2")
+	require.NoError(t, err)
+	require.Equal(t, 2, state.count("GET /api/v1/message/msg-1/part/2"), "least recently used part was evicted")
+}
+
+func TestCachingClient_DiskStore(t *testing.T) {
+	t.Parallel()
+
+	state := &cacheServer{}
+	inner := newCacheTestClient(t, state)
+	dir := t.TempDir()
+
+	first, err := NewCachingClient(inner, WithCacheDir(dir))
+	require.NoError(t, err)
+	_, err = first.GetMessage(t.Context(), "msg-1")
+	require.NoError(t, err)
+	_, err = first.GetMessagePart(t.Context(), "msg-1", "2")
+	require.NoError(t, err)
+
+	second, err := NewCachingClient(inner, WithCacheDir(dir))
+	require.NoError(t, err)
+
+	msg, err := second.GetMessage(t.Context(), "msg-1")
+	require.NoError(t, err)
+	require.Equal(t, "Hello", msg.Subject)
+	require.Equal(t, 1, state.count("GET /api/v1/message/msg-1"))
+	require.Equal(t, int64(1), second.CacheStats().DiskHits)
+
+	require.NoError(t, second.DeleteMessage(t.Context(), "msg-1"))
+
+	third, err := NewCachingClient(inner, WithCacheDir(dir))
+	require.NoError(t, err)
+	_, err = third.GetMessagePart(t.Context(), "msg-1", "2")
+	require.NoError(t, err)
+	require.Equal(t, 2, state.count("GET /api/v1/message/msg-1/part/2"), "deletes remove disk entries")
+}
+
+func TestNewCachingClient_Validation(t *testing.T) {
+	t.Parallel()
+
+	_, err := NewCachingClient(nil)
+	require.Error(t, err)
+
+	c, err := NewClient(&Config{BaseURL: "http://localhost:8025"})
+	require.NoError(t, err)
+
+	_, err = NewCachingClient(c, WithCacheMaxBytes(0))
+	require.Error(t, err)
+
+	var _ Client = &CachingClient{}
+}
+
+func TestCachingClient_OpenEventStream(t *testing.T) {
+	t.Parallel()
+
+	// A client that only implements Client has no event stream.
+	c, err := NewCachingClient(struct{ Client }{newCacheTestClient(t, &cacheServer{})})
+	require.NoError(t, err)
+
+	_, err = c.OpenEventStream(t.Context())
+	require.ErrorIs(t, err, errors.ErrUnsupported)
+}
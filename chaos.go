@@ -4,7 +4,9 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
+	"time"
 )
 
 // GetChaosConfig retrieves the current chaos triggers configuration.
@@ -27,10 +29,18 @@ func (c *client) GetChaosConfig(ctx context.Context) (*ChaosResponse, error) {
 
 // SetChaosConfig sets the chaos triggers configuration and returns the updated values.
 // This API route will return an error if Chaos is not enabled at runtime.
-// If any triggers are omitted from the request, then those are reset to their
-// default values with a 0% probability (ie: disabled).
-// Setting a blank config will reset all triggers to their default values.
+// All triggers are always sent, so a zero ChaosTrigger disables that trigger
+// and a nil or blank config resets every trigger to its default value.
+// The triggers are validated before the request is made.
 func (c *client) SetChaosConfig(ctx context.Context, config *ChaosTriggers) (*ChaosResponse, error) {
+	if config == nil {
+		config = &ChaosTriggers{}
+	}
+
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
 	endpoint := "/chaos"
 
 	var body bytes.Buffer
@@ -54,3 +64,104 @@ func (c *client) SetChaosConfig(ctx context.Context, config *ChaosTriggers) (*Ch
 
 	return &chaos, nil
 }
+
+// Names of the predefined chaos scenarios.
+const (
+	ChaosScenarioFlakyNetwork        = "flaky-network"
+	ChaosScenarioAuthOutage          = "auth-outage"
+	ChaosScenarioSenderRejections    = "sender-rejections"
+	ChaosScenarioRecipientRejections = "recipient-rejections"
+)
+
+// ChaosScenario is a named set of chaos triggers that can be applied with WithChaos.
+type ChaosScenario struct {
+	Name     string
+	Triggers ChaosTriggers
+}
+
+// NewChaosScenario returns one of the predefined chaos scenarios by name.
+//
+//   - flaky-network: 10% of senders and recipients fail with 421 (service not available)
+//   - auth-outage: every authentication attempt fails with 454 (temporary authentication failure)
+//   - sender-rejections: 30% of senders are rejected with 550
+//   - recipient-rejections: 30% of recipients are rejected with 451
+//
+// Mailpit can only fail SMTP commands, it cannot delay them, so there is no
+// scenario for slow connections or a slow DATA phase.
+func NewChaosScenario(name string) (ChaosScenario, error) {
+	scenario := ChaosScenario{Name: name}
+
+	switch name {
+	case ChaosScenarioFlakyNetwork:
+		scenario.Triggers = ChaosTriggers{
+			Sender:    ChaosTrigger{ErrorCode: 421, Probability: 10},
+			Recipient: ChaosTrigger{ErrorCode: 421, Probability: 10},
+		}
+	case ChaosScenarioAuthOutage:
+		scenario.Triggers = ChaosTriggers{
+			Authentication: ChaosTrigger{ErrorCode: 454, Probability: 100},
+		}
+	case ChaosScenarioSenderRejections:
+		scenario.Triggers = ChaosTriggers{
+			Sender: ChaosTrigger{ErrorCode: 550, Probability: 30},
+		}
+	case ChaosScenarioRecipientRejections:
+		scenario.Triggers = ChaosTriggers{
+			Recipient: ChaosTrigger{ErrorCode: 451, Probability: 30},
+		}
+	default:
+		return ChaosScenario{}, NewValidationError(fmt.Sprintf("unknown chaos scenario %q", name))
+	}
+
+	return scenario, nil
+}
+
+// WithChaos applies the scenario's triggers, runs fn and then restores the
+// chaos configuration that was active before, even if fn panics or ctx is
+// cancelled. If the scenario cannot be applied fn is not run. An error
+// restoring the previous configuration is returned after fn completes.
+func WithChaos(ctx context.Context, c Client, scenario ChaosScenario, fn func()) error {
+	if c == nil {
+		return NewValidationError("client cannot be nil")
+	}
+	if fn == nil {
+		return NewValidationError("function cannot be nil")
+	}
+
+	previous, err := c.GetChaosConfig(ctx)
+	if err != nil {
+		return err
+	}
+
+	triggers := scenario.Triggers
+	if _, err = c.SetChaosConfig(ctx, &triggers); err != nil {
+		return err
+	}
+
+	restore := func() error {
+		// Restore with a context that outlives cancellation of ctx, so a
+		// timed out test does not leave the instance in a broken state.
+		restoreCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), chaosRestoreTimeout)
+		defer cancel()
+
+		_, restoreErr := c.SetChaosConfig(restoreCtx, &previous.Triggers)
+
+		return restoreErr
+	}
+
+	func() {
+		defer func() {
+			if r := recover(); r != nil {
+				_ = restore()
+
+				panic(r)
+			}
+		}()
+
+		fn()
+	}()
+
+	return restore()
+}
+
+const chaosRestoreTimeout = 30 * time.Second
@@ -0,0 +1,167 @@
+package mailpitclient
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// chaosServer is a fake Mailpit chaos endpoint that keeps the applied triggers.
+type chaosServer struct {
+	current ChaosTriggers
+	history []ChaosTriggers
+	mu      sync.Mutex
+	enabled bool
+}
+
+func newChaosTestClient(t *testing.T, state *chaosServer) Client {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		state.mu.Lock()
+		defer state.mu.Unlock()
+
+		if !state.enabled {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte("Chaos is not enabled"))
+
+			return
+		}
+
+		if r.Method == http.MethodPut {
+			var triggers ChaosTriggers
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&triggers))
+			state.current = triggers
+			state.history = append(state.history, triggers)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(state.current)
+	}))
+	t.Cleanup(server.Close)
+
+	c, err := NewClient(&Config{
+		BaseURL:    server.URL,
+		APIPath:    "/api/v1",
+		MaxRetries: 0,
+		HTTPClient: &http.Client{Timeout: 5 * time.Second},
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = c.Close() })
+
+	return c
+}
+
+func TestNewChaosScenario(t *testing.T) {
+	t.Parallel()
+
+	for _, name := range []string{
+		ChaosScenarioFlakyNetwork,
+		ChaosScenarioAuthOutage,
+		ChaosScenarioSenderRejections,
+		ChaosScenarioRecipientRejections,
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			scenario, err := NewChaosScenario(name)
+			require.NoError(t, err)
+			require.Equal(t, name, scenario.Name)
+			require.NotEqual(t, ChaosTriggers{}, scenario.Triggers)
+			require.NoError(t, scenario.Triggers.Validate())
+		})
+	}
+
+	t.Run("unknown", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := NewChaosScenario("meteor-strike")
+
+		var mailpitErr *Error
+		require.ErrorAs(t, err, &mailpitErr)
+		require.Equal(t, ErrorTypeValidation, mailpitErr.Type)
+	})
+}
+
+func TestWithChaos(t *testing.T) {
+	t.Parallel()
+
+	previous := ChaosTriggers{
+		Sender:         ChaosTrigger{ErrorCode: 550, Probability: 5},
+		Recipient:      ChaosTrigger{ErrorCode: DefaultChaosErrorCode},
+		Authentication: ChaosTrigger{ErrorCode: DefaultChaosErrorCode},
+	}
+
+	t.Run("applies and restores", func(t *testing.T) {
+		t.Parallel()
+
+		state := &chaosServer{enabled: true, current: previous}
+		c := newChaosTestClient(t, state)
+
+		scenario, err := NewChaosScenario(ChaosScenarioAuthOutage)
+		require.NoError(t, err)
+
+		called := false
+		err = WithChaos(t.Context(), c, scenario, func() {
+			called = true
+
+			state.mu.Lock()
+			defer state.mu.Unlock()
+			require.Equal(t, scenario.Triggers.Authentication, state.current.Authentication)
+			require.False(t, state.current.Sender.Enabled())
+		})
+		require.NoError(t, err)
+		require.True(t, called)
+		require.Equal(t, previous, state.current)
+		require.Len(t, state.history, 2)
+	})
+
+	t.Run("restores on panic", func(t *testing.T) {
+		t.Parallel()
+
+		state := &chaosServer{enabled: true, current: previous}
+		c := newChaosTestClient(t, state)
+
+		scenario, err := NewChaosScenario(ChaosScenarioRecipientRejections)
+		require.NoError(t, err)
+
+		require.PanicsWithValue(t, "boom", func() {
+			_ = WithChaos(t.Context(), c, scenario, func() {
+				panic("boom")
+			})
+		})
+		require.Equal(t, previous, state.current)
+	})
+
+	t.Run("restores after context cancellation", func(t *testing.T) {
+		t.Parallel()
+
+		state := &chaosServer{enabled: true, current: previous}
+		c := newChaosTestClient(t, state)
+
+		scenario, err := NewChaosScenario(ChaosScenarioFlakyNetwork)
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithCancel(t.Context())
+		require.NoError(t, WithChaos(ctx, c, scenario, cancel))
+		require.Equal(t, previous, state.current)
+	})
+
+	t.Run("chaos disabled", func(t *testing.T) {
+		t.Parallel()
+
+		state := &chaosServer{}
+		c := newChaosTestClient(t, state)
+
+		called := false
+		err := WithChaos(t.Context(), c, ChaosScenario{}, func() { called = true })
+		require.ErrorIs(t, err, ErrChaosDisabled)
+		require.False(t, called)
+	})
+}
@@ -0,0 +1,187 @@
+package mailpitclient
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// CompatibilityScore returns the overall HTML compatibility of the message as
+// a percentage. Partially supported features count for half.
+func (r *HTMLCheckResponse) CompatibilityScore() float64 {
+	if r == nil {
+		return 0
+	}
+
+	if r.Total.Tests == 0 && r.Total.Supported == 0 && r.Total.Partial == 0 && r.Total.Unsupported == 0 {
+		return 100
+	}
+
+	return r.Total.Supported + r.Total.Partial/2
+}
+
+// UnsupportedOn returns the warnings for features that are not supported by at
+// least one email client on the given platform (e.g. "ios", "windows").
+// Matching is case-insensitive.
+func (r *HTMLCheckResponse) UnsupportedOn(platform string) []HTMLCheckWarning {
+	return r.filterWarnings(func(res HTMLCheckResult) bool {
+		return res.Support == HTMLCheckSupportNo && strings.EqualFold(res.Platform, platform)
+	})
+}
+
+// UnsupportedBy returns the warnings for features that are not supported by
+// the given email client family (e.g. "gmail", "outlook").
+// Matching is case-insensitive.
+func (r *HTMLCheckResponse) UnsupportedBy(family string) []HTMLCheckWarning {
+	return r.filterWarnings(func(res HTMLCheckResult) bool {
+		return res.Support == HTMLCheckSupportNo && strings.EqualFold(res.Family, family)
+	})
+}
+
+// ClientSupport returns the support percentages for a single email client
+// family, calculated against the total number of features tested.
+func (r *HTMLCheckResponse) ClientSupport(family string) HTMLCheckScore {
+	score := HTMLCheckScore{Supported: 100}
+	if r == nil || r.Total.Tests == 0 {
+		return score
+	}
+
+	var partial, unsupported int
+	for _, w := range r.Warnings {
+		// A feature counts against the client using the worst support
+		// reported for any of its versions or platforms.
+		support := ""
+		for _, res := range w.Results {
+			if strings.EqualFold(res.Family, family) && supportRank(res.Support) > supportRank(support) {
+				support = res.Support
+			}
+		}
+
+		switch support {
+		case HTMLCheckSupportNo:
+			unsupported++
+		case HTMLCheckSupportPartial:
+			partial++
+		}
+	}
+
+	tests := float64(r.Total.Tests)
+	score.Found = partial + unsupported
+	score.Partial = float64(partial) / tests * 100
+	score.Unsupported = float64(unsupported) / tests * 100
+	score.Supported = 100 - score.Partial - score.Unsupported
+
+	return score
+}
+
+// Families returns the sorted, de-duplicated list of email client families
+// that appear in the check results.
+func (r *HTMLCheckResponse) Families() []string {
+	if r == nil {
+		return nil
+	}
+
+	seen := make(map[string]struct{})
+	for _, w := range r.Warnings {
+		for _, res := range w.Results {
+			seen[res.Family] = struct{}{}
+		}
+	}
+
+	families := make([]string, 0, len(seen))
+	for f := range seen {
+		families = append(families, f)
+	}
+	sort.Strings(families)
+
+	return families
+}
+
+func supportRank(support string) int {
+	switch support {
+	case HTMLCheckSupportNo:
+		return 3
+	case HTMLCheckSupportPartial:
+		return 2
+	case HTMLCheckSupportYes:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func (r *HTMLCheckResponse) filterWarnings(match func(HTMLCheckResult) bool) []HTMLCheckWarning {
+	if r == nil {
+		return nil
+	}
+
+	var out []HTMLCheckWarning
+	for _, w := range r.Warnings {
+		for _, res := range w.Results {
+			if match(res) {
+				out = append(out, w)
+
+				break
+			}
+		}
+	}
+
+	return out
+}
+
+// IsOK reports whether the link responded with a successful (2xx or 3xx) status.
+func (lc *LinkCheck) IsOK() bool {
+	return lc.StatusCode >= http.StatusOK && lc.StatusCode < http.StatusBadRequest
+}
+
+// FailingLinks returns all links that could not be reached or returned an
+// error status code.
+func (r *LinkCheckResponse) FailingLinks() []LinkCheck {
+	if r == nil {
+		return nil
+	}
+
+	var out []LinkCheck
+	for _, link := range r.Links {
+		if !link.IsOK() {
+			out = append(out, link)
+		}
+	}
+
+	return out
+}
+
+// IsSpam reports whether the message should be treated as spam.
+// If threshold is greater than zero the message is spam when its score is
+// greater than or equal to threshold; otherwise SpamAssassin's own verdict is used.
+func (r *SpamAssassinCheckResponse) IsSpam(threshold float64) bool {
+	if r == nil {
+		return false
+	}
+
+	if threshold > 0 {
+		return r.Score >= threshold
+	}
+
+	return r.Flagged
+}
+
+// TriggeredRules returns the rules that increased the spam score, highest first.
+func (r *SpamAssassinCheckResponse) TriggeredRules() []SpamAssassinRule {
+	if r == nil {
+		return nil
+	}
+
+	var out []SpamAssassinRule
+	for _, rule := range r.Rules {
+		if rule.Score > 0 {
+			out = append(out, rule)
+		}
+	}
+
+	sort.SliceStable(out, func(i, j int) bool {
+		return out[i].Score > out[j].Score
+	})
+
+	return out
+}
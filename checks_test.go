@@ -0,0 +1,176 @@
+package mailpitclient
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const htmlCheckFixture = `{
+	"Platforms": {"ios": ["Apple Mail", "Gmail"], "windows": ["Outlook"]},
+	"Total": {"Nodes": 20, "Tests": 10, "Supported": 80, "Partial": 10, "Unsupported": 10},
+	"Warnings": [
+		{
+			"Slug": "css-display-flex",
+			"Title": "display:flex",
+			"Category": "css",
+			"Results": [
+				{"Name": "Gmail", "Platform": "ios", "Family": "gmail", "Support": "no"},
+				{"Name": "Outlook", "Platform": "windows", "Family": "outlook", "Support": "no"},
+				{"Name": "Apple Mail", "Platform": "ios", "Family": "apple-mail", "Support": "yes"}
+			]
+		},
+		{
+			"Slug": "html-svg",
+			"Title": "<svg> element",
+			"Category": "html",
+			"Results": [
+				{"Name": "Gmail", "Platform": "desktop-webmail", "Family": "gmail", "Support": "yes"},
+				{"Name": "Gmail", "Platform": "android", "Family": "gmail", "Support": "partial"}
+			]
+		}
+	]
+}`
+
+func TestHTMLCheckResponse_Helpers(t *testing.T) {
+	t.Parallel()
+
+	var resp HTMLCheckResponse
+	require.NoError(t, json.Unmarshal([]byte(htmlCheckFixture), &resp))
+
+	t.Run("CompatibilityScore", func(t *testing.T) {
+		t.Parallel()
+
+		require.InDelta(t, 85.0, resp.CompatibilityScore(), 0.001)
+		require.InDelta(t, 100.0, (&HTMLCheckResponse{}).CompatibilityScore(), 0.001)
+	})
+
+	t.Run("UnsupportedOn", func(t *testing.T) {
+		t.Parallel()
+
+		ios := resp.UnsupportedOn("iOS")
+		require.Len(t, ios, 1)
+		require.Equal(t, "css-display-flex", ios[0].Slug)
+		require.Empty(t, resp.UnsupportedOn("android"))
+	})
+
+	t.Run("UnsupportedBy", func(t *testing.T) {
+		t.Parallel()
+
+		require.Len(t, resp.UnsupportedBy("outlook"), 1)
+		require.Empty(t, resp.UnsupportedBy("apple-mail"))
+	})
+
+	t.Run("ClientSupport", func(t *testing.T) {
+		t.Parallel()
+
+		gmail := resp.ClientSupport("gmail")
+		require.Equal(t, 2, gmail.Found)
+		require.InDelta(t, 10.0, gmail.Unsupported, 0.001)
+		require.InDelta(t, 10.0, gmail.Partial, 0.001)
+		require.InDelta(t, 80.0, gmail.Supported, 0.001)
+
+		apple := resp.ClientSupport("apple-mail")
+		require.Equal(t, 0, apple.Found)
+		require.InDelta(t, 100.0, apple.Supported, 0.001)
+	})
+
+	t.Run("Families", func(t *testing.T) {
+		t.Parallel()
+
+		require.Equal(t, []string{"apple-mail", "gmail", "outlook"}, resp.Families())
+	})
+}
+
+func TestLinkCheck_UnmarshalJSON(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		jsonData   string
+		url        string
+		status     string
+		statusCode int
+	}{
+		{
+			name:       "current format",
+			jsonData:   `{"URL": "https://example.com", "StatusCode": 404, "Status": "Not Found"}`,
+			url:        "https://example.com",
+			status:     "Not Found",
+			statusCode: 404,
+		},
+		{
+			name:       "numeric status",
+			jsonData:   `{"url": "https://example.com", "status": 200}`,
+			url:        "https://example.com",
+			status:     "OK",
+			statusCode: 200,
+		},
+		{
+			name:       "connection error",
+			jsonData:   `{"URL": "https://example.invalid", "StatusCode": 0, "Status": "no such host"}`,
+			url:        "https://example.invalid",
+			status:     "no such host",
+			statusCode: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var link LinkCheck
+			require.NoError(t, json.Unmarshal([]byte(tt.jsonData), &link))
+			require.Equal(t, tt.url, link.URL)
+			require.Equal(t, tt.status, link.Status)
+			require.Equal(t, tt.statusCode, link.StatusCode)
+		})
+	}
+}
+
+func TestLinkCheckResponse_FailingLinks(t *testing.T) {
+	t.Parallel()
+
+	resp := &LinkCheckResponse{
+		Links: []LinkCheck{
+			{URL: "https://ok.example.com", StatusCode: 200},
+			{URL: "https://redirect.example.com", StatusCode: 301},
+			{URL: "https://missing.example.com", StatusCode: 404},
+			{URL: "https://unreachable.example.com", Status: "dial tcp: timeout"},
+		},
+	}
+
+	failing := resp.FailingLinks()
+	require.Len(t, failing, 2)
+	require.Equal(t, "https://missing.example.com", failing[0].URL)
+	require.Equal(t, "https://unreachable.example.com", failing[1].URL)
+}
+
+func TestSpamAssassinCheckResponse_Helpers(t *testing.T) {
+	t.Parallel()
+
+	var resp SpamAssassinCheckResponse
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"IsSpam": false,
+		"Score": 3.2,
+		"Rules": [
+			{"Name": "HTML_MESSAGE", "Description": "HTML included in message", "Score": 0.001},
+			{"Name": "MISSING_DATE", "Description": "Missing Date: header", "Score": 1.4},
+			{"Name": "DKIM_VALID", "Description": "Message has a valid DKIM signature", "Score": -0.1},
+			{"Name": "MISSING_MID", "Description": "Missing Message-Id: header", "Score": 1.8}
+		]
+	}`), &resp))
+
+	require.False(t, resp.IsSpam(0))
+	require.True(t, resp.IsSpam(3))
+	require.False(t, resp.IsSpam(5))
+
+	rules := resp.TriggeredRules()
+	require.Len(t, rules, 3)
+	require.Equal(t, "MISSING_MID", rules[0].Name)
+	require.Equal(t, "MISSING_DATE", rules[1].Name)
+
+	resp.Flagged = true
+	require.True(t, resp.IsSpam(0))
+}
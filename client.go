@@ -3,12 +3,14 @@
 package mailpitclient
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"strings"
 	"time"
 )
 
@@ -63,6 +65,10 @@ type Client interface {
 	GetChaosConfig(ctx context.Context) (*ChaosResponse, error)
 	SetChaosConfig(ctx context.Context, config *ChaosTriggers) (*ChaosResponse, error)
 
+	// Low-level requests for endpoints without a dedicated method
+	Do(ctx context.Context, method, path string, in, out any, opts ...RequestOption) error
+	DoRaw(ctx context.Context, method, path string, body io.Reader, opts ...RequestOption) (*http.Response, error)
+
 	// Utility methods
 	Close() error
 }
@@ -72,6 +78,10 @@ type Config struct {
 	HTTPClient *http.Client
 	BaseURL    string
 	APIPath    string
+	// WebRoot is the path Mailpit is served under (MP_WEBROOT), e.g.
+	// "/mailpit/". A path in BaseURL is treated the same way; if both are set
+	// WebRoot is appended to it. API, view and health routes resolve below it.
+	WebRoot    string
 	Username   string
 	Password   string
 	APIKey     string
@@ -79,6 +89,16 @@ type Config struct {
 	Timeout    time.Duration
 	MaxRetries int
 	RetryDelay time.Duration
+	// OnDecodeWarning, if set, receives every difference between a response
+	// and the client's types, such as unknown fields. Responses are still
+	// decoded leniently.
+	OnDecodeWarning func(DecodeWarning)
+	// PreserveReadState keeps GetMessage from marking unread messages as
+	// read. See PreserveReadState for enabling it per call.
+	PreserveReadState bool
+	// StrictDecoding turns decode warnings into errors matching
+	// ErrContractDrift, e.g. to detect Mailpit API changes in CI.
+	StrictDecoding bool
 }
 
 // DefaultConfig returns a default configuration.
@@ -100,6 +120,7 @@ func DefaultConfig() *Config {
 type client struct {
 	config    *Config
 	baseURL   *url.URL
+	rootURL   string
 	apiURL    string
 	userAgent string
 }
@@ -127,6 +148,13 @@ func NewClient(config *Config) (Client, error) {
 		}
 	}
 
+	if baseURL.Scheme == "" || baseURL.Host == "" {
+		return nil, &Error{
+			Type:    ErrorTypeConfig,
+			Message: fmt.Sprintf("invalid BaseURL %q: scheme and host are required", config.BaseURL),
+		}
+	}
+
 	if config.APIPath == "" {
 		config.APIPath = "/api/v1"
 	}
@@ -141,12 +169,17 @@ func NewClient(config *Config) (Client, error) {
 		config.UserAgent = "mailpit-go-client/1.0.0"
 	}
 
-	apiURL := baseURL.String() + config.APIPath
+	// Routes are resolved against the webroot, so strip anything that is not
+	// part of the path and normalise slashes before joining.
+	root := baseURL.JoinPath(strings.Trim(config.WebRoot, "/"))
+	root.RawQuery, root.Fragment = "", ""
+	rootURL := strings.TrimSuffix(root.String(), "/")
 
 	return &client{
 		config:    config,
 		baseURL:   baseURL,
-		apiURL:    apiURL,
+		rootURL:   rootURL,
+		apiURL:    rootURL + "/" + strings.Trim(config.APIPath, "/"),
 		userAgent: config.UserAgent,
 	}, nil
 }
@@ -161,11 +194,35 @@ func (c *client) Close() error {
 	return nil
 }
 
-// makeRequest performs an HTTP request with proper error handling and retries.
+// makeRequest performs an API request, relative to APIPath, with proper error
+// handling and retries.
 //
 //nolint:unparam
 func (c *client) makeRequest(ctx context.Context, method, endpoint string, body io.Reader) (*http.Response, error) {
-	u := c.apiURL + endpoint
+	return c.do(ctx, method, c.apiURL+endpoint, body, nil)
+}
+
+// makeWebRequest performs a request for a route outside the API, such as the
+// /view/ and /livez routes, relative to the webroot.
+func (c *client) makeWebRequest(ctx context.Context, method, endpoint string) (*http.Response, error) {
+	return c.do(ctx, method, c.rootURL+endpoint, nil, nil)
+}
+
+// do performs an HTTP request against an absolute URL, retrying network
+// errors, rate limiting and server errors. The body is buffered so that it can
+// be resent on retries. header overrides the default headers.
+func (c *client) do(ctx context.Context, method, u string, body io.Reader, header http.Header) (*http.Response, error) {
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = io.ReadAll(body); err != nil {
+			return nil, &Error{
+				Type:    ErrorTypeRequest,
+				Message: fmt.Sprintf("failed to read request body: %v", err),
+				Cause:   err,
+			}
+		}
+	}
 
 	var lastErr error
 	for attempt := 0; attempt <= c.config.MaxRetries; attempt++ {
@@ -177,7 +234,12 @@ func (c *client) makeRequest(ctx context.Context, method, endpoint string, body
 			}
 		}
 
-		req, err := http.NewRequestWithContext(ctx, method, u, body)
+		var reqBody io.Reader
+		if payload != nil {
+			reqBody = bytes.NewReader(payload)
+		}
+
+		req, err := http.NewRequestWithContext(ctx, method, u, reqBody)
 		if err != nil {
 			return nil, &Error{
 				Type:    ErrorTypeRequest,
@@ -187,15 +249,11 @@ func (c *client) makeRequest(ctx context.Context, method, endpoint string, body
 		}
 
 		// Set headers
-		req.Header.Set("User-Agent", c.userAgent)
+		c.setHeaders(req)
 		req.Header.Set("Content-Type", "application/json")
 		req.Header.Set("Accept", "application/json")
-
-		// Add authentication if configured
-		if c.config.APIKey != "" {
-			req.Header.Set("Authorization", "Bearer "+c.config.APIKey)
-		} else if c.config.Username != "" && c.config.Password != "" {
-			req.SetBasicAuth(c.config.Username, c.config.Password)
+		for key, values := range header {
+			req.Header[key] = values
 		}
 
 		resp, err := c.config.HTTPClient.Do(req)
@@ -218,12 +276,7 @@ func (c *client) makeRequest(ctx context.Context, method, endpoint string, body
 		defer resp.Body.Close()
 		b, _ := io.ReadAll(resp.Body)
 
-		lastErr = &Error{
-			Type:       ErrorTypeAPI,
-			Message:    fmt.Sprintf("API request failed with status %d", resp.StatusCode),
-			StatusCode: resp.StatusCode,
-			Response:   string(b),
-		}
+		lastErr = NewAPIError(resp.StatusCode, b)
 
 		// Don't retry on 4xx errors (except rate limiting)
 		if resp.StatusCode >= http.StatusBadRequest && resp.StatusCode < http.StatusInternalServerError && resp.StatusCode != http.StatusTooManyRequests {
@@ -234,6 +287,17 @@ func (c *client) makeRequest(ctx context.Context, method, endpoint string, body
 	return nil, lastErr
 }
 
+// setHeaders sets the User-Agent and, if configured, authentication headers.
+func (c *client) setHeaders(req *http.Request) {
+	req.Header.Set("User-Agent", c.userAgent)
+
+	if c.config.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.config.APIKey)
+	} else if c.config.Username != "" && c.config.Password != "" {
+		req.SetBasicAuth(c.config.Username, c.config.Password)
+	}
+}
+
 // parseResponse parses a JSON response into the given struct.
 func (c *client) parseResponse(resp *http.Response, target any) error {
 	defer resp.Body.Close()
@@ -255,7 +319,17 @@ func (c *client) parseResponse(resp *http.Response, target any) error {
 		return nil
 	}
 
-	if err = json.Unmarshal(body, target); err != nil {
+	err = json.Unmarshal(body, target)
+
+	var endpoint string
+	if resp.Request != nil {
+		endpoint = resp.Request.URL.Path
+	}
+	if checkErr := c.checkDecoding(endpoint, body, target); err == nil && checkErr != nil {
+		return checkErr
+	}
+
+	if err != nil {
 		return &Error{
 			Type:    ErrorTypeResponse,
 			Message: fmt.Sprintf("failed to parse JSON response: %v", err),
@@ -1,6 +1,7 @@
 package mailpitclient
 
 import (
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -48,6 +49,14 @@ func TestNewClient(t *testing.T) {
 			expectError: true,
 			errorType:   ErrorTypeConfig,
 		},
+		{
+			name: "BaseURL without scheme",
+			config: &Config{
+				BaseURL: "localhost:8025",
+			},
+			expectError: true,
+			errorType:   ErrorTypeConfig,
+		},
 	}
 
 	for _, tt := range tests {
@@ -70,6 +79,69 @@ func TestNewClient(t *testing.T) {
 	}
 }
 
+func TestClient_Routing(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		baseURL string // appended to the test server URL
+		webRoot string
+		apiPath string
+		prefix  string
+	}{
+		{name: "root", baseURL: "", prefix: ""},
+		{name: "trailing slash", baseURL: "/", prefix: ""},
+		{name: "webroot in BaseURL", baseURL: "/mailpit/", prefix: "/mailpit"},
+		{name: "webroot option", webRoot: "mailpit", prefix: "/mailpit"},
+		{name: "proxy prefix and webroot", baseURL: "/proxy", webRoot: "/mailpit/", prefix: "/proxy/mailpit"},
+		{name: "api path without slashes", baseURL: "/mailpit", apiPath: "api/v1/", prefix: "/mailpit"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var got []string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = append(got, r.Method+" "+r.URL.EscapedPath())
+				_, _ = w.Write([]byte("{}"))
+			}))
+			defer server.Close()
+
+			c, err := NewClient(&Config{
+				BaseURL:    server.URL + tt.baseURL + "?ignored=1",
+				WebRoot:    tt.webRoot,
+				APIPath:    tt.apiPath,
+				HTTPClient: &http.Client{Timeout: 5 * time.Second},
+			})
+			require.NoError(t, err)
+			defer c.Close()
+
+			ctx := t.Context()
+
+			_, err = c.GetMessage(ctx, "a/b?c")
+			require.NoError(t, err)
+			_, err = c.GetMessagePart(ctx, "msg 1", "1.2")
+			require.NoError(t, err)
+			_, err = c.GetMessageHTML(ctx, "msg-1")
+			require.NoError(t, err)
+			_, err = c.GetMessagePartText(ctx, "msg-1", "2")
+			require.NoError(t, err)
+			require.NoError(t, c.DeleteTag(ctx, "release/2025 #1"))
+			require.NoError(t, c.HealthCheck(ctx))
+
+			require.Equal(t, []string{
+				"GET " + tt.prefix + "/api/v1/message/a%2Fb%3Fc",
+				"GET " + tt.prefix + "/api/v1/message/msg%201/part/1.2",
+				"GET " + tt.prefix + "/view/msg-1.html",
+				"GET " + tt.prefix + "/view/msg-1/part/2.text",
+				"DELETE " + tt.prefix + "/api/v1/tags/release%2F2025%20%231",
+				"GET " + tt.prefix + "/livez",
+			}, got)
+		})
+	}
+}
+
 func TestDefaultConfig(t *testing.T) {
 	t.Parallel()
 
@@ -395,7 +467,7 @@ func TestClient_HealthCheck(t *testing.T) {
 			t.Parallel()
 
 			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-				require.True(t, strings.HasSuffix(r.URL.Path, "/info"))
+				require.Equal(t, "/livez", r.URL.Path)
 				w.Header().Set("Content-Type", "application/json")
 				w.WriteHeader(tt.serverStatus)
 				if tt.serverStatus == http.StatusOK {
@@ -437,11 +509,9 @@ func TestClient_GetChaosConfig(t *testing.T) {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusOK)
 		_, _ = w.Write([]byte(`{
-			"enabled": true,
-			"triggers": {
-				"accept_connections": 0.1,
-				"reject_senders": 0.2
-			}
+			"Authentication": {"ErrorCode": 451, "Probability": 0},
+			"Recipient": {"ErrorCode": 451, "Probability": 30},
+			"Sender": {"ErrorCode": 550, "Probability": 10}
 		}`))
 	}))
 	defer server.Close()
@@ -460,8 +530,9 @@ func TestClient_GetChaosConfig(t *testing.T) {
 	require.NoError(t, err)
 	require.NotNil(t, result)
 	require.True(t, result.Enabled)
-	require.InEpsilon(t, 0.1, result.Triggers.AcceptConnections, 0.01)
-	require.InEpsilon(t, 0.2, result.Triggers.RejectSenders, 0.01)
+	require.Equal(t, ChaosTrigger{ErrorCode: 550, Probability: 10}, result.Triggers.Sender)
+	require.Equal(t, ChaosTrigger{ErrorCode: 451, Probability: 30}, result.Triggers.Recipient)
+	require.False(t, result.Triggers.Authentication.Enabled())
 }
 
 func TestClient_SetChaosConfig(t *testing.T) {
@@ -470,14 +541,22 @@ func TestClient_SetChaosConfig(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		require.Equal(t, http.MethodPut, r.Method)
 		require.True(t, strings.HasSuffix(r.URL.Path, "/chaos"))
+
+		// Every trigger must be sent, including disabled ones
+		var body map[string]map[string]int
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		require.Equal(t, map[string]map[string]int{
+			"Sender":         {"ErrorCode": 550, "Probability": 10},
+			"Recipient":      {"ErrorCode": 451, "Probability": 30},
+			"Authentication": {"ErrorCode": DefaultChaosErrorCode, "Probability": 0},
+		}, body)
+
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusOK)
 		_, _ = w.Write([]byte(`{
-			"enabled": true,
-			"triggers": {
-				"accept_connections": 0.1,
-				"reject_senders": 0.2
-			}
+			"Authentication": {"ErrorCode": 451, "Probability": 0},
+			"Recipient": {"ErrorCode": 451, "Probability": 30},
+			"Sender": {"ErrorCode": 550, "Probability": 10}
 		}`))
 	}))
 	defer server.Close()
@@ -493,14 +572,21 @@ func TestClient_SetChaosConfig(t *testing.T) {
 	defer c.Close()
 
 	chaosConfig := &ChaosTriggers{
-		AcceptConnections: 0.1,
-		RejectSenders:     0.2,
+		Sender:    ChaosTrigger{ErrorCode: 550, Probability: 10},
+		Recipient: ChaosTrigger{Probability: 30},
 	}
 
 	result, err := c.SetChaosConfig(t.Context(), chaosConfig)
 	require.NoError(t, err)
 	require.NotNil(t, result)
 	require.True(t, result.Enabled)
+	require.Equal(t, 30, result.Triggers.Recipient.Probability)
+
+	_, err = c.SetChaosConfig(t.Context(), &ChaosTriggers{Recipient: ChaosTrigger{ErrorCode: 250, Probability: 30}})
+
+	var mailpitErr *Error
+	require.ErrorAs(t, err, &mailpitErr)
+	require.Equal(t, ErrorTypeValidation, mailpitErr.Type)
 }
 
 // Test Send Operations
@@ -580,17 +666,19 @@ func TestClient_GetMessageHTMLCheck(t *testing.T) {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusOK)
 		_, _ = w.Write([]byte(`{
-			"errors": [],
-			"warnings": [
+			"Platforms": {"ios": ["Apple Mail", "Gmail"]},
+			"Total": {"Nodes": 12, "Tests": 40, "Supported": 90, "Partial": 5, "Unsupported": 5},
+			"Warnings": [
 				{
-					"type": "warning",
-					"message": "Missing alt attribute",
-					"lastLine": 10,
-					"firstColumn": 1,
-					"lastColumn": 10,
-					"extract": "<img src='test'>",
-					"hiliteStart": 0,
-					"hiliteLength": 5
+					"Slug": "css-display-flex",
+					"Title": "display:flex",
+					"Category": "css",
+					"URL": "https://www.caniemail.com/features/css-display-flex/",
+					"Score": {"Found": 1, "Supported": 50, "Partial": 0, "Unsupported": 50},
+					"Results": [
+						{"Name": "Gmail", "Platform": "ios", "Family": "gmail", "Version": "2023-01", "Support": "no"},
+						{"Name": "Apple Mail", "Platform": "ios", "Family": "apple-mail", "Version": "16", "Support": "yes"}
+					]
 				}
 			]
 		}`))
@@ -610,7 +698,9 @@ func TestClient_GetMessageHTMLCheck(t *testing.T) {
 	result, err := c.GetMessageHTMLCheck(t.Context(), "test-id")
 	require.NoError(t, err)
 	require.NotNil(t, result)
-	require.Empty(t, result.Errors)
 	require.Len(t, result.Warnings, 1)
-	require.Equal(t, "warning", result.Warnings[0].Type)
+	require.Equal(t, "css-display-flex", result.Warnings[0].Slug)
+	require.Equal(t, HTMLCheckSupportNo, result.Warnings[0].Results[0].Support)
+	require.Equal(t, 40, result.Total.Tests)
+	require.Equal(t, []string{"Apple Mail", "Gmail"}, result.Platforms["ios"])
 }
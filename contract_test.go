@@ -0,0 +1,942 @@
+package mailpitclient
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// pinnedSwagger is Mailpit's OpenAPI (Swagger 2.0) specification, pinned so the
+// contract tests run offline. Refresh it with
+// "scripts/api-coverage.sh pin-spec <version>".
+//
+//go:embed testdata/mailpit-swagger.json
+var pinnedSwagger []byte
+
+// pinnedMailpitVersion is the Mailpit release testdata/mailpit-swagger.json
+// was taken from.
+const pinnedMailpitVersion = "v1.27.0"
+
+type contractSpec struct {
+	Paths       map[string]*contractPathItem `json:"paths"`
+	Definitions map[string]*contractSchema   `json:"definitions"`
+	Responses   map[string]*contractResponse `json:"responses"`
+
+	routes []contractRoute
+}
+
+type contractPathItem struct {
+	Get    *contractOperation `json:"get"`
+	Head   *contractOperation `json:"head"`
+	Post   *contractOperation `json:"post"`
+	Put    *contractOperation `json:"put"`
+	Patch  *contractOperation `json:"patch"`
+	Delete *contractOperation `json:"delete"`
+}
+
+type contractOperation struct {
+	Responses   map[string]*contractResponse `json:"responses"`
+	OperationID string                       `json:"operationId"`
+	Parameters  []contractParameter          `json:"parameters"`
+}
+
+type contractParameter struct {
+	Schema   *contractSchema `json:"schema"`
+	Name     string          `json:"name"`
+	In       string          `json:"in"`
+	Required bool            `json:"required"`
+}
+
+type contractResponse struct {
+	Schema *contractSchema `json:"schema"`
+	Ref    string          `json:"$ref"`
+}
+
+type contractSchema struct {
+	Properties map[string]*contractSchema `json:"properties"`
+	Items      *contractSchema            `json:"items"`
+	// AdditionalProperties is either a schema or a boolean.
+	AdditionalProperties json.RawMessage   `json:"additionalProperties"`
+	Ref                  string            `json:"$ref"`
+	Type                 string            `json:"type"`
+	Format               string            `json:"format"`
+	Required             []string          `json:"required"`
+	AllOf                []*contractSchema `json:"allOf"`
+}
+
+type contractRoute struct {
+	pattern  *regexp.Regexp
+	template string
+	item     *contractPathItem
+}
+
+func loadContractSpec(tb testing.TB) *contractSpec {
+	tb.Helper()
+
+	var spec contractSpec
+	require.NoError(tb, json.Unmarshal(pinnedSwagger, &spec), "parsing the pinned Mailpit %s spec", pinnedMailpitVersion)
+
+	param := regexp.MustCompile(`\\\{[^}]+\\}`)
+	for template, item := range spec.Paths {
+		pattern := param.ReplaceAllString(regexp.QuoteMeta(template), `[^/]+?`)
+		spec.routes = append(spec.routes, contractRoute{
+			pattern:  regexp.MustCompile("^" + pattern + "$"),
+			template: template,
+			item:     item,
+		})
+	}
+
+	sort.Slice(spec.routes, func(i, j int) bool {
+		return spec.routes[i].template < spec.routes[j].template
+	})
+
+	return &spec
+}
+
+func (item *contractPathItem) operation(method string) *contractOperation {
+	switch method {
+	case http.MethodGet:
+		return item.Get
+	case http.MethodHead:
+		return item.Head
+	case http.MethodPost:
+		return item.Post
+	case http.MethodPut:
+		return item.Put
+	case http.MethodPatch:
+		return item.Patch
+	case http.MethodDelete:
+		return item.Delete
+	default:
+		return nil
+	}
+}
+
+// match finds the operation for a request, returning the path template.
+func (s *contractSpec) match(method, path string) (string, *contractOperation) {
+	for _, route := range s.routes {
+		if route.pattern.MatchString(path) {
+			return route.template, route.item.operation(method)
+		}
+	}
+
+	return "", nil
+}
+
+// resolve follows $ref and merges allOf.
+func (s *contractSpec) resolve(schema *contractSchema) *contractSchema {
+	for schema != nil && schema.Ref != "" {
+		schema = s.Definitions[strings.TrimPrefix(schema.Ref, "#/definitions/")]
+	}
+
+	if schema == nil || len(schema.AllOf) == 0 {
+		return schema
+	}
+
+	merged := &contractSchema{Type: "object", Properties: make(map[string]*contractSchema)}
+	for _, part := range append([]*contractSchema{schema}, schema.AllOf...) {
+		part = s.resolve(&contractSchema{Ref: part.Ref, Properties: part.Properties, Required: part.Required})
+		for name, prop := range part.Properties {
+			merged.Properties[name] = prop
+		}
+		merged.Required = append(merged.Required, part.Required...)
+	}
+
+	return merged
+}
+
+// additional returns the schema of additionalProperties, if any.
+func (schema *contractSchema) additional() *contractSchema {
+	if len(schema.AdditionalProperties) == 0 || schema.AdditionalProperties[0] != '{' {
+		return nil
+	}
+
+	var ap contractSchema
+	if err := json.Unmarshal(schema.AdditionalProperties, &ap); err != nil {
+		return nil
+	}
+
+	return &ap
+}
+
+// property finds a property like encoding/json, which Mailpit decodes with:
+// exact name first, then case-insensitively.
+func (schema *contractSchema) property(name string) (*contractSchema, bool) {
+	if prop, ok := schema.Properties[name]; ok {
+		return prop, true
+	}
+
+	for key, prop := range schema.Properties {
+		if strings.EqualFold(key, name) {
+			return prop, true
+		}
+	}
+
+	return nil, false
+}
+
+// responseSchema returns the schema of the operation's successful response.
+func (s *contractSpec) responseSchema(op *contractOperation) *contractSchema {
+	resp := op.Responses["200"]
+	if resp == nil {
+		return nil
+	}
+
+	if resp.Ref != "" {
+		resp = s.Responses[strings.TrimPrefix(resp.Ref, "#/responses/")]
+	}
+
+	if resp == nil {
+		return nil
+	}
+
+	return s.resolve(resp.Schema)
+}
+
+// sampleID is used for every sample string, so that lookups of the message
+// the test cases use, such as the read state, find it.
+const sampleID = "msg-1"
+
+// sample builds a response with every property of schema set.
+func (s *contractSpec) sample(schema *contractSchema) any {
+	schema = s.resolve(schema)
+	if schema == nil {
+		return nil
+	}
+
+	switch schema.Type {
+	case "object", "":
+		obj := make(map[string]any)
+		for name, prop := range schema.Properties {
+			obj[name] = s.sample(prop)
+		}
+		if ap := schema.additional(); ap != nil {
+			obj["key"] = s.sample(ap)
+		}
+
+		return obj
+	case "array":
+		return []any{s.sample(schema.Items)}
+	case "string":
+		if schema.Format == "date-time" {
+			return "2024-01-02T03:04:05Z"
+		}
+
+		return sampleID
+	case "integer":
+		return 1
+	case "number":
+		return 1.5
+	case "boolean":
+		return true
+	default:
+		return nil
+	}
+}
+
+// validate reports where v, a JSON request body, differs from schema.
+func (s *contractSpec) validate(v any, schema *contractSchema, path string, report func(path, msg string)) {
+	schema = s.resolve(schema)
+	if schema == nil || v == nil {
+		return
+	}
+
+	mismatch := func(want string) {
+		report(path, fmt.Sprintf("expected %s, got %s", want, jsonKind(v)))
+	}
+
+	switch schema.Type {
+	case "object", "":
+		obj, ok := v.(map[string]any)
+		if !ok {
+			mismatch("object")
+
+			return
+		}
+
+		for _, key := range sortedKeys(obj) {
+			prop, ok := schema.property(key)
+			if !ok {
+				prop = schema.additional()
+			}
+			if prop == nil {
+				report(path+"."+key, "not in the spec")
+
+				continue
+			}
+			s.validate(obj[key], prop, path+"."+key, report)
+		}
+
+		for _, name := range schema.Required {
+			if !hasKeyFold(obj, name) {
+				report(path+"."+name, "missing required field")
+			}
+		}
+	case "array":
+		arr, ok := v.([]any)
+		if !ok {
+			mismatch("array")
+
+			return
+		}
+
+		for i, elem := range arr {
+			s.validate(elem, schema.Items, fmt.Sprintf("%s[%d]", path, i), report)
+		}
+	case "string":
+		if _, ok := v.(string); !ok {
+			mismatch("string")
+		}
+	case "integer", "number":
+		if _, ok := v.(float64); !ok {
+			mismatch("number")
+		}
+	case "boolean":
+		if _, ok := v.(bool); !ok {
+			mismatch("boolean")
+		}
+	}
+}
+
+// clientOnly reports fields of the client type t that schema does not define.
+func (s *contractSpec) clientOnly(t reflect.Type, schema *contractSchema, path string, report func(path, msg string)) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	schema = s.resolve(schema)
+	if schema == nil {
+		return
+	}
+
+	switch t {
+	case timeType:
+		return
+	case attachmentListType:
+		t = reflect.TypeFor[[]Attachment]()
+	case chaosResponseType:
+		// Enabled is set by the client, not sent by Mailpit.
+		t = reflect.TypeFor[ChaosTriggers]()
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		fields := jsonFields(t)
+		names := make([]string, 0, len(fields))
+		for name := range fields {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			prop, ok := schema.property(name)
+			if !ok {
+				report(path+"."+name, "not in the spec")
+
+				continue
+			}
+			s.clientOnly(fields[name], prop, path+"."+name, report)
+		}
+	case reflect.Slice, reflect.Array:
+		if schema.Items != nil {
+			s.clientOnly(t.Elem(), schema.Items, path+"[]", report)
+		}
+	case reflect.Map:
+		if ap := schema.additional(); ap != nil {
+			s.clientOnly(t.Elem(), ap, path+".*", report)
+		}
+	default:
+	}
+}
+
+func hasKeyFold(obj map[string]any, name string) bool {
+	for key := range obj {
+		if strings.EqualFold(key, name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// contractServer answers client requests with samples of the spec's response
+// schemas and records where the requests differ from the spec.
+type contractServer struct {
+	spec *contractSpec
+
+	mu       sync.Mutex
+	findings []string
+}
+
+func (cs *contractServer) report(format string, args ...any) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	cs.findings = append(cs.findings, fmt.Sprintf(format, args...))
+}
+
+func (cs *contractServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+
+	template, op := cs.spec.match(r.Method, r.URL.Path)
+	if op == nil {
+		cs.report("%s %s: no such operation in the spec", r.Method, r.URL.Path)
+		http.Error(w, "Not found", http.StatusNotFound)
+
+		return
+	}
+
+	route := r.Method + " " + template
+	cs.checkRequest(route, op, r, body)
+
+	schema := cs.spec.responseSchema(op)
+	if schema == nil || schema.Type == "string" {
+		_, _ = w.Write([]byte("ok"))
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(cs.spec.sample(schema))
+}
+
+func (cs *contractServer) checkRequest(route string, op *contractOperation, r *http.Request, body []byte) {
+	declared := make(map[string]bool)
+	var bodyParam *contractParameter
+
+	for i, p := range op.Parameters {
+		switch p.In {
+		case "query":
+			declared[p.Name] = true
+			if p.Required && !r.URL.Query().Has(p.Name) {
+				cs.report("%s: missing required query parameter %q", route, p.Name)
+			}
+		case "body":
+			bodyParam = &op.Parameters[i]
+		}
+	}
+
+	keys := make([]string, 0, len(r.URL.Query()))
+	for key := range r.URL.Query() {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if !declared[key] {
+			cs.report("%s: query parameter %q is not in the spec", route, key)
+		}
+	}
+
+	body = bytes.TrimSpace(body)
+	switch {
+	case bodyParam == nil && len(body) > 0:
+		cs.report("%s: request body is not in the spec", route)
+	case bodyParam == nil:
+	case len(body) == 0:
+		if bodyParam.Required {
+			cs.report("%s: missing required request body", route)
+		}
+	default:
+		var v any
+		if err := json.Unmarshal(body, &v); err != nil {
+			cs.report("%s: request body is not JSON", route)
+
+			return
+		}
+
+		cs.spec.validate(v, bodyParam.Schema, "$", func(path, msg string) {
+			cs.report("%s: request body %s: %s", route, path, msg)
+		})
+	}
+}
+
+// runContract performs call against a contract server and returns the
+// differences from the spec, including decode warnings for the responses.
+func runContract(t *testing.T, spec *contractSpec, call func(context.Context, Client) error) []string {
+	t.Helper()
+
+	cs := &contractServer{spec: spec}
+	server := httptest.NewServer(cs)
+	t.Cleanup(server.Close)
+
+	c, err := NewClient(&Config{
+		BaseURL:    server.URL,
+		HTTPClient: &http.Client{Timeout: 5 * time.Second},
+		MaxRetries: 0,
+		OnDecodeWarning: func(w DecodeWarning) {
+			template, _ := spec.match(http.MethodGet, w.Endpoint)
+			if template == "" {
+				template = w.Endpoint
+			}
+			cs.report("%s: response %s: %s", template, w.Path, w.Message)
+		},
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = c.Close() })
+
+	// Unknown operations are answered with 404 and already reported.
+	if err := call(t.Context(), c); err != nil && !errors.Is(err, ErrNotFound) {
+		cs.report("call failed: %v", err)
+	}
+
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	return cs.findings
+}
+
+// TestContract checks every request the client builds against the pinned
+// Mailpit spec. drift lists the known differences; fix the client, or the
+// list when the spec is refreshed, so that it stays exact.
+func TestContract(t *testing.T) {
+	t.Parallel()
+
+	spec := loadContractSpec(t)
+
+	tests := []struct {
+		name  string
+		call  func(context.Context, Client) error
+		drift []string
+	}{
+		{
+			name: "ListMessages",
+			call: func(ctx context.Context, c Client) error {
+				_, err := c.ListMessages(ctx, &ListOptions{Start: 10, Limit: 5, Query: "q", Tag: "t", Sort: "s"})
+
+				return err
+			},
+			drift: []string{
+				`GET /api/v1/messages: query parameter "query" is not in the spec`,
+				`GET /api/v1/messages: query parameter "sort" is not in the spec`,
+				`GET /api/v1/messages: query parameter "tag" is not in the spec`,
+			},
+		},
+		{
+			name: "SearchMessages",
+			call: func(ctx context.Context, c Client) error {
+				_, err := c.SearchMessages(ctx, "subject:hi", &SearchOptions{Start: 10, Limit: 5, Tag: "t", Sort: "s"})
+
+				return err
+			},
+			drift: []string{
+				`GET /api/v1/search: query parameter "sort" is not in the spec`,
+				`GET /api/v1/search: query parameter "tag" is not in the spec`,
+			},
+		},
+		{
+			name: "GetMessage",
+			call: func(ctx context.Context, c Client) error {
+				_, err := c.GetMessage(ctx, "msg-1")
+
+				return err
+			},
+		},
+		{
+			name: "GetMessage preserving read state",
+			call: func(ctx context.Context, c Client) error {
+				_, err := c.GetMessage(PreserveReadState(ctx), sampleID)
+
+				return err
+			},
+		},
+		{
+			name: "GetMessageHeaders",
+			call: func(ctx context.Context, c Client) error {
+				_, err := c.GetMessageHeaders(ctx, "msg-1")
+
+				return err
+			},
+		},
+		{
+			name: "GetMessageSource",
+			call: func(ctx context.Context, c Client) error {
+				_, err := c.GetMessageSource(ctx, "msg-1")
+
+				return err
+			},
+		},
+		{
+			name: "GetMessageAttachment",
+			call: func(ctx context.Context, c Client) error {
+				_, err := c.GetMessageAttachment(ctx, "msg-1", "2")
+
+				return err
+			},
+		},
+		{
+			name: "GetMessagePart",
+			call: func(ctx context.Context, c Client) error {
+				_, err := c.GetMessagePart(ctx, "msg-1", "2")
+
+				return err
+			},
+		},
+		{
+			name: "GetMessagePartThumbnail",
+			call: func(ctx context.Context, c Client) error {
+				_, err := c.GetMessagePartThumbnail(ctx, "msg-1", "2")
+
+				return err
+			},
+		},
+		{
+			name: "GetMessageHTMLCheck",
+			call: func(ctx context.Context, c Client) error {
+				_, err := c.GetMessageHTMLCheck(ctx, "msg-1")
+
+				return err
+			},
+		},
+		{
+			name: "GetMessageLinkCheck",
+			call: func(ctx context.Context, c Client) error {
+				_, err := c.GetMessageLinkCheck(ctx, "msg-1")
+
+				return err
+			},
+		},
+		{
+			name: "GetMessageSpamAssassinCheck",
+			call: func(ctx context.Context, c Client) error {
+				_, err := c.GetMessageSpamAssassinCheck(ctx, "msg-1")
+
+				return err
+			},
+		},
+		{
+			name: "GetMessageEvents",
+			call: func(ctx context.Context, c Client) error {
+				_, err := c.GetMessageEvents(ctx, "msg-1")
+
+				return err
+			},
+			drift: []string{
+				"GET /api/v1/message/msg-1/events: no such operation in the spec",
+			},
+		},
+		{
+			name: "MarkMessageRead",
+			call: func(ctx context.Context, c Client) error {
+				return c.MarkMessageRead(ctx, "msg-1")
+			},
+		},
+		{
+			name: "MarkMessageUnread",
+			call: func(ctx context.Context, c Client) error {
+				return c.MarkMessageUnread(ctx, "msg-1")
+			},
+		},
+		{
+			name: "DeleteMessage",
+			call: func(ctx context.Context, c Client) error {
+				return c.DeleteMessage(ctx, "msg-1")
+			},
+		},
+		{
+			name: "DeleteAllMessages",
+			call: func(ctx context.Context, c Client) error {
+				return c.DeleteAllMessages(ctx)
+			},
+		},
+		{
+			name: "DeleteSearchResults",
+			call: func(ctx context.Context, c Client) error {
+				return c.DeleteSearchResults(ctx, "subject:hi")
+			},
+		},
+		{
+			name: "ReleaseMessage",
+			call: func(ctx context.Context, c Client) error {
+				return c.ReleaseMessage(ctx, "msg-1", &ReleaseMessageRequest{To: []string{"a@example.com"}, Host: "smtp.example.com", Port: 25})
+			},
+			drift: []string{
+				"POST /api/v1/message/{ID}/release: request body $.host: not in the spec",
+				"POST /api/v1/message/{ID}/release: request body $.port: not in the spec",
+			},
+		},
+		{
+			name: "SendMessage",
+			call: func(ctx context.Context, c Client) error {
+				_, err := c.SendMessage(ctx, &SendMessageRequest{
+					From:        Address{Address: "from@example.com", Name: "From"},
+					To:          []Address{{Address: "to@example.com"}},
+					Cc:          []Address{{Address: "cc@example.com"}},
+					Bcc:         []Address{{Address: "bcc@example.com"}},
+					ReplyTo:     []Address{{Address: "reply@example.com"}},
+					Subject:     "Hello",
+					Text:        "text",
+					HTML:        "<p>html</p>",
+					Headers:     map[string]string{"X-Test": "1"},
+					Tags:        []string{"tag"},
+					Attachments: []SendAttachment{{Filename: "a.txt", ContentType: "text/plain", Content: "YQ=="}},
+				})
+
+				return err
+			},
+			drift: []string{
+				"POST /api/v1/send: request body $.attachments[0].content-type: not in the spec",
+				"POST /api/v1/send: request body $.bcc[0]: expected string, got object",
+				"POST /api/v1/send: request body $.cc[0].Address: not in the spec",
+				"POST /api/v1/send: request body $.cc[0].Email: missing required field",
+				"POST /api/v1/send: request body $.from.Address: not in the spec",
+				"POST /api/v1/send: request body $.from.Email: missing required field",
+				"POST /api/v1/send: request body $.reply-to: not in the spec",
+				"POST /api/v1/send: request body $.to[0].Address: not in the spec",
+				"POST /api/v1/send: request body $.to[0].Email: missing required field",
+			},
+		},
+		{
+			name: "GetServerInfo",
+			call: func(ctx context.Context, c Client) error {
+				_, err := c.GetServerInfo(ctx)
+
+				return err
+			},
+		},
+		{
+			name: "HealthCheck",
+			call: func(ctx context.Context, c Client) error {
+				return c.HealthCheck(ctx)
+			},
+			drift: []string{
+				"GET /livez: no such operation in the spec",
+			},
+		},
+		{
+			name: "Ping",
+			call: func(ctx context.Context, c Client) error {
+				return c.Ping(ctx)
+			},
+			drift: []string{
+				"HEAD /api/v1/info: no such operation in the spec",
+			},
+		},
+		{
+			name: "GetStats",
+			call: func(ctx context.Context, c Client) error {
+				_, err := c.GetStats(ctx)
+
+				return err
+			},
+			drift: []string{
+				"GET /api/v1/stats: no such operation in the spec",
+			},
+		},
+		{
+			name: "GetWebUIConfig",
+			call: func(ctx context.Context, c Client) error {
+				_, err := c.GetWebUIConfig(ctx)
+
+				return err
+			},
+		},
+		{
+			name: "GetTags",
+			call: func(ctx context.Context, c Client) error {
+				_, err := c.GetTags(ctx)
+
+				return err
+			},
+		},
+		{
+			name: "SetTags",
+			call: func(ctx context.Context, c Client) error {
+				_, err := c.SetTags(ctx, []string{"a", "b"})
+
+				return err
+			},
+			drift: []string{
+				"PUT /api/v1/tags: request body $: expected object, got array",
+				"/api/v1/tags: response $: response is not valid JSON",
+				"call failed: mailpit response error: failed to parse JSON response: invalid character 'o' looking for beginning of value",
+			},
+		},
+		{
+			name: "SetMessageTags",
+			call: func(ctx context.Context, c Client) error {
+				return c.SetMessageTags(ctx, "a", []string{"msg-1"})
+			},
+			drift: []string{
+				"PUT /api/v1/tags/{Tag}: request body $: expected object, got array",
+			},
+		},
+		{
+			name: "DeleteTag",
+			call: func(ctx context.Context, c Client) error {
+				return c.DeleteTag(ctx, "a")
+			},
+		},
+		{
+			name: "GetChaosConfig",
+			call: func(ctx context.Context, c Client) error {
+				_, err := c.GetChaosConfig(ctx)
+
+				return err
+			},
+		},
+		{
+			name: "SetChaosConfig",
+			call: func(ctx context.Context, c Client) error {
+				_, err := c.SetChaosConfig(ctx, &ChaosTriggers{Sender: ChaosTrigger{ErrorCode: 451, Probability: 50}})
+
+				return err
+			},
+		},
+		{
+			name: "GetMessageHTML",
+			call: func(ctx context.Context, c Client) error {
+				_, err := c.GetMessageHTML(ctx, "msg-1")
+
+				return err
+			},
+		},
+		{
+			name: "GetMessageText",
+			call: func(ctx context.Context, c Client) error {
+				_, err := c.GetMessageText(ctx, "msg-1")
+
+				return err
+			},
+		},
+		{
+			name: "GetMessageRaw",
+			call: func(ctx context.Context, c Client) error {
+				_, err := c.GetMessageRaw(ctx, "msg-1")
+
+				return err
+			},
+			drift: []string{
+				"GET /view/msg-1.raw: no such operation in the spec",
+			},
+		},
+		{
+			name: "GetMessagePartHTML",
+			call: func(ctx context.Context, c Client) error {
+				_, err := c.GetMessagePartHTML(ctx, "msg-1", "2")
+
+				return err
+			},
+			drift: []string{
+				"GET /view/msg-1/part/2.html: no such operation in the spec",
+			},
+		},
+		{
+			name: "GetMessagePartText",
+			call: func(ctx context.Context, c Client) error {
+				_, err := c.GetMessagePartText(ctx, "msg-1", "2")
+
+				return err
+			},
+			drift: []string{
+				"GET /view/msg-1/part/2.text: no such operation in the spec",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			require.Equal(t, tt.drift, runContract(t, spec, tt.call),
+				"requests differ from the Mailpit %s spec", pinnedMailpitVersion)
+		})
+	}
+}
+
+// TestContract_ResponseTypes checks the client's response types against the
+// spec definitions in both directions: fields Mailpit sends that the client
+// drops, and fields the client expects that Mailpit does not send.
+func TestContract_ResponseTypes(t *testing.T) {
+	t.Parallel()
+
+	spec := loadContractSpec(t)
+
+	tests := []struct {
+		target     any
+		definition string
+		drift      []string
+	}{
+		{
+			target:     &Message{},
+			definition: "Message",
+			drift: []string{
+				"Message: client expects $.Created: not in the spec",
+				"Message: client expects $.Read: not in the spec",
+			},
+		},
+		{target: &MessageSummary{}, definition: "MessageSummary"},
+		{
+			target:     &MessagesResponse{},
+			definition: "MessagesSummary",
+			drift: []string{
+				"MessagesSummary: client expects $.count: not in the spec",
+			},
+		},
+		{target: &Attachment{}, definition: "Attachment"},
+		{target: &ServerInfo{}, definition: "AppInformation"},
+		{
+			target:     &WebUIConfig{},
+			definition: "WebUIConfiguration",
+			drift: []string{
+				"WebUIConfiguration: client expects $.MessageRelay.RecipientAllowlist: not in the spec",
+			},
+		},
+		{target: &ChaosResponse{}, definition: "ChaosTriggers"},
+		{
+			target:     &HTMLCheckResponse{},
+			definition: "HTMLCheckResponse",
+		},
+		{
+			target:     &LinkCheckResponse{},
+			definition: "LinkCheckResponse",
+			drift: []string{
+				"LinkCheckResponse: client expects $.Links[].Error: not in the spec",
+			},
+		},
+		{
+			target:     &SpamAssassinCheckResponse{},
+			definition: "SpamAssassinResponse",
+		},
+		{target: &SendMessageResponse{}, definition: "SendMessageConfirmation"},
+		{target: &map[string][]string{}, definition: "MessageHeadersResponse"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.definition, func(t *testing.T) {
+			t.Parallel()
+
+			schema := spec.Definitions[tt.definition]
+			require.NotNil(t, schema, "definition %s is not in the spec", tt.definition)
+
+			body, err := json.Marshal(spec.sample(schema))
+			require.NoError(t, err)
+
+			var drift []string
+			for _, w := range decodeWarnings(tt.definition, body, reflect.TypeOf(tt.target)) {
+				drift = append(drift, fmt.Sprintf("%s: Mailpit sends %s: %s", tt.definition, w.Path, w.Message))
+			}
+
+			spec.clientOnly(reflect.TypeOf(tt.target), schema, "$", func(path, msg string) {
+				drift = append(drift, fmt.Sprintf("%s: client expects %s: %s", tt.definition, path, msg))
+			})
+
+			require.Equal(t, tt.drift, drift, "response types differ from the Mailpit %s spec", pinnedMailpitVersion)
+		})
+	}
+}
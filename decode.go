@@ -0,0 +1,326 @@
+package mailpitclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DecodeWarningKind classifies a difference between a response and the
+// client's types.
+type DecodeWarningKind string
+
+const (
+	// DecodeWarningUnknownField is a response field the client does not know
+	DecodeWarningUnknownField DecodeWarningKind = "unknown_field"
+
+	// DecodeWarningTypeMismatch is a value of a different JSON type than expected
+	DecodeWarningTypeMismatch DecodeWarningKind = "type_mismatch"
+
+	// DecodeWarningShape is a value the client tolerates but that indicates a
+	// changed shape, such as an attachment list that is neither an array nor 0
+	DecodeWarningShape DecodeWarningKind = "shape"
+)
+
+// DecodeWarning describes one difference between a Mailpit response and the
+// client's types, found when strict decoding or a warning hook is enabled.
+type DecodeWarning struct {
+	Endpoint string            `json:"endpoint"`
+	Path     string            `json:"path"`
+	Kind     DecodeWarningKind `json:"kind"`
+	Message  string            `json:"message"`
+}
+
+// String formats the warning as "endpoint: path: message".
+func (w DecodeWarning) String() string {
+	return fmt.Sprintf("%s: %s: %s", w.Endpoint, w.Path, w.Message)
+}
+
+// DecodeWarnings is the cause of the error returned in strict decoding mode.
+// It matches ErrContractDrift with errors.Is.
+type DecodeWarnings []DecodeWarning
+
+// Error implements the error interface.
+func (ws DecodeWarnings) Error() string {
+	lines := make([]string, 0, len(ws))
+	for _, w := range ws {
+		lines = append(lines, w.String())
+	}
+
+	return "response does not match client types: " + strings.Join(lines, "; ")
+}
+
+// Is reports whether target is ErrContractDrift.
+func (ws DecodeWarnings) Is(target error) bool {
+	return target == ErrContractDrift //nolint:errorlint // sentinel comparison
+}
+
+// checkDecoding compares the response body with the type of target and
+// reports the differences to the warning hook, returning them as an error in
+// strict mode. The target has already been decoded leniently.
+func (c *client) checkDecoding(endpoint string, body []byte, target any) error {
+	if !c.config.StrictDecoding && c.config.OnDecodeWarning == nil {
+		return nil
+	}
+
+	warnings := decodeWarnings(endpoint, body, reflect.TypeOf(target))
+
+	if c.config.OnDecodeWarning != nil {
+		for _, w := range warnings {
+			c.config.OnDecodeWarning(w)
+		}
+	}
+
+	if c.config.StrictDecoding && len(warnings) > 0 {
+		return &Error{
+			Type:    ErrorTypeResponse,
+			Message: fmt.Sprintf("response from %s does not match client types (%d warnings)", endpoint, len(warnings)),
+			Cause:   warnings,
+		}
+	}
+
+	return nil
+}
+
+// decodeWarnings walks the generic JSON value of body alongside t.
+func decodeWarnings(endpoint string, body []byte, t reflect.Type) DecodeWarnings {
+	var v any
+	if err := json.Unmarshal(body, &v); err != nil {
+		return DecodeWarnings{{Endpoint: endpoint, Path: "$", Kind: DecodeWarningShape, Message: "response is not valid JSON"}}
+	}
+
+	var warnings DecodeWarnings
+	walkDecoded(v, t, "$", func(path string, kind DecodeWarningKind, msg string) {
+		warnings = append(warnings, DecodeWarning{Endpoint: endpoint, Path: path, Kind: kind, Message: msg})
+	})
+
+	return warnings
+}
+
+var (
+	timeType           = reflect.TypeFor[time.Time]()
+	attachmentListType = reflect.TypeFor[AttachmentList]()
+	linkCheckType      = reflect.TypeFor[LinkCheck]()
+	chaosResponseType  = reflect.TypeFor[ChaosResponse]()
+	unmarshalerType    = reflect.TypeFor[json.Unmarshaler]()
+)
+
+// linkCheckShape is the wire format LinkCheck.UnmarshalJSON accepts, where
+// Status is either the status text or, in older versions, the numeric code.
+type linkCheckShape struct {
+	Status     any    `json:"Status"`
+	URL        string `json:"URL"`
+	Error      string `json:"Error"`
+	StatusCode int    `json:"StatusCode"`
+}
+
+type warnFunc func(path string, kind DecodeWarningKind, msg string)
+
+//nolint:gocyclo // one case per JSON kind
+func walkDecoded(v any, t reflect.Type, path string, warn warnFunc) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	if v == nil {
+		return
+	}
+
+	mismatch := func(want string) {
+		warn(path, DecodeWarningTypeMismatch, fmt.Sprintf("expected %s, got %s", want, jsonKind(v)))
+	}
+
+	switch t {
+	case timeType:
+		if s, ok := v.(string); !ok {
+			mismatch("string")
+		} else if _, err := time.Parse(time.RFC3339Nano, s); err != nil {
+			warn(path, DecodeWarningTypeMismatch, fmt.Sprintf("invalid RFC 3339 time %q", s))
+		}
+
+		return
+	case attachmentListType:
+		switch v := v.(type) {
+		case []any:
+			walkDecoded(v, reflect.TypeFor[[]Attachment](), path, warn)
+		case float64:
+			// Mailpit reports an empty list as 0.
+			if v != 0 {
+				warn(path, DecodeWarningShape, fmt.Sprintf("expected array or 0, got number %v", v))
+			}
+		default:
+			warn(path, DecodeWarningShape, "expected array or 0, got "+jsonKind(v))
+		}
+
+		return
+	case linkCheckType:
+		walkDecoded(v, reflect.TypeFor[linkCheckShape](), path, warn)
+
+		return
+	case chaosResponseType:
+		walkDecoded(v, reflect.TypeFor[ChaosTriggers](), path, warn)
+
+		return
+	}
+
+	// Other custom decoders define their own wire format.
+	if reflect.PointerTo(t).Implements(unmarshalerType) {
+		return
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		obj, ok := v.(map[string]any)
+		if !ok {
+			mismatch("object")
+
+			return
+		}
+
+		fields := jsonFields(t)
+		for _, key := range sortedKeys(obj) {
+			field, ok := lookupField(fields, key)
+			if !ok {
+				warn(path+"."+key, DecodeWarningUnknownField, "unknown field")
+
+				continue
+			}
+			walkDecoded(obj[key], field, path+"."+key, warn)
+		}
+	case reflect.Map:
+		obj, ok := v.(map[string]any)
+		if !ok {
+			mismatch("object")
+
+			return
+		}
+
+		for _, key := range sortedKeys(obj) {
+			walkDecoded(obj[key], t.Elem(), path+"."+key, warn)
+		}
+	case reflect.Slice, reflect.Array:
+		arr, ok := v.([]any)
+		if !ok {
+			mismatch("array")
+
+			return
+		}
+
+		for i, elem := range arr {
+			walkDecoded(elem, t.Elem(), path+"["+strconv.Itoa(i)+"]", warn)
+		}
+	case reflect.String:
+		if _, ok := v.(string); !ok {
+			mismatch("string")
+		}
+	case reflect.Bool:
+		if _, ok := v.(bool); !ok {
+			mismatch("boolean")
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if n, ok := v.(float64); !ok {
+			mismatch("integer")
+		} else if n != math.Trunc(n) {
+			warn(path, DecodeWarningTypeMismatch, fmt.Sprintf("expected integer, got %v", n))
+		}
+	case reflect.Float32, reflect.Float64:
+		if _, ok := v.(float64); !ok {
+			mismatch("number")
+		}
+	case reflect.Interface:
+		// Any value is accepted.
+	case reflect.Invalid, reflect.Uintptr, reflect.Complex64, reflect.Complex128, reflect.Chan,
+		reflect.Func, reflect.Pointer, reflect.UnsafePointer:
+		// Not used in response types.
+	}
+}
+
+// jsonFields maps the JSON names of t's fields, including promoted fields of
+// embedded structs, to their types.
+func jsonFields(t reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type)
+
+	for i := range t.NumField() {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+
+		name, _, _ := strings.Cut(tag, ",")
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				for k, v := range jsonFields(ft) {
+					if _, ok := fields[k]; !ok {
+						fields[k] = v
+					}
+				}
+
+				continue
+			}
+		}
+
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields[name] = f.Type
+	}
+
+	return fields
+}
+
+// lookupField finds a field like encoding/json does: exact name first, then
+// case-insensitively.
+func lookupField(fields map[string]reflect.Type, key string) (reflect.Type, bool) {
+	if t, ok := fields[key]; ok {
+		return t, true
+	}
+
+	for name, t := range fields {
+		if strings.EqualFold(name, key) {
+			return t, true
+		}
+	}
+
+	return nil, false
+}
+
+func sortedKeys(obj map[string]any) []string {
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+func jsonKind(v any) string {
+	switch v.(type) {
+	case map[string]any:
+		return "object"
+	case []any:
+		return "array"
+	case string:
+		return "string"
+	case float64:
+		return "number"
+	case bool:
+		return "boolean"
+	default:
+		return "null"
+	}
+}
@@ -0,0 +1,173 @@
+package mailpitclient
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeWarnings(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		body     string
+		target   any
+		expected DecodeWarnings
+	}{
+		{
+			name:   "matching response",
+			body:   `{"ID":"1","Read":true,"Tags":["a"],"Created":"2024-01-01T00:00:00Z","Attachments":[{"PartID":"2","Size":10}]}`,
+			target: &Message{},
+		},
+		{
+			name:   "unknown field",
+			body:   `{"ID":"1","NewField":1,"From":{"Name":"A","Address":"a@example.com","Extra":true}}`,
+			target: &Message{},
+			expected: DecodeWarnings{
+				{Endpoint: "/test", Path: "$.From.Extra", Kind: DecodeWarningUnknownField, Message: "unknown field"},
+				{Endpoint: "/test", Path: "$.NewField", Kind: DecodeWarningUnknownField, Message: "unknown field"},
+			},
+		},
+		{
+			name:   "type mismatch",
+			body:   `{"ID":1,"Size":"big","Read":"yes","Tags":"a","Created":"yesterday"}`,
+			target: &Message{},
+			expected: DecodeWarnings{
+				{Endpoint: "/test", Path: "$.Created", Kind: DecodeWarningTypeMismatch, Message: `invalid RFC 3339 time "yesterday"`},
+				{Endpoint: "/test", Path: "$.ID", Kind: DecodeWarningTypeMismatch, Message: "expected string, got number"},
+				{Endpoint: "/test", Path: "$.Read", Kind: DecodeWarningTypeMismatch, Message: "expected boolean, got string"},
+				{Endpoint: "/test", Path: "$.Size", Kind: DecodeWarningTypeMismatch, Message: "expected integer, got string"},
+				{Endpoint: "/test", Path: "$.Tags", Kind: DecodeWarningTypeMismatch, Message: "expected array, got string"},
+			},
+		},
+		{
+			name:   "attachment list reported as 0",
+			body:   `{"Attachments":0,"Inline":null}`,
+			target: &Message{},
+		},
+		{
+			name:   "attachment list shape",
+			body:   `{"Attachments":{"PartID":"2"},"Inline":3}`,
+			target: &Message{},
+			expected: DecodeWarnings{
+				{Endpoint: "/test", Path: "$.Attachments", Kind: DecodeWarningShape, Message: "expected array or 0, got object"},
+				{Endpoint: "/test", Path: "$.Inline", Kind: DecodeWarningShape, Message: "expected array or 0, got number 3"},
+			},
+		},
+		{
+			name:   "nested arrays",
+			body:   `{"total":1,"messages":[{"ID":"1","Size":1.5}]}`,
+			target: &MessagesResponse{},
+			expected: DecodeWarnings{
+				{Endpoint: "/test", Path: "$.messages[0].Size", Kind: DecodeWarningTypeMismatch, Message: "expected integer, got 1.5"},
+			},
+		},
+		{
+			name:   "link check status as number or text",
+			body:   `{"Errors":0,"Links":[{"URL":"a","Status":200},{"URL":"b","Status":"OK","StatusCode":200}]}`,
+			target: &LinkCheckResponse{},
+		},
+		{
+			name:     "invalid JSON",
+			body:     `{`,
+			target:   &Message{},
+			expected: DecodeWarnings{{Endpoint: "/test", Path: "$", Kind: DecodeWarningShape, Message: "response is not valid JSON"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := decodeWarnings("/test", []byte(tt.body), reflect.TypeOf(tt.target))
+			require.Equal(t, tt.expected, got)
+		})
+	}
+}
+
+func newDecodeTestClient(t *testing.T, body string, configure func(*Config)) Client {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+
+	config := &Config{
+		BaseURL:    server.URL,
+		HTTPClient: &http.Client{Timeout: 5 * time.Second},
+	}
+	configure(config)
+
+	c, err := NewClient(config)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = c.Close() })
+
+	return c
+}
+
+func TestClient_DecodeWarningHook(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mu       sync.Mutex
+		warnings []DecodeWarning
+	)
+
+	c := newDecodeTestClient(t, `{"ID":"msg-1","Subject":"Hi","Priority":"high","Attachments":"none"}`, func(config *Config) {
+		config.OnDecodeWarning = func(w DecodeWarning) {
+			mu.Lock()
+			defer mu.Unlock()
+			warnings = append(warnings, w)
+		}
+	})
+
+	msg, err := c.GetMessage(t.Context(), "msg-1")
+	require.NoError(t, err, "the hook does not make decoding fail")
+	require.Equal(t, "Hi", msg.Subject)
+	require.Empty(t, msg.Attachments)
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	require.Equal(t, []DecodeWarning{
+		{Endpoint: "/api/v1/message/msg-1", Path: "$.Attachments", Kind: DecodeWarningShape, Message: "expected array or 0, got string"},
+		{Endpoint: "/api/v1/message/msg-1", Path: "$.Priority", Kind: DecodeWarningUnknownField, Message: "unknown field"},
+	}, warnings)
+	require.Equal(t, "/api/v1/message/msg-1: $.Priority: unknown field", warnings[1].String())
+}
+
+func TestClient_StrictDecoding(t *testing.T) {
+	t.Parallel()
+
+	body := `{"ID":"msg-1","Priority":"high"}`
+
+	lenient := newDecodeTestClient(t, body, func(*Config) {})
+	msg, err := lenient.GetMessage(t.Context(), "msg-1")
+	require.NoError(t, err)
+	require.Equal(t, "msg-1", msg.ID)
+
+	strict := newDecodeTestClient(t, body, func(config *Config) { config.StrictDecoding = true })
+	_, err = strict.GetMessage(t.Context(), "msg-1")
+	require.ErrorIs(t, err, ErrContractDrift)
+
+	var mailpitErr *Error
+	require.ErrorAs(t, err, &mailpitErr)
+	require.Equal(t, ErrorTypeResponse, mailpitErr.Type)
+
+	var warnings DecodeWarnings
+	require.ErrorAs(t, err, &warnings)
+	require.Len(t, warnings, 1)
+	require.Equal(t, "$.Priority", warnings[0].Path)
+
+	clean := newDecodeTestClient(t, `{"ID":"msg-1"}`, func(config *Config) { config.StrictDecoding = true })
+	_, err = clean.GetMessage(t.Context(), "msg-1")
+	require.NoError(t, err)
+}
@@ -31,6 +31,9 @@
 //	}
 //	defer client.Close()
 //
+// For Mailpit served under a sub-path (MP_WEBROOT), include it in BaseURL or
+// set Config.WebRoot; API, view and health routes are resolved below it.
+//
 // # Message Operations
 //
 // List all messages:
@@ -60,6 +63,11 @@
 //	}
 //	fmt.Printf("Subject: %s\n", message.Subject)
 //
+// Fetching a message marks it read. Use Config.PreserveReadState, or
+// PreserveReadState(ctx) for a single call, to restore unread messages:
+//
+//	message, err = client.GetMessage(mailpit.PreserveReadState(ctx), "message-id")
+//
 // Search messages:
 //
 //	results, err := client.SearchMessages(ctx, "test@example.com", nil)
@@ -89,7 +97,7 @@
 //	if err != nil {
 //		log.Fatal(err)
 //	}
-//	fmt.Printf("HTML errors: %d, warnings: %d\n", len(htmlCheck.Errors), len(htmlCheck.Warnings))
+//	fmt.Printf("HTML compatibility: %.1f%%, warnings: %d\n", htmlCheck.CompatibilityScore(), len(htmlCheck.Warnings))
 //
 // Check links in a message:
 //
@@ -97,8 +105,8 @@
 //	if err != nil {
 //		log.Fatal(err)
 //	}
-//	for _, link := range linkCheck.Links {
-//		fmt.Printf("URL: %s, Status: %d\n", link.URL, link.Status)
+//	for _, link := range linkCheck.FailingLinks() {
+//		fmt.Printf("URL: %s, Status: %d\n", link.URL, link.StatusCode)
 //	}
 //
 // Get SpamAssassin score for a message:
@@ -121,6 +129,40 @@
 //		log.Fatal(err)
 //	}
 //
+// Release messages selected by ID or search query to several recipients,
+// validating them against the server's relay configuration first:
+//
+//	report, err := mailpit.Release(ctx, client, &mailpit.ReleaseRequest{
+//		Query: "tag:review",
+//		To:    []string{"stakeholder@example.com"},
+//	})
+//	if err != nil {
+//		log.Fatal(err) // matches mailpit.ErrRelayDisabled if relay is off
+//	}
+//	fmt.Printf("released %d, failed %d\n", len(report.Released()), len(report.Failed()))
+//
+// Extract links, one-time codes and HTML fragments from a message:
+//
+//	link, err := msg.FindLink(mailpit.LinkTextContains("verify"))
+//	code, err := msg.ExtractOTP("") // DefaultOTPPattern
+//	cells, err := msg.ExtractByCSSSelector("td.total")
+//
+//	// or by ID
+//	link, err = mailpit.FindMessageLink(ctx, client, "message-id", mailpit.LinkURLContains("/reset"))
+//
+// Render a message for golden file comparison, with volatile data masked:
+//
+//	snapshot, err := mailpit.MessageSnapshot(ctx, client, "message-id", nil)
+//	text := mailpit.HTMLToText(msg.HTML)
+//
+// Compare image parts and thumbnails against a baseline:
+//
+//	logo, err := mailpit.MessagePartImage(ctx, client, "message-id", "2")
+//	diff := mailpit.CompareImages(baseline, logo, nil) // DefaultImageCompareOptions
+//	if !diff.Match {
+//		_ = png.Encode(f, diff.Diff) // differing pixels in red
+//	}
+//
 // # Server Operations
 //
 // Check server health:
@@ -238,9 +280,8 @@
 // Configure chaos triggers for testing:
 //
 //	triggers := &mailpit.ChaosTriggers{
-//		AcceptConnections: 0.9,  // 90% success rate
-//		RejectSenders:     0.1,  // 10% sender rejection
-//		DelayConnections:  0.2,  // 20% connection delay
+//		Recipient: mailpit.ChaosTrigger{ErrorCode: 451, Probability: 30}, // reject 30% of recipients
+//		Sender:    mailpit.ChaosTrigger{ErrorCode: 550, Probability: 10}, // reject 10% of senders
 //	}
 //
 //	updated, err := client.SetChaosConfig(ctx, triggers)
@@ -249,6 +290,98 @@
 //	}
 //	fmt.Printf("Chaos triggers updated, enabled: %v\n", updated.Enabled)
 //
+// Apply a predefined scenario for the duration of a function. The previous
+// configuration is always restored, even if the function panics:
+//
+//	scenario, _ := mailpit.NewChaosScenario(mailpit.ChaosScenarioAuthOutage)
+//	err := mailpit.WithChaos(ctx, client, scenario, func() {
+//		// exercise SMTP retry logic
+//	})
+//
+// # Email Quality Gate
+//
+// Run the HTML, link and SpamAssassin checks concurrently and evaluate them
+// against a policy:
+//
+//	policy := mailpit.DefaultLintPolicy()
+//	policy.Clients = []string{"gmail", "outlook"}
+//	policy.RequiredHeaders = []string{"List-Unsubscribe"}
+//	policy.MaxSpamScore = 0 // enabled by CheckSpam, so zero allows no points
+//
+//	report, err := mailpit.Lint(ctx, client, "message-id", policy)
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	if !report.Passed() {
+//		junit, _ := report.JUnit()
+//		_ = os.WriteFile("mail-lint.xml", junit, 0o644)
+//	}
+//
+// # Multiple Instances
+//
+// Fan out queries to several Mailpit instances and merge the results:
+//
+//	multi, err := mailpit.NewMultiClient(
+//		mailpit.Instance{Name: "billing", Client: billingClient},
+//		mailpit.Instance{Name: "auth", Client: authClient},
+//	)
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	resp, err := multi.ListMessages(ctx, nil)
+//	if err != nil {
+//		log.Fatal(err) // every instance failed
+//	}
+//	for _, msg := range resp.Messages {
+//		fmt.Printf("[%s] %s\n", msg.Instance, msg.Subject)
+//	}
+//
+// # Watching a Mailbox
+//
+// Dispatch new messages to handlers, filtered by query, tag or recipient:
+//
+//	watcher, err := mailpit.NewWatcher(client,
+//		mailpit.WithWatchCursorStore(mailpit.NewFileCursorStore("cursor.json")))
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	watcher.Handle(mailpit.WatchFilter{Recipient: "bot@example.com"},
+//		func(ctx context.Context, msg *mailpit.MessageSummary) error {
+//			return followMagicLink(ctx, msg.ID) // an error redelivers the message
+//		})
+//	err = watcher.Run(ctx)
+//
+// # Caching
+//
+// Cache immutable message data (details, headers, source, parts) in memory and
+// optionally on disk; mutable data such as listings, read status and tags is
+// never cached:
+//
+//	cached, err := mailpit.NewCachingClient(client, mailpit.WithCacheDir(".cache/mailpit"))
+//	msg, err := cached.GetMessage(ctx, id)                        // cached after the first call
+//	msg, err = cached.GetMessage(mailpit.WithoutCache(ctx), id)  // refresh
+//
+// # Low-level Requests
+//
+// Call endpoints that have no dedicated method with the client's
+// authentication, retries and error handling:
+//
+//	var out map[string]any
+//	err := client.Do(ctx, http.MethodGet, "/new-endpoint", nil, &out)
+//	resp, err := client.DoRaw(ctx, http.MethodGet, "/readyz", nil, mailpit.WithWebRoute())
+//
+// # Strict Decoding
+//
+// Responses are decoded leniently by default. To learn about API changes,
+// report unknown fields, type mismatches and unexpected shapes through a hook,
+// or fail the call with an error matching ErrContractDrift:
+//
+//	client, err := mailpit.NewClient(&mailpit.Config{
+//		BaseURL:         "http://localhost:8025",
+//		StrictDecoding:  os.Getenv("CI") != "",
+//		OnDecodeWarning: func(w mailpit.DecodeWarning) { log.Println(w) },
+//	})
+//
 // # Error Handling
 //
 // The client provides structured error handling with different error types:
@@ -270,6 +403,16 @@
 //		}
 //	}
 //
+// Common API failures can be matched with errors.Is using sentinel errors:
+//
+//	_, err := client.GetMessage(ctx, "message-id")
+//	switch {
+//	case errors.Is(err, mailpit.ErrNotFound):
+//		// message was deleted, don't retry
+//	case errors.Is(err, mailpit.ErrServerUnavailable), errors.Is(err, mailpit.ErrRateLimited):
+//		// transient failure, retry later
+//	}
+//
 // # Authentication
 //
 // If your Mailpit server requires authentication, configure it:
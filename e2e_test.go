@@ -590,8 +590,10 @@ func TestE2E_ChaosOperations(t *testing.T) {
 			return
 		}
 		assert.NotNil(t, config)
-		// Chaos should be disabled by default
-		assert.False(t, config.Enabled)
+		// No triggers should be active by default
+		assert.False(t, config.Triggers.Sender.Enabled())
+		assert.False(t, config.Triggers.Recipient.Enabled())
+		assert.False(t, config.Triggers.Authentication.Enabled())
 	})
 
 	t.Run("SetChaosConfig", func(t *testing.T) {
@@ -601,8 +603,8 @@ func TestE2E_ChaosOperations(t *testing.T) {
 		ctx := t.Context()
 
 		chaosConfig := &ChaosTriggers{
-			AcceptConnections: 0.1,  // 10% chance to reject connections
-			RejectSenders:     0.05, // 5% chance to reject senders
+			Sender:    ChaosTrigger{ErrorCode: 451, Probability: 10}, // 10% chance to reject senders
+			Recipient: ChaosTrigger{ErrorCode: 550, Probability: 5},  // 5% chance to reject recipients
 		}
 
 		response, err := client.SetChaosConfig(ctx, chaosConfig)
@@ -612,8 +614,9 @@ func TestE2E_ChaosOperations(t *testing.T) {
 			return
 		}
 		assert.True(t, response.Enabled)
-		assert.Equal(t, 0.1, response.Triggers.AcceptConnections)
-		assert.Equal(t, 0.05, response.Triggers.RejectSenders)
+		assert.Equal(t, chaosConfig.Sender, response.Triggers.Sender)
+		assert.Equal(t, chaosConfig.Recipient, response.Triggers.Recipient)
+		assert.False(t, response.Triggers.Authentication.Enabled())
 
 		// Reset chaos config
 		resetConfig := &ChaosTriggers{}
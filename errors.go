@@ -1,6 +1,13 @@
 package mailpitclient
 
-import "fmt"
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
 
 // ErrorType represents the type of error that occurred.
 type ErrorType string
@@ -25,11 +32,46 @@ const (
 	ErrorTypeValidation ErrorType = "validation"
 )
 
+// Sentinel errors for common API failures. They can be matched against any
+// *Error returned by the client using errors.Is.
+var (
+	// ErrNotFound matches API errors with status 404, e.g. a deleted message
+	ErrNotFound = errors.New("mailpit: not found")
+
+	// ErrUnauthorized matches API errors with status 401 or 403
+	ErrUnauthorized = errors.New("mailpit: unauthorized")
+
+	// ErrRateLimited matches API errors with status 429
+	ErrRateLimited = errors.New("mailpit: rate limited")
+
+	// ErrServerUnavailable matches network failures and 5xx API errors
+	ErrServerUnavailable = errors.New("mailpit: server unavailable")
+
+	// ErrChaosDisabled matches errors returned when chaos testing is not enabled
+	ErrChaosDisabled = errors.New("mailpit: chaos is not enabled")
+
+	// ErrRelayDisabled matches errors returned when message relay is not enabled
+	ErrRelayDisabled = errors.New("mailpit: message relay is not enabled")
+
+	// ErrRecipientNotAllowed matches errors for recipients rejected by the relay allow or block lists
+	ErrRecipientNotAllowed = errors.New("mailpit: recipient not allowed by relay configuration")
+
+	// ErrNoMatch matches errors returned when an extraction helper finds nothing in a message
+	ErrNoMatch = errors.New("mailpit: no match in message")
+
+	// ErrWatchMessageSkipped matches errors reported when a Watcher gives up on a message its handlers keep failing on
+	ErrWatchMessageSkipped = errors.New("mailpit: watcher skipped message")
+
+	// ErrContractDrift matches strict decoding errors for responses that do not match the client's types
+	ErrContractDrift = errors.New("mailpit: response does not match client types")
+)
+
 // Error represents a Mailpit client error with structured information.
 type Error struct {
 	Cause      error     `json:"-"`
 	Type       ErrorType `json:"type"`
 	Message    string    `json:"message"`
+	APIMessage string    `json:"api_message,omitempty"`
 	Response   string    `json:"response,omitempty"`
 	StatusCode int       `json:"status_code,omitempty"`
 }
@@ -48,6 +90,47 @@ func (e *Error) Unwrap() error {
 	return e.Cause
 }
 
+// Is reports whether the error matches one of the package sentinel errors,
+// allowing errors.Is(err, ErrNotFound) and similar checks.
+func (e *Error) Is(target error) bool {
+	//nolint:errorlint // target is compared against package sentinels
+	switch target {
+	case ErrNotFound:
+		return e.IsAPIError(http.StatusNotFound)
+	case ErrUnauthorized:
+		return e.IsAPIError(http.StatusUnauthorized) || e.IsAPIError(http.StatusForbidden)
+	case ErrRateLimited:
+		return e.IsAPIError(http.StatusTooManyRequests)
+	case ErrServerUnavailable:
+		if e.Type == ErrorTypeNetwork {
+			return !errors.Is(e.Cause, context.Canceled) && !errors.Is(e.Cause, context.DeadlineExceeded)
+		}
+
+		return e.Type == ErrorTypeAPI && e.StatusCode >= http.StatusInternalServerError
+	case ErrChaosDisabled:
+		return e.apiMessageMentions("chaos")
+	case ErrRelayDisabled:
+		return e.apiMessageMentions("relay") || e.apiMessageMentions("release")
+	default:
+		return false
+	}
+}
+
+// apiMessageMentions reports whether the server error message states that the
+// given feature is not enabled.
+func (e *Error) apiMessageMentions(feature string) bool {
+	if e.Type != ErrorTypeAPI || e.APIMessage == "" {
+		return false
+	}
+
+	msg := strings.ToLower(e.APIMessage)
+	if !strings.Contains(msg, feature) {
+		return false
+	}
+
+	return strings.Contains(msg, "not enabled") || strings.Contains(msg, "disabled") || strings.Contains(msg, "not configured")
+}
+
 // IsType checks if the error is of a specific type.
 func (e *Error) IsType(errorType ErrorType) bool {
 	return e.Type == errorType
@@ -58,6 +141,47 @@ func (e *Error) IsAPIError(statusCode int) bool {
 	return e.Type == ErrorTypeAPI && e.StatusCode == statusCode
 }
 
+// NewAPIError creates a new API error from an HTTP status code and response body.
+// Mailpit error bodies are either plain text or a JSON object with an error
+// field; the decoded text is stored in APIMessage.
+func NewAPIError(statusCode int, body []byte) *Error {
+	apiMessage := parseAPIErrorMessage(body)
+
+	message := fmt.Sprintf("API request failed with status %d", statusCode)
+	if apiMessage != "" {
+		message += ": " + apiMessage
+	}
+
+	return &Error{
+		Type:       ErrorTypeAPI,
+		Message:    message,
+		APIMessage: apiMessage,
+		StatusCode: statusCode,
+		Response:   string(body),
+	}
+}
+
+// parseAPIErrorMessage extracts a human readable message from an error body.
+func parseAPIErrorMessage(body []byte) string {
+	trimmed := strings.TrimSpace(string(body))
+	if trimmed == "" {
+		return ""
+	}
+
+	if strings.HasPrefix(trimmed, "{") {
+		var payload map[string]any
+		if err := json.Unmarshal([]byte(trimmed), &payload); err == nil {
+			for _, key := range []string{"Error", "error", "Message", "message"} {
+				if msg, ok := payload[key].(string); ok && msg != "" {
+					return msg
+				}
+			}
+		}
+	}
+
+	return trimmed
+}
+
 // NewConfigError creates a new configuration error.
 func NewConfigError(message string) *Error {
 	return &Error{
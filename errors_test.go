@@ -1,6 +1,7 @@
 package mailpitclient
 
 import (
+	"context"
 	"errors"
 	"testing"
 
@@ -255,3 +256,136 @@ func TestError_WrappingInterface(t *testing.T) {
 	// Test that errors.Is works
 	require.ErrorIs(t, mailpitErr, originalErr)
 }
+
+func TestError_Is(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		err      *Error
+		target   error
+		name     string
+		expected bool
+	}{
+		{
+			name:     "not found",
+			err:      NewAPIError(404, []byte("message not found")),
+			target:   ErrNotFound,
+			expected: true,
+		},
+		{
+			name:     "not found does not match server unavailable",
+			err:      NewAPIError(404, nil),
+			target:   ErrServerUnavailable,
+			expected: false,
+		},
+		{
+			name:     "unauthorized",
+			err:      NewAPIError(401, nil),
+			target:   ErrUnauthorized,
+			expected: true,
+		},
+		{
+			name:     "forbidden is unauthorized",
+			err:      NewAPIError(403, nil),
+			target:   ErrUnauthorized,
+			expected: true,
+		},
+		{
+			name:     "rate limited",
+			err:      NewAPIError(429, nil),
+			target:   ErrRateLimited,
+			expected: true,
+		},
+		{
+			name:     "5xx is server unavailable",
+			err:      NewAPIError(503, nil),
+			target:   ErrServerUnavailable,
+			expected: true,
+		},
+		{
+			name:     "network error is server unavailable",
+			err:      &Error{Type: ErrorTypeNetwork, Cause: errors.New("connection refused")}, // nolint:err113
+			target:   ErrServerUnavailable,
+			expected: true,
+		},
+		{
+			name:     "cancelled request is not server unavailable",
+			err:      &Error{Type: ErrorTypeNetwork, Cause: context.Canceled},
+			target:   ErrServerUnavailable,
+			expected: false,
+		},
+		{
+			name:     "chaos disabled",
+			err:      NewAPIError(400, []byte("Chaos is not enabled")),
+			target:   ErrChaosDisabled,
+			expected: true,
+		},
+		{
+			name:     "relay disabled",
+			err:      NewAPIError(400, []byte(`{"Error": "SMTP relay is disabled"}`)),
+			target:   ErrRelayDisabled,
+			expected: true,
+		},
+		{
+			name:     "relay error is not chaos disabled",
+			err:      NewAPIError(400, []byte("SMTP relay is disabled")),
+			target:   ErrChaosDisabled,
+			expected: false,
+		},
+		{
+			name:     "validation error",
+			err:      NewValidationError("message ID cannot be empty"),
+			target:   ErrNotFound,
+			expected: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, tt.expected, errors.Is(tt.err, tt.target))
+		})
+	}
+}
+
+func TestNewAPIError(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		body       string
+		apiMessage string
+		message    string
+	}{
+		{
+			name:       "plain text body",
+			body:       "message not found\n",
+			apiMessage: "message not found",
+			message:    "API request failed with status 404: message not found",
+		},
+		{
+			name:       "JSON body",
+			body:       `{"error": "message not found"}`,
+			apiMessage: "message not found",
+			message:    "API request failed with status 404: message not found",
+		},
+		{
+			name:    "empty body",
+			body:    "",
+			message: "API request failed with status 404",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := NewAPIError(404, []byte(tt.body))
+			require.Equal(t, ErrorTypeAPI, err.Type)
+			require.Equal(t, 404, err.StatusCode)
+			require.Equal(t, tt.apiMessage, err.APIMessage)
+			require.Equal(t, tt.message, err.Message)
+			require.Equal(t, tt.body, err.Response)
+		})
+	}
+}
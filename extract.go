@@ -0,0 +1,414 @@
+package mailpitclient
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/andybalholm/cascadia"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// DefaultOTPPattern matches a standalone six digit code.
+const DefaultOTPPattern = `\b\d{6}\b`
+
+// textURLPattern finds bare URLs in plain text bodies.
+var textURLPattern = regexp.MustCompile(`https?://[^\s<>"'` + "`" + `]+`)
+
+// trackingParams lists query parameters added by email and ad platforms to
+// track clicks. Parameters starting with "utm_" are always removed.
+var trackingParams = map[string]struct{}{
+	"fbclid":      {},
+	"gclid":       {},
+	"dclid":       {},
+	"msclkid":     {},
+	"yclid":       {},
+	"igshid":      {},
+	"mc_cid":      {},
+	"mc_eid":      {},
+	"_hsenc":      {},
+	"_hsmi":       {},
+	"mkt_tok":     {},
+	"oly_enc_id":  {},
+	"oly_anon_id": {},
+	"vero_id":     {},
+}
+
+// Link is a link found in a message body.
+type Link struct {
+	// Href is the link target exactly as it appears in the message.
+	Href string `json:"href"`
+	// URL is Href resolved against the document base, with tracking
+	// parameters such as utm_source removed.
+	URL string `json:"url"`
+	// Text is the anchor text with whitespace collapsed. It is empty for bare
+	// URLs found in the plain text body.
+	Text string `json:"text,omitempty"`
+}
+
+// LinkMatcher selects a link for FindLink.
+type LinkMatcher func(Link) bool
+
+// LinkTextContains matches links whose anchor text contains s, case-insensitively.
+func LinkTextContains(s string) LinkMatcher {
+	s = strings.ToLower(s)
+
+	return func(l Link) bool {
+		return strings.Contains(strings.ToLower(l.Text), s)
+	}
+}
+
+// LinkURLContains matches links whose cleaned URL contains s.
+func LinkURLContains(s string) LinkMatcher {
+	return func(l Link) bool {
+		return strings.Contains(l.URL, s)
+	}
+}
+
+// LinkURLMatches matches links whose cleaned URL matches re.
+func LinkURLMatches(re *regexp.Regexp) LinkMatcher {
+	return func(l Link) bool {
+		return re.MatchString(l.URL)
+	}
+}
+
+// HTMLElement is an element selected by ExtractByCSSSelector.
+type HTMLElement struct {
+	Attrs map[string]string `json:"attrs,omitempty"`
+	Tag   string            `json:"tag"`
+	// Text is the text content with whitespace collapsed.
+	Text string `json:"text"`
+	// HTML is the outer HTML of the element.
+	HTML string `json:"html"`
+}
+
+// Attr returns the value of the named attribute, or an empty string.
+func (e *HTMLElement) Attr(name string) string {
+	return e.Attrs[name]
+}
+
+// Links returns the links in the message. Anchors in the HTML body are used
+// when present, otherwise bare URLs are taken from the text body. Duplicate
+// links (same URL and text) are returned once, in document order.
+func (m *Message) Links() []Link {
+	if m.HTML != "" {
+		if doc, err := html.Parse(strings.NewReader(m.HTML)); err == nil {
+			return htmlLinks(doc)
+		}
+	}
+
+	return textLinks(m.Text)
+}
+
+// FindLink returns the first link accepted by matcher. If no link matches, the
+// error matches ErrNoMatch.
+func (m *Message) FindLink(matcher LinkMatcher) (Link, error) {
+	if matcher == nil {
+		return Link{}, NewValidationError("link matcher cannot be nil")
+	}
+
+	for _, l := range m.Links() {
+		if matcher(l) {
+			return l, nil
+		}
+	}
+
+	return Link{}, noMatchError(m.ID, "no link matched")
+}
+
+// ExtractOTP returns the first match of pattern in the message, searching the
+// text body first and then the text content of the HTML body. If pattern has a
+// capture group, the first group is returned. An empty pattern uses
+// DefaultOTPPattern. If nothing matches, the error matches ErrNoMatch.
+func (m *Message) ExtractOTP(pattern string) (string, error) {
+	if pattern == "" {
+		pattern = DefaultOTPPattern
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return "", NewValidationError(fmt.Sprintf("invalid OTP pattern %q: %v", pattern, err))
+	}
+
+	sources := []string{m.Text}
+	if m.HTML != "" {
+		if doc, parseErr := html.Parse(strings.NewReader(m.HTML)); parseErr == nil {
+			sources = append(sources, nodeText(doc))
+		}
+	}
+
+	for _, src := range sources {
+		match := re.FindStringSubmatch(src)
+		switch {
+		case match == nil:
+			continue
+		case len(match) > 1:
+			return match[1], nil
+		default:
+			return match[0], nil
+		}
+	}
+
+	return "", noMatchError(m.ID, "no text matched "+pattern)
+}
+
+// ExtractByCSSSelector returns the elements of the HTML body matching the CSS
+// selector, in document order. If nothing matches, the error matches ErrNoMatch.
+func (m *Message) ExtractByCSSSelector(selector string) ([]HTMLElement, error) {
+	sel, err := cascadia.Parse(selector)
+	if err != nil {
+		return nil, NewValidationError(fmt.Sprintf("invalid CSS selector %q: %v", selector, err))
+	}
+
+	if m.HTML == "" {
+		return nil, noMatchError(m.ID, "message has no HTML body")
+	}
+
+	doc, err := html.Parse(strings.NewReader(m.HTML))
+	if err != nil {
+		return nil, &Error{
+			Type:    ErrorTypeResponse,
+			Message: fmt.Sprintf("failed to parse HTML of message %s: %v", m.ID, err),
+			Cause:   err,
+		}
+	}
+
+	nodes := cascadia.QueryAll(doc, sel)
+	if len(nodes) == 0 {
+		return nil, noMatchError(m.ID, "no element matched "+selector)
+	}
+
+	elements := make([]HTMLElement, 0, len(nodes))
+	for _, n := range nodes {
+		el := HTMLElement{Tag: n.Data, Text: nodeText(n)}
+
+		if len(n.Attr) > 0 {
+			el.Attrs = make(map[string]string, len(n.Attr))
+			for _, a := range n.Attr {
+				el.Attrs[a.Key] = a.Val
+			}
+		}
+
+		var b strings.Builder
+		if err = html.Render(&b, n); err == nil {
+			el.HTML = b.String()
+		}
+
+		elements = append(elements, el)
+	}
+
+	return elements, nil
+}
+
+// MessageLinks fetches a message and returns its links. See Message.Links.
+func MessageLinks(ctx context.Context, c Client, id string) ([]Link, error) {
+	msg, err := fetchMessage(ctx, c, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return msg.Links(), nil
+}
+
+// FindMessageLink fetches a message and returns the first link accepted by
+// matcher. See Message.FindLink.
+func FindMessageLink(ctx context.Context, c Client, id string, matcher LinkMatcher) (Link, error) {
+	msg, err := fetchMessage(ctx, c, id)
+	if err != nil {
+		return Link{}, err
+	}
+
+	return msg.FindLink(matcher)
+}
+
+// ExtractMessageOTP fetches a message and extracts a one-time code from it.
+// See Message.ExtractOTP.
+func ExtractMessageOTP(ctx context.Context, c Client, id, pattern string) (string, error) {
+	msg, err := fetchMessage(ctx, c, id)
+	if err != nil {
+		return "", err
+	}
+
+	return msg.ExtractOTP(pattern)
+}
+
+// ExtractMessageByCSSSelector fetches a message and returns the HTML elements
+// matching selector. See Message.ExtractByCSSSelector.
+func ExtractMessageByCSSSelector(ctx context.Context, c Client, id, selector string) ([]HTMLElement, error) {
+	msg, err := fetchMessage(ctx, c, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return msg.ExtractByCSSSelector(selector)
+}
+
+// fetchMessage retrieves a message including its HTML and text bodies.
+func fetchMessage(ctx context.Context, c Client, id string) (*Message, error) {
+	if c == nil {
+		return nil, NewValidationError("client cannot be nil")
+	}
+
+	return c.GetMessage(ctx, id)
+}
+
+func noMatchError(id, msg string) error {
+	return &Error{
+		Type:    ErrorTypeValidation,
+		Message: fmt.Sprintf("message %s: %s", id, msg),
+		Cause:   ErrNoMatch,
+	}
+}
+
+// htmlLinks collects the anchors in doc, resolving hrefs against <base href>.
+func htmlLinks(doc *html.Node) []Link {
+	var (
+		base  *url.URL
+		links []Link
+		seen  = make(map[Link]struct{})
+	)
+
+	if n := cascadia.Query(doc, cascadia.MustCompile("base[href]")); n != nil {
+		base, _ = url.Parse(attr(n, "href"))
+	}
+
+	for _, n := range cascadia.QueryAll(doc, cascadia.MustCompile("a[href], area[href]")) {
+		href := strings.TrimSpace(attr(n, "href"))
+		if href == "" || strings.HasPrefix(href, "#") {
+			continue
+		}
+
+		l := Link{Href: href, URL: cleanURL(base, href), Text: nodeText(n)}
+		if l.Text == "" && n.DataAtom == atom.A {
+			// Image links: fall back to the alt text of the image.
+			if img := cascadia.Query(n, cascadia.MustCompile("img[alt]")); img != nil {
+				l.Text = strings.Join(strings.Fields(attr(img, "alt")), " ")
+			}
+		}
+
+		if _, dup := seen[l]; dup {
+			continue
+		}
+		seen[l] = struct{}{}
+		links = append(links, l)
+	}
+
+	return links
+}
+
+func textLinks(text string) []Link {
+	var (
+		links []Link
+		seen  = make(map[string]struct{})
+	)
+
+	for _, href := range textURLPattern.FindAllString(text, -1) {
+		href = strings.TrimRight(href, ".,;:!?)]}>'\"")
+		if _, dup := seen[href]; dup {
+			continue
+		}
+		seen[href] = struct{}{}
+		links = append(links, Link{Href: href, URL: cleanURL(nil, href)})
+	}
+
+	return links
+}
+
+// cleanURL resolves href against base and removes tracking parameters. Hrefs
+// that cannot be parsed are returned unchanged.
+func cleanURL(base *url.URL, href string) string {
+	u, err := url.Parse(href)
+	if err != nil {
+		return href
+	}
+
+	if base != nil {
+		u = base.ResolveReference(u)
+	}
+
+	if u.RawQuery == "" {
+		return u.String()
+	}
+
+	query := u.Query()
+	removed := false
+	for key := range query {
+		lower := strings.ToLower(key)
+		if _, tracking := trackingParams[lower]; tracking || strings.HasPrefix(lower, "utm_") {
+			query.Del(key)
+			removed = true
+		}
+	}
+
+	if removed {
+		u.RawQuery = query.Encode()
+	}
+
+	return u.String()
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+
+	return ""
+}
+
+// nodeText returns the text content of n with whitespace collapsed, skipping
+// scripts, styles and other non-rendered elements. Block elements are
+// separated by a space; inline elements are joined as rendered.
+func nodeText(n *html.Node) string {
+	var b strings.Builder
+
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		switch n.Type {
+		case html.TextNode:
+			b.WriteString(n.Data)
+
+			return
+		case html.ElementNode:
+			if _, skip := hiddenElements[n.DataAtom]; skip {
+				return
+			}
+		case html.ErrorNode, html.DocumentNode, html.CommentNode, html.DoctypeNode, html.RawNode:
+		}
+
+		_, block := blockElements[n.DataAtom]
+		if block {
+			b.WriteByte(' ')
+		}
+
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+
+		if block {
+			b.WriteByte(' ')
+		}
+	}
+	walk(n)
+
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// hiddenElements are never rendered as text.
+var hiddenElements = map[atom.Atom]struct{}{
+	atom.Head: {}, atom.Script: {}, atom.Style: {}, atom.Template: {}, atom.Noscript: {}, atom.Title: {},
+}
+
+// blockElements start on a new line when rendered.
+var blockElements = map[atom.Atom]struct{}{
+	atom.Address: {}, atom.Article: {}, atom.Aside: {}, atom.Blockquote: {}, atom.Br: {}, atom.Center: {},
+	atom.Dd: {}, atom.Div: {}, atom.Dl: {}, atom.Dt: {}, atom.Footer: {}, atom.Form: {}, atom.H1: {},
+	atom.H2: {}, atom.H3: {}, atom.H4: {}, atom.H5: {}, atom.H6: {}, atom.Header: {}, atom.Hr: {},
+	atom.Li: {}, atom.Main: {}, atom.Nav: {}, atom.Ol: {}, atom.P: {}, atom.Pre: {}, atom.Section: {},
+	atom.Table: {}, atom.Tbody: {}, atom.Td: {}, atom.Tfoot: {}, atom.Th: {}, atom.Thead: {}, atom.Tr: {},
+	atom.Ul: {},
+}
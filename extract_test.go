@@ -0,0 +1,241 @@
+package mailpitclient
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const extractHTMLFixture = `<!DOCTYPE html>
+<html>
+<head>
+  <title>Ignored 999999</title>
+  <base href="https://app.example.com/">
+  <style>.code { font-weight: bold }</style>
+</head>
+<body>
+  <p>Hi Alice,</p>
+  <p>Your verification code is <span class="code">48<b>21</b>93</span>.</p>
+  <a href="verify?token=abc123&amp;utm_source=email&amp;utm_campaign=signup">
+    Verify   your
+    email
+  </a>
+  <a href="https://example.com/reset?token=xyz&amp;fbclid=1#top">Reset password</a>
+  <a href="https://example.com/reset?token=xyz&amp;fbclid=1#top">Reset password</a>
+  <a href="#footer">Skip</a>
+  <a href="https://example.com/logo"><img src="logo.png" alt="Example Inc"></a>
+  <table><tr><td id="amount" data-currency="EUR">12.50</td><td>paid</td></tr></table>
+</body>
+</html>`
+
+func TestMessage_Links(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		msg      Message
+		expected []Link
+	}{
+		{
+			name: "html anchors",
+			msg:  Message{HTML: extractHTMLFixture, Text: "ignored https://text.example.com"},
+			expected: []Link{
+				{
+					Href: "verify?token=abc123&utm_source=email&utm_campaign=signup",
+					URL:  "https://app.example.com/verify?token=abc123",
+					Text: "Verify your email",
+				},
+				{
+					Href: "https://example.com/reset?token=xyz&fbclid=1#top",
+					URL:  "https://example.com/reset?token=xyz#top",
+					Text: "Reset password",
+				},
+				{
+					Href: "https://example.com/logo",
+					URL:  "https://example.com/logo",
+					Text: "Example Inc",
+				},
+			},
+		},
+		{
+			name: "text body",
+			msg:  Message{Text: "Confirm at https://example.com/confirm?id=1&utm_medium=mail.\nOr (https://example.com/help)"},
+			expected: []Link{
+				{Href: "https://example.com/confirm?id=1&utm_medium=mail", URL: "https://example.com/confirm?id=1"},
+				{Href: "https://example.com/help", URL: "https://example.com/help"},
+			},
+		},
+		{
+			name: "no links",
+			msg:  Message{Text: "nothing here"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			require.Equal(t, tt.expected, tt.msg.Links())
+		})
+	}
+}
+
+func TestMessage_FindLink(t *testing.T) {
+	t.Parallel()
+
+	msg := &Message{ID: "msg-1", HTML: extractHTMLFixture}
+
+	link, err := msg.FindLink(LinkTextContains("verify YOUR"))
+	require.NoError(t, err)
+	require.Equal(t, "https://app.example.com/verify?token=abc123", link.URL)
+
+	link, err = msg.FindLink(LinkURLMatches(regexp.MustCompile(`/reset\?token=\w+`)))
+	require.NoError(t, err)
+	require.Equal(t, "Reset password", link.Text)
+
+	link, err = msg.FindLink(LinkURLContains("/logo"))
+	require.NoError(t, err)
+	require.Equal(t, "Example Inc", link.Text)
+
+	_, err = msg.FindLink(LinkTextContains("unsubscribe"))
+	require.ErrorIs(t, err, ErrNoMatch)
+
+	_, err = msg.FindLink(nil)
+	require.Error(t, err)
+}
+
+func TestMessage_ExtractOTP(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		msg      Message
+		pattern  string
+		expected string
+		wantErr  error
+	}{
+		{
+			name:     "default pattern from html joins inline elements",
+			msg:      Message{HTML: extractHTMLFixture},
+			expected: "482193",
+		},
+		{
+			name:     "text body takes precedence",
+			msg:      Message{Text: "Your code: 123456", HTML: extractHTMLFixture},
+			expected: "123456",
+		},
+		{
+			name:     "capture group",
+			msg:      Message{Text: "Use token RST-9F3K2 to reset"},
+			pattern:  `token (RST-[A-Z0-9]+)`,
+			expected: "RST-9F3K2",
+		},
+		{
+			name:    "no match",
+			msg:     Message{Text: "no code"},
+			wantErr: ErrNoMatch,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			code, err := tt.msg.ExtractOTP(tt.pattern)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, code)
+		})
+	}
+
+	_, err := (&Message{Text: "x"}).ExtractOTP("(")
+	require.Error(t, err)
+}
+
+func TestMessage_ExtractByCSSSelector(t *testing.T) {
+	t.Parallel()
+
+	msg := &Message{ID: "msg-1", HTML: extractHTMLFixture}
+
+	elements, err := msg.ExtractByCSSSelector("td#amount")
+	require.NoError(t, err)
+	require.Len(t, elements, 1)
+	require.Equal(t, "td", elements[0].Tag)
+	require.Equal(t, "12.50", elements[0].Text)
+	require.Equal(t, "EUR", elements[0].Attr("data-currency"))
+	require.Equal(t, `<td id="amount" data-currency="EUR">12.50</td>`, elements[0].HTML)
+
+	elements, err = msg.ExtractByCSSSelector("p")
+	require.NoError(t, err)
+	require.Len(t, elements, 2)
+	require.Equal(t, "Your verification code is 482193.", elements[1].Text)
+
+	_, err = msg.ExtractByCSSSelector(".missing")
+	require.ErrorIs(t, err, ErrNoMatch)
+
+	_, err = msg.ExtractByCSSSelector("p[")
+	require.Error(t, err)
+	require.NotErrorIs(t, err, ErrNoMatch)
+
+	_, err = (&Message{Text: "plain"}).ExtractByCSSSelector("p")
+	require.ErrorIs(t, err, ErrNoMatch)
+}
+
+func TestExtractByID(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/message/msg-1" {
+			w.WriteHeader(http.StatusNotFound)
+
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(Message{ID: "msg-1", HTML: extractHTMLFixture})
+	}))
+	t.Cleanup(server.Close)
+
+	c, err := NewClient(&Config{
+		BaseURL:    server.URL,
+		APIPath:    "/api/v1",
+		MaxRetries: 0,
+		HTTPClient: &http.Client{Timeout: 5 * time.Second},
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = c.Close() })
+
+	ctx := t.Context()
+
+	links, err := MessageLinks(ctx, c, "msg-1")
+	require.NoError(t, err)
+	require.Len(t, links, 3)
+
+	link, err := FindMessageLink(ctx, c, "msg-1", LinkTextContains("reset"))
+	require.NoError(t, err)
+	require.Equal(t, "https://example.com/reset?token=xyz#top", link.URL)
+
+	code, err := ExtractMessageOTP(ctx, c, "msg-1", "")
+	require.NoError(t, err)
+	require.Equal(t, "482193", code)
+
+	elements, err := ExtractMessageByCSSSelector(ctx, c, "msg-1", ".code")
+	require.NoError(t, err)
+	require.Equal(t, "482193", elements[0].Text)
+
+	_, err = MessageLinks(ctx, c, "missing")
+	require.ErrorIs(t, err, ErrNotFound)
+
+	_, err = MessageLinks(ctx, nil, "msg-1")
+	require.Error(t, err)
+}
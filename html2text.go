@@ -0,0 +1,215 @@
+package mailpitclient
+
+import (
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// HTMLToText converts an HTML email body into readable plain text.
+//
+// Block elements start new lines and paragraphs are separated by a blank line,
+// list items are prefixed with "- " (or their number in ordered lists), table
+// cells are separated by " | ", links are rendered as "text (url)" when the
+// text differs from the URL, and images are rendered as their alt text in
+// brackets. Scripts, styles and the document head are dropped. Whitespace is
+// collapsed as a browser would, except inside <pre>.
+func HTMLToText(body string) string {
+	doc, err := html.Parse(strings.NewReader(body))
+	if err != nil {
+		return strings.TrimSpace(body)
+	}
+
+	r := &textRenderer{}
+	r.render(doc)
+
+	return r.String()
+}
+
+// textRenderer accumulates rendered text, tracking pending line breaks so that
+// consecutive block boundaries produce at most one blank line.
+type textRenderer struct {
+	b        strings.Builder
+	line     strings.Builder
+	newlines int
+	pre      int
+	space    bool
+}
+
+func (r *textRenderer) String() string {
+	r.flushLine()
+
+	return strings.TrimSpace(r.b.String())
+}
+
+// write appends inline text, collapsing whitespace outside <pre>.
+func (r *textRenderer) write(s string) {
+	if r.pre > 0 {
+		for i, part := range strings.Split(s, "\n") {
+			if i > 0 {
+				r.breakLine(1)
+			}
+			r.emit(part)
+		}
+
+		return
+	}
+
+	for _, c := range s {
+		if c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f' || c == '\u00a0' {
+			r.space = r.line.Len() > 0
+
+			continue
+		}
+
+		if r.space {
+			r.emit(" ")
+			r.space = false
+		}
+		r.emit(string(c))
+	}
+}
+
+func (r *textRenderer) emit(s string) {
+	if s == "" {
+		return
+	}
+
+	if r.newlines > 0 && r.b.Len() > 0 {
+		r.b.WriteString(strings.Repeat("\n", r.newlines))
+	}
+	r.newlines = 0
+	r.line.WriteString(s)
+}
+
+// breakLine ends the current line and requests n line breaks (2 for a blank
+// line) before the next text.
+func (r *textRenderer) breakLine(n int) {
+	r.flushLine()
+	r.space = false
+	r.newlines = max(r.newlines, n)
+}
+
+func (r *textRenderer) flushLine() {
+	if r.line.Len() == 0 {
+		return
+	}
+
+	r.b.WriteString(strings.TrimRight(r.line.String(), " "))
+	r.line.Reset()
+}
+
+//nolint:gocyclo // a flat switch over element types reads better than a lookup table
+func (r *textRenderer) render(n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		r.write(n.Data)
+
+		return
+	case html.ElementNode:
+	case html.ErrorNode, html.DocumentNode, html.CommentNode, html.DoctypeNode, html.RawNode:
+		r.children(n)
+
+		return
+	}
+
+	if _, hidden := hiddenElements[n.DataAtom]; hidden {
+		return
+	}
+
+	switch n.DataAtom {
+	case atom.Br:
+		r.breakLine(1)
+	case atom.Hr:
+		r.breakLine(2)
+		r.emit("---")
+		r.breakLine(2)
+	case atom.Img:
+		if alt := strings.TrimSpace(attr(n, "alt")); alt != "" {
+			r.write("[" + alt + "]")
+		}
+	case atom.A:
+		r.link(n)
+	case atom.P, atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6, atom.Table, atom.Ul, atom.Ol,
+		atom.Blockquote, atom.Pre:
+		r.breakLine(2)
+		if n.DataAtom == atom.Pre {
+			r.pre++
+		}
+		r.children(n)
+		if n.DataAtom == atom.Pre {
+			r.pre--
+		}
+		r.breakLine(2)
+	case atom.Li:
+		r.breakLine(1)
+		r.emit(listMarker(n))
+		r.children(n)
+		r.breakLine(1)
+	case atom.Td, atom.Th:
+		if prev := prevElement(n); prev != nil {
+			r.emit(" | ")
+		}
+		r.children(n)
+	default:
+		if _, block := blockElements[n.DataAtom]; block {
+			r.breakLine(1)
+			r.children(n)
+			r.breakLine(1)
+
+			return
+		}
+
+		r.children(n)
+	}
+}
+
+func (r *textRenderer) children(n *html.Node) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		r.render(c)
+	}
+}
+
+func (r *textRenderer) link(n *html.Node) {
+	href := strings.TrimSpace(attr(n, "href"))
+	text := nodeText(n)
+
+	r.children(n)
+
+	switch {
+	case href == "", strings.HasPrefix(href, "#"), text == href, text == strings.TrimPrefix(href, "mailto:"):
+		return
+	case text == "":
+		r.write(href)
+	default:
+		r.write(" (" + href + ")")
+	}
+}
+
+// listMarker returns "- " for unordered list items and "N. " for ordered ones.
+func listMarker(li *html.Node) string {
+	if li.Parent == nil || li.Parent.DataAtom != atom.Ol {
+		return "- "
+	}
+
+	i := 1
+	for s := li.PrevSibling; s != nil; s = s.PrevSibling {
+		if s.Type == html.ElementNode && s.DataAtom == atom.Li {
+			i++
+		}
+	}
+
+	return strconv.Itoa(i) + ". "
+}
+
+func prevElement(n *html.Node) *html.Node {
+	for s := n.PrevSibling; s != nil; s = s.PrevSibling {
+		if s.Type == html.ElementNode {
+			return s
+		}
+	}
+
+	return nil
+}
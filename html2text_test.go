@@ -0,0 +1,63 @@
+package mailpitclient
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTMLToText(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		html     string
+		expected string
+	}{
+		{
+			name: "paragraphs and headings",
+			html: `<html><head><title>x</title><style>p{}</style></head><body>
+				<h1>Welcome</h1><p>Hello   <b>Alice</b>,</p><p>Thanks&nbsp;for
+				joining.</p><script>track()</script></body></html>`,
+			expected: "Welcome\n\nHello Alice,\n\nThanks for joining.",
+		},
+		{
+			name:     "links",
+			html:     `<p><a href="https://example.com/verify">Verify</a> or <a href="https://example.com">https://example.com</a> <a href="#top">top</a> <a href="mailto:help@example.com">help@example.com</a></p>`,
+			expected: "Verify (https://example.com/verify) or https://example.com top help@example.com",
+		},
+		{
+			name:     "images and line breaks",
+			html:     `<div><img src="logo.png" alt="Example Inc"><img src="pixel.gif"></div><div>line one<br>line two</div><hr><p>footer</p>`,
+			expected: "[Example Inc]\nline one\nline two\n\n---\n\nfooter",
+		},
+		{
+			name:     "lists",
+			html:     `<ul><li>apples</li><li>pears</li></ul><ol><li>first</li><li>second</li></ol>`,
+			expected: "- apples\n- pears\n\n1. first\n2. second",
+		},
+		{
+			name:     "tables",
+			html:     `<table><tr><th>Item</th><th>Price</th></tr><tr><td>Book</td><td>12.50</td></tr></table>`,
+			expected: "Item | Price\nBook | 12.50",
+		},
+		{
+			name:     "preformatted",
+			html:     "<pre>a  b\n  c</pre>",
+			expected: "a  b\n  c",
+		},
+		{
+			name:     "plain text",
+			html:     "just text",
+			expected: "just text",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			require.Equal(t, tt.expected, HTMLToText(tt.html))
+		})
+	}
+}
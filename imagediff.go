@@ -0,0 +1,211 @@
+package mailpitclient
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif"  // register GIF decoding for DecodeImage
+	_ "image/jpeg" // register JPEG decoding for DecodeImage
+	_ "image/png"  // register PNG decoding for DecodeImage
+	"strings"
+)
+
+// DefaultImageThreshold is the default per-pixel colour distance, between 0
+// and 1, below which two pixels are considered equal. It tolerates
+// re-encoding and anti-aliasing noise while catching visible changes.
+const DefaultImageThreshold = 0.1
+
+// maxYIQDelta is the largest possible squared YIQ distance between two colours.
+const maxYIQDelta = 35215.0
+
+// ImageCompareOptions configures CompareImages.
+type ImageCompareOptions struct {
+	// Threshold is the perceptual colour distance (0 to 1) a pixel must
+	// exceed to count as different. 0 requires exact colour matches.
+	Threshold float64
+	// MaxDiffRatio is the fraction of differing pixels (0 to 1) still
+	// considered a match.
+	MaxDiffRatio float64
+}
+
+// DefaultImageCompareOptions returns options using DefaultImageThreshold and
+// no tolerance for differing pixels.
+func DefaultImageCompareOptions() *ImageCompareOptions {
+	return &ImageCompareOptions{Threshold: DefaultImageThreshold}
+}
+
+// ImageDiff is the result of comparing two images.
+type ImageDiff struct {
+	// Diff shows the baseline faded to grey with differing pixels in red.
+	Diff *image.RGBA
+	// DifferentPixels counts pixels above the threshold, including pixels
+	// present in only one image when the sizes differ.
+	DifferentPixels int
+	TotalPixels     int
+	// SizeMismatch is set when the images have different dimensions.
+	SizeMismatch bool
+	// Match reports whether the images are equal within the options used.
+	Match bool
+}
+
+// Ratio returns the fraction of differing pixels.
+func (d *ImageDiff) Ratio() float64 {
+	if d.TotalPixels == 0 {
+		return 0
+	}
+
+	return float64(d.DifferentPixels) / float64(d.TotalPixels)
+}
+
+// String summarises the difference.
+func (d *ImageDiff) String() string {
+	s := fmt.Sprintf("%d of %d pixels differ (%.2f%%)", d.DifferentPixels, d.TotalPixels, d.Ratio()*100)
+	if d.SizeMismatch {
+		s += ", image sizes differ"
+	}
+
+	return s
+}
+
+// CompareImages compares actual against baseline using a perceptual colour
+// distance in YIQ space, so that changes in brightness weigh more than
+// changes in hue, as they do to the eye. Transparent pixels are blended onto
+// white before comparison. opts may be nil to use DefaultImageCompareOptions.
+func CompareImages(baseline, actual image.Image, opts *ImageCompareOptions) *ImageDiff {
+	if opts == nil {
+		opts = DefaultImageCompareOptions()
+	}
+
+	bb, ab := baseline.Bounds(), actual.Bounds()
+	width, height := max(bb.Dx(), ab.Dx()), max(bb.Dy(), ab.Dy())
+
+	diff := &ImageDiff{
+		Diff:         image.NewRGBA(image.Rect(0, 0, width, height)),
+		TotalPixels:  width * height,
+		SizeMismatch: bb.Size() != ab.Size(),
+	}
+
+	limit := maxYIQDelta * opts.Threshold * opts.Threshold
+	for y := range height {
+		for x := range width {
+			inBaseline := x < bb.Dx() && y < bb.Dy()
+			inActual := x < ab.Dx() && y < ab.Dy()
+
+			if !inBaseline || !inActual {
+				diff.DifferentPixels++
+				diff.Diff.Set(x, y, color.RGBA{R: 255, A: 255})
+
+				continue
+			}
+
+			pb := baseline.At(bb.Min.X+x, bb.Min.Y+y)
+			pa := actual.At(ab.Min.X+x, ab.Min.Y+y)
+			if yiqDelta(pb, pa) > limit {
+				diff.DifferentPixels++
+				diff.Diff.Set(x, y, color.RGBA{R: 255, A: 255})
+
+				continue
+			}
+
+			diff.Diff.Set(x, y, fadedGray(pb))
+		}
+	}
+
+	diff.Match = !diff.SizeMismatch && diff.Ratio() <= opts.MaxDiffRatio
+
+	return diff
+}
+
+// DecodeImage decodes PNG, JPEG or GIF data.
+func DecodeImage(data []byte) (image.Image, error) {
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, &Error{
+			Type:    ErrorTypeResponse,
+			Message: "failed to decode image",
+			Cause:   err,
+		}
+	}
+
+	return img, nil
+}
+
+// MessagePartImage fetches a message part, such as an inline logo, and
+// decodes it as an image.
+func MessagePartImage(ctx context.Context, c Client, messageID, partID string) (image.Image, error) {
+	if c == nil {
+		return nil, NewValidationError("client cannot be nil")
+	}
+
+	data, err := c.GetMessagePart(ctx, messageID, partID)
+	if err != nil {
+		return nil, err
+	}
+
+	return DecodeImage(data)
+}
+
+// MessagePartThumbnail fetches the thumbnail Mailpit renders for an image
+// part and decodes it.
+func MessagePartThumbnail(ctx context.Context, c Client, messageID, partID string) (image.Image, error) {
+	if c == nil {
+		return nil, NewValidationError("client cannot be nil")
+	}
+
+	data, err := c.GetMessagePartThumbnail(ctx, messageID, partID)
+	if err != nil {
+		return nil, err
+	}
+
+	return DecodeImage(data)
+}
+
+// FindPart returns the inline part or attachment whose file name, content ID
+// or part ID equals name, looking at inline parts first. Content IDs match
+// with or without their angle brackets.
+func (m *Message) FindPart(name string) (Attachment, error) {
+	cid := strings.Trim(name, "<>")
+	for _, parts := range []AttachmentList{m.Inline, m.Attachments} {
+		for _, p := range parts {
+			if p.FileName == name || p.PartID == name || (p.ContentID != "" && strings.Trim(p.ContentID, "<>") == cid) {
+				return p, nil
+			}
+		}
+	}
+
+	return Attachment{}, noMatchError(m.ID, fmt.Sprintf("no part named %q", name))
+}
+
+// yiqDelta returns the squared perceptual distance between two colours.
+func yiqDelta(c1, c2 color.Color) float64 {
+	r1, g1, b1 := blendWhite(c1)
+	r2, g2, b2 := blendWhite(c2)
+
+	y := yiqY(r1, g1, b1) - yiqY(r2, g2, b2)
+	i := yiqI(r1, g1, b1) - yiqI(r2, g2, b2)
+	q := yiqQ(r1, g1, b1) - yiqQ(r2, g2, b2)
+
+	return 0.5053*y*y + 0.299*i*i + 0.1957*q*q
+}
+
+// blendWhite returns the colour's 8-bit channels composited onto white.
+func blendWhite(c color.Color) (float64, float64, float64) {
+	r, g, b, a := c.RGBA()
+	white := 65535 - float64(a)
+
+	return (float64(r) + white) / 257, (float64(g) + white) / 257, (float64(b) + white) / 257
+}
+
+func yiqY(r, g, b float64) float64 { return r*0.29889531 + g*0.58662247 + b*0.11448223 }
+func yiqI(r, g, b float64) float64 { return r*0.59597799 - g*0.27417610 - b*0.32180189 }
+func yiqQ(r, g, b float64) float64 { return r*0.21147017 - g*0.52261711 + b*0.31114694 }
+
+// fadedGray renders an unchanged pixel as light grey for context in diffs.
+func fadedGray(c color.Color) color.Color {
+	r, g, b := blendWhite(c)
+	v := 255 - (255-yiqY(r, g, b))*0.1
+
+	return color.Gray{Y: uint8(v)}
+}
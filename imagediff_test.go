@@ -0,0 +1,198 @@
+package mailpitclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"image"
+	"image/color"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// testBanner returns a w×h white image with a blue block in the top left.
+func testBanner(w, h int, block color.Color) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := range h {
+		for x := range w {
+			c := color.Color(color.White)
+			if x < w/2 && y < h/2 {
+				c = block
+			}
+			img.Set(x, y, c)
+		}
+	}
+
+	return img
+}
+
+func encodePNG(t *testing.T, img image.Image) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+
+	return buf.Bytes()
+}
+
+func TestCompareImages(t *testing.T) {
+	t.Parallel()
+
+	blue := color.RGBA{B: 200, A: 255}
+	baseline := testBanner(10, 10, blue)
+
+	tests := []struct {
+		actual       image.Image
+		opts         *ImageCompareOptions
+		name         string
+		different    int
+		match        bool
+		sizeMismatch bool
+	}{
+		{
+			name:   "identical",
+			actual: testBanner(10, 10, blue),
+			match:  true,
+		},
+		{
+			name:   "imperceptible change within threshold",
+			actual: testBanner(10, 10, color.RGBA{R: 2, G: 1, B: 203, A: 255}),
+			match:  true,
+		},
+		{
+			name:      "zero threshold requires exact colours",
+			actual:    testBanner(10, 10, color.RGBA{R: 2, G: 1, B: 203, A: 255}),
+			opts:      &ImageCompareOptions{},
+			different: 25,
+		},
+		{
+			name:      "changed block",
+			actual:    testBanner(10, 10, color.RGBA{R: 200, A: 255}),
+			different: 25,
+		},
+		{
+			name:      "changed block within max diff ratio",
+			actual:    testBanner(10, 10, color.RGBA{R: 200, A: 255}),
+			opts:      &ImageCompareOptions{Threshold: DefaultImageThreshold, MaxDiffRatio: 0.25},
+			different: 25,
+			match:     true,
+		},
+		{
+			name:         "different size",
+			actual:       testBanner(10, 12, blue),
+			different:    25, // 20 extra pixels plus the taller block's extra row
+			sizeMismatch: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			diff := CompareImages(baseline, tt.actual, tt.opts)
+			require.Equal(t, tt.match, diff.Match, diff.String())
+			require.Equal(t, tt.different, diff.DifferentPixels)
+			require.Equal(t, tt.sizeMismatch, diff.SizeMismatch)
+			require.Equal(t, tt.actual.Bounds().Size(), diff.Diff.Bounds().Size())
+		})
+	}
+
+	opaque := testBanner(4, 4, color.White)
+	transparent := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	require.True(t, CompareImages(opaque, transparent, nil).Match, "transparent pixels are blended onto white")
+
+	diff := CompareImages(baseline, testBanner(10, 10, color.RGBA{R: 200, A: 255}), nil)
+	require.Equal(t, color.RGBA{R: 255, A: 255}, diff.Diff.RGBAAt(0, 0), "differing pixels are red")
+	require.InDelta(t, 0.25, diff.Ratio(), 1e-9)
+	require.Equal(t, "25 of 100 pixels differ (25.00%)", diff.String())
+}
+
+func TestDecodeImage(t *testing.T) {
+	t.Parallel()
+
+	img, err := DecodeImage(encodePNG(t, testBanner(3, 2, color.Black)))
+	require.NoError(t, err)
+	require.Equal(t, image.Pt(3, 2), img.Bounds().Size())
+
+	_, err = DecodeImage([]byte("not an image"))
+	require.Error(t, err)
+}
+
+func TestMessage_FindPart(t *testing.T) {
+	t.Parallel()
+
+	msg := &Message{
+		ID: "msg-1",
+		Inline: AttachmentList{
+			{PartID: "1.2", FileName: "logo.png", ContentID: "logo@example.com"},
+		},
+		Attachments: AttachmentList{
+			{PartID: "2", FileName: "banner.png"},
+		},
+	}
+
+	for _, name := range []string{"logo.png", "1.2", "logo@example.com", "<logo@example.com>"} {
+		part, err := msg.FindPart(name)
+		require.NoError(t, err, name)
+		require.Equal(t, "1.2", part.PartID)
+	}
+
+	part, err := msg.FindPart("banner.png")
+	require.NoError(t, err)
+	require.Equal(t, "2", part.PartID)
+
+	_, err = msg.FindPart("missing.png")
+	require.ErrorIs(t, err, ErrNoMatch)
+}
+
+func TestMessagePartImage(t *testing.T) {
+	t.Parallel()
+
+	logo := encodePNG(t, testBanner(8, 8, color.Black))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/message/msg-1/part/2":
+			_, _ = w.Write(logo)
+		case "/api/v1/message/msg-1/part/2/thumb":
+			_, _ = w.Write(encodePNG(t, testBanner(4, 4, color.Black)))
+		case "/api/v1/message/msg-1/part/3":
+			_, _ = w.Write([]byte("%PDF-1.4"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_ = json.NewEncoder(w).Encode(map[string]string{"Error": "not found"})
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	c, err := NewClient(&Config{
+		BaseURL:    server.URL,
+		APIPath:    "/api/v1",
+		MaxRetries: 0,
+		HTTPClient: &http.Client{Timeout: 5 * time.Second},
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = c.Close() })
+
+	ctx := t.Context()
+
+	img, err := MessagePartImage(ctx, c, "msg-1", "2")
+	require.NoError(t, err)
+	require.Equal(t, image.Pt(8, 8), img.Bounds().Size())
+
+	thumb, err := MessagePartThumbnail(ctx, c, "msg-1", "2")
+	require.NoError(t, err)
+	require.Equal(t, image.Pt(4, 4), thumb.Bounds().Size())
+
+	_, err = MessagePartImage(ctx, c, "msg-1", "3")
+	require.Error(t, err)
+
+	_, err = MessagePartThumbnail(ctx, c, "msg-1", "9")
+	require.ErrorIs(t, err, ErrNotFound)
+
+	_, err = MessagePartImage(ctx, nil, "msg-1", "2")
+	require.Error(t, err)
+}
@@ -0,0 +1,288 @@
+package mailpitclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/textproto"
+	"strings"
+	"sync"
+)
+
+// LintCheck identifies a single check performed by Lint.
+type LintCheck string
+
+const (
+	// LintCheckHeaders verifies that required headers are present
+	LintCheckHeaders LintCheck = "headers"
+
+	// LintCheckHTML verifies HTML compatibility across email clients
+	LintCheckHTML LintCheck = "html"
+
+	// LintCheckLinks verifies that all links in the message are reachable
+	LintCheckLinks LintCheck = "links"
+
+	// LintCheckSpam verifies the SpamAssassin score of the message
+	LintCheckSpam LintCheck = "spam"
+)
+
+// LintPolicy configures which checks Lint runs and the thresholds they must meet.
+// Each check is enabled by its Check field, so a zero threshold is a valid
+// limit, e.g. MaxSpamScore 0 allows no spam points at all.
+type LintPolicy struct {
+	// RequiredHeaders lists headers that must be present, e.g. "List-Unsubscribe".
+	RequiredHeaders []string
+	// Clients lists email client families (e.g. "gmail", "outlook") that must
+	// each meet MinHTMLCompatibility. If empty, the overall score is used.
+	Clients []string
+	// MaxSpamScore is the highest acceptable SpamAssassin score.
+	MaxSpamScore float64
+	// MinHTMLCompatibility is the lowest acceptable HTML compatibility percentage.
+	MinHTMLCompatibility float64
+	// CheckHTML fails the report if the HTML compatibility is below
+	// MinHTMLCompatibility.
+	CheckHTML bool
+	// CheckLinks fails the report if any link in the message is broken.
+	CheckLinks bool
+	// CheckSpam fails the report if the SpamAssassin score exceeds MaxSpamScore.
+	CheckSpam bool
+}
+
+// DefaultLintPolicy returns a policy suitable for most transactional emails.
+func DefaultLintPolicy() *LintPolicy {
+	return &LintPolicy{
+		MaxSpamScore:         5,
+		MinHTMLCompatibility: 80,
+		CheckHTML:            true,
+		CheckLinks:           true,
+		CheckSpam:            true,
+	}
+}
+
+// LintResult holds the outcome of a single check.
+type LintResult struct {
+	Check    LintCheck `json:"check"`
+	Error    string    `json:"error,omitempty"`
+	Failures []string  `json:"failures,omitempty"`
+}
+
+// Passed reports whether the check ran successfully and found no violations.
+func (r *LintResult) Passed() bool {
+	return r.Error == "" && len(r.Failures) == 0
+}
+
+// LintReport is the unified result of running all checks against a message.
+type LintReport struct {
+	HTMLCheck         *HTMLCheckResponse         `json:"-"`
+	LinkCheck         *LinkCheckResponse         `json:"-"`
+	SpamAssassinCheck *SpamAssassinCheckResponse `json:"-"`
+	MessageID         string                     `json:"message_id"`
+	Subject           string                     `json:"subject"`
+	Results           []LintResult               `json:"results"`
+}
+
+// Passed reports whether every check in the report passed.
+func (r *LintReport) Passed() bool {
+	for i := range r.Results {
+		if !r.Results[i].Passed() {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Failures returns all failures and check errors, prefixed with the check name.
+func (r *LintReport) Failures() []string {
+	var out []string
+	for _, res := range r.Results {
+		if res.Error != "" {
+			out = append(out, fmt.Sprintf("%s: %s", res.Check, res.Error))
+		}
+		for _, f := range res.Failures {
+			out = append(out, fmt.Sprintf("%s: %s", res.Check, f))
+		}
+	}
+
+	return out
+}
+
+// JSON renders the report as indented JSON.
+func (r *LintReport) JSON() ([]byte, error) {
+	out := struct {
+		*LintReport
+		Passed bool `json:"passed"`
+	}{
+		LintReport: r,
+		Passed:     r.Passed(),
+	}
+
+	return json.MarshalIndent(out, "", "  ")
+}
+
+// JUnit renders the report as JUnit XML with one test case per check.
+func (r *LintReport) JUnit() ([]byte, error) {
+	reporter := NewReporter()
+	reporter.AddLintReport(r)
+
+	var buf bytes.Buffer
+	if err := reporter.WriteJUnit(&buf); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// Lint runs the HTML, link and SpamAssassin checks required by policy
+// concurrently against a message and evaluates them into a single report.
+// Checks that cannot be performed (for example when SpamAssassin is not
+// enabled) are recorded as errors in the report rather than returned.
+// If policy is nil, DefaultLintPolicy is used.
+func Lint(ctx context.Context, c Client, id string, policy *LintPolicy) (*LintReport, error) {
+	if c == nil {
+		return nil, NewValidationError("client cannot be nil")
+	}
+	if id == "" {
+		return nil, NewValidationError("message ID cannot be empty")
+	}
+	if policy == nil {
+		policy = DefaultLintPolicy()
+	}
+
+	report := &LintReport{MessageID: id}
+
+	var (
+		wg         sync.WaitGroup
+		headers    map[string][]string
+		headersRes = &LintResult{Check: LintCheckHeaders}
+		results    = []*LintResult{headersRes}
+	)
+
+	// Headers are always fetched so the report can carry the message subject.
+	wg.Go(func() {
+		var err error
+		if headers, err = c.GetMessageHeaders(ctx, id); err != nil {
+			headersRes.Error = err.Error()
+		}
+	})
+
+	if policy.CheckHTML {
+		res := &LintResult{Check: LintCheckHTML}
+		results = append(results, res)
+
+		wg.Go(func() {
+			check, err := c.GetMessageHTMLCheck(ctx, id)
+			if err != nil {
+				res.Error = err.Error()
+
+				return
+			}
+
+			report.HTMLCheck = check
+			res.Failures = lintHTML(check, policy)
+		})
+	}
+
+	if policy.CheckLinks {
+		res := &LintResult{Check: LintCheckLinks}
+		results = append(results, res)
+
+		wg.Go(func() {
+			check, err := c.GetMessageLinkCheck(ctx, id)
+			if err != nil {
+				res.Error = err.Error()
+
+				return
+			}
+
+			report.LinkCheck = check
+			for _, link := range check.FailingLinks() {
+				res.Failures = append(res.Failures, fmt.Sprintf("broken link %s: %d %s", link.URL, link.StatusCode, link.Status))
+			}
+		})
+	}
+
+	if policy.CheckSpam {
+		res := &LintResult{Check: LintCheckSpam}
+		results = append(results, res)
+
+		wg.Go(func() {
+			check, err := c.GetMessageSpamAssassinCheck(ctx, id)
+			if err != nil {
+				res.Error = err.Error()
+
+				return
+			}
+
+			report.SpamAssassinCheck = check
+			if check.Error != "" {
+				res.Error = check.Error
+
+				return
+			}
+
+			if check.Score > policy.MaxSpamScore {
+				res.Failures = append(res.Failures, fmt.Sprintf("spam score %.2f exceeds maximum %.2f", check.Score, policy.MaxSpamScore))
+			}
+		})
+	}
+
+	wg.Wait()
+
+	if headers != nil {
+		report.Subject = firstHeader(headers, "Subject")
+
+		for _, name := range policy.RequiredHeaders {
+			if firstHeader(headers, name) == "" {
+				headersRes.Failures = append(headersRes.Failures, "missing required header "+name)
+			}
+		}
+	}
+
+	for _, res := range results {
+		report.Results = append(report.Results, *res)
+	}
+
+	return report, nil
+}
+
+func lintHTML(check *HTMLCheckResponse, policy *LintPolicy) []string {
+	var failures []string
+
+	if len(policy.Clients) == 0 {
+		if score := check.CompatibilityScore(); score < policy.MinHTMLCompatibility {
+			failures = append(failures, fmt.Sprintf("HTML compatibility %.1f%% is below minimum %.1f%%", score, policy.MinHTMLCompatibility))
+		}
+
+		return failures
+	}
+
+	for _, client := range policy.Clients {
+		score := check.ClientSupport(client)
+		if compat := score.Supported + score.Partial/2; compat < policy.MinHTMLCompatibility {
+			msg := fmt.Sprintf("HTML compatibility for %s %.1f%% is below minimum %.1f%%", client, compat, policy.MinHTMLCompatibility)
+			for _, w := range check.UnsupportedBy(client) {
+				msg += "\n  unsupported: " + w.Title
+			}
+			failures = append(failures, msg)
+		}
+	}
+
+	return failures
+}
+
+// firstHeader returns the first value of a header, matching names case-insensitively.
+func firstHeader(headers map[string][]string, name string) string {
+	if values := headers[textproto.CanonicalMIMEHeaderKey(name)]; len(values) > 0 {
+		return values[0]
+	}
+
+	for k, values := range headers {
+		if strings.EqualFold(k, name) && len(values) > 0 {
+			return values[0]
+		}
+	}
+
+	return ""
+}
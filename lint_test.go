@@ -0,0 +1,189 @@
+package mailpitclient
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newLintTestClient(t *testing.T, spamStatus int, spamBody string) Client {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/headers"):
+			_, _ = w.Write([]byte(`{"Subject": ["Welcome aboard"], "From": ["noreply@example.com"]}`))
+		case strings.HasSuffix(r.URL.Path, "/html-check"):
+			_, _ = w.Write([]byte(htmlCheckFixture))
+		case strings.HasSuffix(r.URL.Path, "/link-check"):
+			_, _ = w.Write([]byte(`{"Errors": 1, "Links": [
+				{"URL": "https://example.com", "StatusCode": 200, "Status": "OK"},
+				{"URL": "https://example.com/missing", "StatusCode": 404, "Status": "Not Found"}
+			]}`))
+		case strings.HasSuffix(r.URL.Path, "/sa-check"):
+			w.WriteHeader(spamStatus)
+			_, _ = w.Write([]byte(spamBody))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	c, err := NewClient(&Config{
+		BaseURL:    server.URL,
+		APIPath:    "/api/v1",
+		MaxRetries: 0,
+		HTTPClient: &http.Client{Timeout: 5 * time.Second},
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = c.Close() })
+
+	return c
+}
+
+func TestLint(t *testing.T) {
+	t.Parallel()
+
+	t.Run("all checks pass", func(t *testing.T) {
+		t.Parallel()
+
+		c := newLintTestClient(t, http.StatusOK, `{"Score": 1.2, "IsSpam": false}`)
+
+		report, err := Lint(t.Context(), c, "msg-1", &LintPolicy{
+			MaxSpamScore:         5,
+			MinHTMLCompatibility: 80,
+			CheckHTML:            true,
+			CheckSpam:            true,
+			Clients:              []string{"apple-mail"},
+			RequiredHeaders:      []string{"from"},
+		})
+		require.NoError(t, err)
+		require.True(t, report.Passed(), report.Failures())
+		require.Equal(t, "Welcome aboard", report.Subject)
+		require.Len(t, report.Results, 3)
+		require.NotNil(t, report.HTMLCheck)
+		require.NotNil(t, report.SpamAssassinCheck)
+		require.Nil(t, report.LinkCheck)
+	})
+
+	t.Run("violations are reported", func(t *testing.T) {
+		t.Parallel()
+
+		c := newLintTestClient(t, http.StatusOK, `{"Score": 7.5, "IsSpam": true}`)
+
+		policy := DefaultLintPolicy()
+		policy.Clients = []string{"gmail"}
+		policy.MinHTMLCompatibility = 95
+		policy.RequiredHeaders = []string{"List-Unsubscribe"}
+
+		report, err := Lint(t.Context(), c, "msg-1", policy)
+		require.NoError(t, err)
+		require.False(t, report.Passed())
+
+		failures := report.Failures()
+		require.Len(t, failures, 4)
+		require.Contains(t, failures[0], "missing required header List-Unsubscribe")
+		require.Contains(t, failures[1], "HTML compatibility for gmail")
+		require.Contains(t, failures[1], "unsupported: display:flex")
+		require.Contains(t, failures[2], "broken link https://example.com/missing: 404")
+		require.Contains(t, failures[3], "spam score 7.50 exceeds maximum 5.00")
+	})
+
+	t.Run("zero thresholds are limits", func(t *testing.T) {
+		t.Parallel()
+
+		c := newLintTestClient(t, http.StatusOK, `{"Score": 0.1, "IsSpam": false}`)
+
+		report, err := Lint(t.Context(), c, "msg-1", &LintPolicy{CheckSpam: true, CheckHTML: true})
+		require.NoError(t, err)
+		require.Equal(t, []string{"spam: spam score 0.10 exceeds maximum 0.00"}, report.Failures(),
+			"a zero HTML minimum always passes, a zero spam maximum allows no points")
+
+		report, err = Lint(t.Context(), c, "msg-1", &LintPolicy{MaxSpamScore: 5})
+		require.NoError(t, err)
+		require.Len(t, report.Results, 1, "thresholds alone do not enable checks")
+	})
+
+	t.Run("check errors fail the report", func(t *testing.T) {
+		t.Parallel()
+
+		c := newLintTestClient(t, http.StatusBadRequest, `SpamAssassin is not enabled`)
+
+		report, err := Lint(t.Context(), c, "msg-1", &LintPolicy{MaxSpamScore: 5, CheckSpam: true})
+		require.NoError(t, err)
+		require.False(t, report.Passed())
+		require.Len(t, report.Results, 2)
+		require.Equal(t, LintCheckSpam, report.Results[1].Check)
+		require.NotEmpty(t, report.Results[1].Error)
+	})
+
+	t.Run("validation", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := Lint(t.Context(), nil, "msg-1", nil)
+		require.Error(t, err)
+
+		c := newLintTestClient(t, http.StatusOK, `{}`)
+		_, err = Lint(t.Context(), c, "", nil)
+
+		var mailpitErr *Error
+		require.ErrorAs(t, err, &mailpitErr)
+		require.Equal(t, ErrorTypeValidation, mailpitErr.Type)
+	})
+}
+
+func TestLintReport_Render(t *testing.T) {
+	t.Parallel()
+
+	report := &LintReport{
+		MessageID: "msg-1",
+		Subject:   "Password reset",
+		Results: []LintResult{
+			{Check: LintCheckHeaders},
+			{Check: LintCheckLinks, Failures: []string{"broken link https://example.com: 500"}},
+			{Check: LintCheckSpam, Error: "SpamAssassin is not enabled"},
+		},
+	}
+
+	t.Run("JSON", func(t *testing.T) {
+		t.Parallel()
+
+		data, err := report.JSON()
+		require.NoError(t, err)
+
+		var decoded map[string]any
+		require.NoError(t, json.Unmarshal(data, &decoded))
+		require.Equal(t, false, decoded["passed"])
+		require.Equal(t, "msg-1", decoded["message_id"])
+		require.Len(t, decoded["results"], 3)
+	})
+
+	t.Run("JUnit", func(t *testing.T) {
+		t.Parallel()
+
+		data, err := report.JUnit()
+		require.NoError(t, err)
+		require.True(t, strings.HasPrefix(string(data), xml.Header))
+
+		var suites junitSuites
+		require.NoError(t, xml.Unmarshal(data, &suites))
+		require.Len(t, suites.Suites, 1)
+
+		suite := suites.Suites[0]
+		require.Equal(t, "Password reset", suite.Name)
+		require.Equal(t, 3, suite.Tests)
+		require.Equal(t, 1, suite.Failures)
+		require.Equal(t, 1, suite.Errors)
+		require.Nil(t, suite.Cases[0].Failure)
+		require.Contains(t, suite.Cases[1].Failure.Text, "https://example.com")
+		require.Equal(t, "SpamAssassin is not enabled", suite.Cases[2].Error.Message)
+	})
+}
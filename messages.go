@@ -41,7 +41,18 @@ func (c *client) GetMessage(ctx context.Context, id string) (*Message, error) {
 		return nil, NewValidationError("message ID cannot be empty")
 	}
 
-	resp, err := c.makeRequest(ctx, http.MethodGet, "/message/"+id, nil)
+	// Mailpit marks a message read when it is fetched, so look up the
+	// current state first if it must be preserved.
+	preserve := c.config.PreserveReadState || readStatePreserved(ctx)
+	wasRead := true
+	if preserve {
+		var err error
+		if wasRead, err = c.readState(ctx, id); err != nil {
+			return nil, err
+		}
+	}
+
+	resp, err := c.makeRequest(ctx, http.MethodGet, "/message/"+url.PathEscape(id), nil)
 	if err != nil {
 		return nil, err
 	}
@@ -51,6 +62,13 @@ func (c *client) GetMessage(ctx context.Context, id string) (*Message, error) {
 		return nil, err
 	}
 
+	if !wasRead {
+		if err = c.MarkMessageUnread(ctx, id); err != nil {
+			return nil, err
+		}
+		message.Read = false
+	}
+
 	return &message, nil
 }
 
@@ -60,7 +78,7 @@ func (c *client) GetMessageSource(ctx context.Context, id string) (string, error
 		return "", NewValidationError("message ID cannot be empty")
 	}
 
-	endpoint := fmt.Sprintf("/messages/%s/source", id)
+	endpoint := fmt.Sprintf("/message/%s/raw", url.PathEscape(id))
 
 	resp, err := c.makeRequest(ctx, http.MethodGet, endpoint, nil)
 	if err != nil {
@@ -80,13 +98,28 @@ func (c *client) GetMessageSource(ctx context.Context, id string) (string, error
 	return string(body), nil
 }
 
-// DeleteMessage deletes a specific message by its ID.
+// deleteMessagesRequest is the body of Mailpit's "delete messages" endpoint.
+type deleteMessagesRequest struct {
+	IDs []string `json:"IDs"`
+}
+
+// DeleteMessage deletes a specific message by its ID through Mailpit's bulk
+// "delete messages" endpoint.
 func (c *client) DeleteMessage(ctx context.Context, id string) error {
 	if id == "" {
 		return NewValidationError("message ID cannot be empty")
 	}
 
-	resp, err := c.makeRequest(ctx, http.MethodDelete, "/messages/"+id, nil)
+	var body bytes.Buffer
+	if err := json.NewEncoder(&body).Encode(deleteMessagesRequest{IDs: []string{id}}); err != nil {
+		return &Error{
+			Type:    ErrorTypeRequest,
+			Message: fmt.Sprintf("failed to encode message IDs: %v", err),
+			Cause:   err,
+		}
+	}
+
+	resp, err := c.makeRequest(ctx, http.MethodDelete, "/messages", &body)
 	if err != nil {
 		return err
 	}
@@ -143,7 +176,9 @@ func (c *client) SearchMessages(ctx context.Context, query string, opts *SearchO
 	return &result, nil
 }
 
-// GetMessageAttachment retrieves a specific attachment from a message.
+// GetMessageAttachment retrieves a specific attachment from a message. Mailpit
+// serves attachments as message parts, so attachmentID is the attachment's
+// PartID.
 func (c *client) GetMessageAttachment(ctx context.Context, messageID, attachmentID string) ([]byte, error) {
 	if messageID == "" {
 		return nil, NewValidationError("message ID cannot be empty")
@@ -152,7 +187,7 @@ func (c *client) GetMessageAttachment(ctx context.Context, messageID, attachment
 		return nil, NewValidationError("attachment ID cannot be empty")
 	}
 
-	endpoint := fmt.Sprintf("/messages/%s/part/%s", messageID, attachmentID)
+	endpoint := fmt.Sprintf("/message/%s/part/%s", url.PathEscape(messageID), url.PathEscape(attachmentID))
 
 	resp, err := c.makeRequest(ctx, http.MethodGet, endpoint, nil)
 	if err != nil {
@@ -174,30 +209,31 @@ func (c *client) GetMessageAttachment(ctx context.Context, messageID, attachment
 
 // MarkMessageRead marks a message as read.
 func (c *client) MarkMessageRead(ctx context.Context, id string) error {
-	if id == "" {
-		return NewValidationError("message ID cannot be empty")
-	}
-
-	endpoint := fmt.Sprintf("/messages/%s/read", id)
-
-	resp, err := c.makeRequest(ctx, http.MethodPut, endpoint, nil)
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
-
-	return nil
+	return c.setReadStatus(ctx, id, true)
 }
 
 // MarkMessageUnread marks a message as unread.
 func (c *client) MarkMessageUnread(ctx context.Context, id string) error {
+	return c.setReadStatus(ctx, id, false)
+}
+
+// setReadStatus sets the read status of a message through Mailpit's bulk
+// "set read status" endpoint.
+func (c *client) setReadStatus(ctx context.Context, id string, read bool) error {
 	if id == "" {
 		return NewValidationError("message ID cannot be empty")
 	}
 
-	endpoint := fmt.Sprintf("/messages/%s/unread", id)
+	var body bytes.Buffer
+	if err := json.NewEncoder(&body).Encode(readStatusRequest{IDs: []string{id}, Read: read}); err != nil {
+		return &Error{
+			Type:    ErrorTypeRequest,
+			Message: fmt.Sprintf("failed to encode read status: %v", err),
+			Cause:   err,
+		}
+	}
 
-	resp, err := c.makeRequest(ctx, http.MethodPut, endpoint, nil)
+	resp, err := c.makeRequest(ctx, http.MethodPut, "/messages", &body)
 	if err != nil {
 		return err
 	}
@@ -212,7 +248,7 @@ func (c *client) GetMessageHeaders(ctx context.Context, id string) (map[string][
 		return nil, NewValidationError("message ID cannot be empty")
 	}
 
-	endpoint := fmt.Sprintf("/message/%s/headers", id)
+	endpoint := fmt.Sprintf("/message/%s/headers", url.PathEscape(id))
 
 	resp, err := c.makeRequest(ctx, http.MethodGet, endpoint, nil)
 	if err != nil {
@@ -233,7 +269,7 @@ func (c *client) GetMessageHTMLCheck(ctx context.Context, id string) (*HTMLCheck
 		return nil, NewValidationError("message ID cannot be empty")
 	}
 
-	endpoint := fmt.Sprintf("/message/%s/html-check", id)
+	endpoint := fmt.Sprintf("/message/%s/html-check", url.PathEscape(id))
 
 	resp, err := c.makeRequest(ctx, http.MethodGet, endpoint, nil)
 	if err != nil {
@@ -254,7 +290,7 @@ func (c *client) GetMessageLinkCheck(ctx context.Context, id string) (*LinkCheck
 		return nil, NewValidationError("message ID cannot be empty")
 	}
 
-	endpoint := fmt.Sprintf("/message/%s/link-check", id)
+	endpoint := fmt.Sprintf("/message/%s/link-check", url.PathEscape(id))
 
 	resp, err := c.makeRequest(ctx, http.MethodGet, endpoint, nil)
 	if err != nil {
@@ -275,7 +311,7 @@ func (c *client) GetMessageSpamAssassinCheck(ctx context.Context, id string) (*S
 		return nil, NewValidationError("message ID cannot be empty")
 	}
 
-	endpoint := fmt.Sprintf("/message/%s/sa-check", id)
+	endpoint := fmt.Sprintf("/message/%s/sa-check", url.PathEscape(id))
 
 	resp, err := c.makeRequest(ctx, http.MethodGet, endpoint, nil)
 	if err != nil {
@@ -299,7 +335,7 @@ func (c *client) GetMessagePart(ctx context.Context, messageID, partID string) (
 		return nil, NewValidationError("part ID cannot be empty")
 	}
 
-	endpoint := fmt.Sprintf("/message/%s/part/%s", messageID, partID)
+	endpoint := fmt.Sprintf("/message/%s/part/%s", url.PathEscape(messageID), url.PathEscape(partID))
 
 	resp, err := c.makeRequest(ctx, http.MethodGet, endpoint, nil)
 	if err != nil {
@@ -328,7 +364,7 @@ func (c *client) GetMessagePartThumbnail(ctx context.Context, messageID, partID
 		return nil, NewValidationError("part ID cannot be empty")
 	}
 
-	endpoint := fmt.Sprintf("/message/%s/part/%s/thumb", messageID, partID)
+	endpoint := fmt.Sprintf("/message/%s/part/%s/thumb", url.PathEscape(messageID), url.PathEscape(partID))
 
 	resp, err := c.makeRequest(ctx, http.MethodGet, endpoint, nil)
 	if err != nil {
@@ -357,7 +393,7 @@ func (c *client) ReleaseMessage(ctx context.Context, id string, releaseData *Rel
 		return NewValidationError("release data cannot be nil")
 	}
 
-	endpoint := fmt.Sprintf("/message/%s/release", id)
+	endpoint := fmt.Sprintf("/message/%s/release", url.PathEscape(id))
 
 	var body bytes.Buffer
 	if err := json.NewEncoder(&body).Encode(releaseData); err != nil {
@@ -1,6 +1,8 @@
 package mailpitclient
 
 import (
+	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -51,7 +53,7 @@ func TestClient_GetMessageSource(t *testing.T) {
 
 			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 				require.Equal(t, http.MethodGet, r.Method)
-				require.Contains(t, r.URL.Path, "/messages/"+tt.messageID+"/source")
+				require.Equal(t, "/api/v1/message/"+tt.messageID+"/raw", r.URL.Path)
 
 				if tt.serverStatus == http.StatusOK {
 					w.Header().Set("Content-Type", "text/plain")
@@ -81,6 +83,8 @@ func TestClient_GetMessageSource(t *testing.T) {
 				var mailpitErr *Error
 				require.ErrorAs(t, err, &mailpitErr)
 				require.Equal(t, tt.errorType, mailpitErr.Type)
+				require.Equal(t, tt.serverStatus == http.StatusNotFound, errors.Is(err, ErrNotFound))
+				require.Equal(t, tt.serverStatus >= http.StatusInternalServerError, errors.Is(err, ErrServerUnavailable))
 				require.Empty(t, result)
 			} else {
 				require.NoError(t, err)
@@ -128,7 +132,11 @@ func TestClient_DeleteMessage(t *testing.T) {
 
 			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 				require.Equal(t, http.MethodDelete, r.Method)
-				require.Contains(t, r.URL.Path, "/messages/"+tt.messageID)
+				require.Equal(t, "/api/v1/messages", r.URL.Path)
+
+				var body deleteMessagesRequest
+				require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+				require.Equal(t, []string{tt.messageID}, body.IDs)
 
 				w.WriteHeader(tt.serverStatus)
 			}))
@@ -295,7 +303,11 @@ func TestClient_MarkMessageRead(t *testing.T) {
 
 			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 				require.Equal(t, http.MethodPut, r.Method)
-				require.Contains(t, r.URL.Path, "/messages/"+tt.messageID+"/read")
+				require.Equal(t, "/api/v1/messages", r.URL.Path)
+
+				var body readStatusRequest
+				require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+				require.Equal(t, readStatusRequest{IDs: []string{tt.messageID}, Read: true}, body)
 
 				w.WriteHeader(tt.serverStatus)
 			}))
@@ -357,7 +369,11 @@ func TestClient_MarkMessageUnread(t *testing.T) {
 
 			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 				require.Equal(t, http.MethodPut, r.Method)
-				require.Contains(t, r.URL.Path, "/messages/"+tt.messageID+"/unread")
+				require.Equal(t, "/api/v1/messages", r.URL.Path)
+
+				var body readStatusRequest
+				require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+				require.Equal(t, readStatusRequest{IDs: []string{tt.messageID}, Read: false}, body)
 
 				w.WriteHeader(tt.serverStatus)
 			}))
@@ -403,9 +419,10 @@ func TestClient_GetMessageLinkCheck(t *testing.T) {
 			name:      "successful link check",
 			messageID: "test-message-id",
 			serverResponse: `{
-				"links": [
-					{"url": "https://example.com", "status": 200},
-					{"url": "https://broken.com", "status": 404, "error": "Not found"}
+				"Errors": 1,
+				"Links": [
+					{"URL": "https://example.com", "StatusCode": 200, "Status": "OK"},
+					{"URL": "https://broken.com", "StatusCode": 404, "Status": "Not Found"}
 				]
 			}`,
 			serverStatus: http.StatusOK,
@@ -459,7 +476,9 @@ func TestClient_GetMessageLinkCheck(t *testing.T) {
 				require.NotNil(t, result)
 				require.Len(t, result.Links, 2)
 				require.Equal(t, "https://example.com", result.Links[0].URL)
-				require.Equal(t, float64(200), result.Links[0].Status) // JSON numbers become float64 with any type
+				require.Equal(t, 200, result.Links[0].StatusCode)
+				require.Equal(t, "OK", result.Links[0].Status)
+				require.Equal(t, 1, result.Errors)
 			}
 		})
 	}
@@ -480,9 +499,9 @@ func TestClient_GetMessageSpamAssassinCheck(t *testing.T) {
 			name:      "successful spam check",
 			messageID: "test-message-id",
 			serverResponse: `{
-				"score": 2.5,
-				"symbols": [{"name": "TEST_SYMBOL", "score": 1.0, "description": "Test symbol"}],
-				"report": [{"score": 1.0, "description": "Test report"}]
+				"Score": 2.5,
+				"IsSpam": false,
+				"Rules": [{"Name": "TEST_RULE", "Score": 1.0, "Description": "Test rule"}]
 			}`,
 			serverStatus: http.StatusOK,
 			expectError:  false,
@@ -534,8 +553,9 @@ func TestClient_GetMessageSpamAssassinCheck(t *testing.T) {
 				require.NoError(t, err)
 				require.NotNil(t, result)
 				require.InDelta(t, 2.5, result.Score, 0.01)
-				require.Len(t, result.Symbols, 1)
-				require.Equal(t, "TEST_SYMBOL", result.Symbols[0].Name)
+				require.False(t, result.Flagged)
+				require.Len(t, result.Rules, 1)
+				require.Equal(t, "TEST_RULE", result.Rules[0].Name)
 			}
 		})
 	}
@@ -0,0 +1,251 @@
+package mailpitclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Instance is a named Mailpit client used by MultiClient.
+type Instance struct {
+	Client Client
+	// Name labels the results returned by this instance, e.g. "billing-staging".
+	Name string
+}
+
+// InstanceError is the error returned by a single instance of a MultiClient.
+type InstanceError struct {
+	Err      error
+	Instance string
+}
+
+// Error implements the error interface.
+func (e *InstanceError) Error() string {
+	return fmt.Sprintf("mailpit instance %s: %v", e.Instance, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *InstanceError) Unwrap() error {
+	return e.Err
+}
+
+// LabeledMessage is a message summary together with the instance it came from.
+type LabeledMessage struct {
+	MessageSummary
+
+	Instance string `json:"Instance"`
+}
+
+// MultiMessagesResponse is the merged result of listing or searching messages
+// on several instances. Messages are sorted by Created, newest first.
+type MultiMessagesResponse struct {
+	// Errors holds the error returned by each instance that failed.
+	Errors   map[string]error `json:"-"`
+	Messages []LabeledMessage `json:"messages"`
+	Total    int              `json:"total"`
+	Unread   int              `json:"unread"`
+}
+
+// MultiStatsResponse holds the statistics of each instance and their totals.
+type MultiStatsResponse struct {
+	Stats map[string]*Stats `json:"stats"`
+	// Errors holds the error returned by each instance that failed.
+	Errors map[string]error `json:"-"`
+	Total  int              `json:"total"`
+	Unread int              `json:"unread"`
+}
+
+// MultiResult reports the per-instance outcome of an operation without a result.
+type MultiResult struct {
+	// Errors holds the error returned by each instance that failed.
+	Errors map[string]error
+	// Succeeded lists the instances on which the operation succeeded.
+	Succeeded []string
+}
+
+// MultiClient fans out queries to several Mailpit instances concurrently and
+// merges their results. A failing instance is reported in the Errors field of
+// the result rather than failing the whole call; an error is only returned if
+// the arguments are invalid or every instance failed.
+type MultiClient struct {
+	instances []Instance
+}
+
+// NewMultiClient creates a MultiClient for the given instances. Instance names
+// must be unique and not empty.
+func NewMultiClient(instances ...Instance) (*MultiClient, error) {
+	if len(instances) == 0 {
+		return nil, NewConfigError("at least one instance is required")
+	}
+
+	seen := make(map[string]struct{}, len(instances))
+	for _, inst := range instances {
+		if inst.Name == "" {
+			return nil, NewConfigError("instance name cannot be empty")
+		}
+		if inst.Client == nil {
+			return nil, NewConfigError(fmt.Sprintf("client for instance %s cannot be nil", inst.Name))
+		}
+		if _, ok := seen[inst.Name]; ok {
+			return nil, NewConfigError("duplicate instance name " + inst.Name)
+		}
+		seen[inst.Name] = struct{}{}
+	}
+
+	return &MultiClient{instances: append([]Instance(nil), instances...)}, nil
+}
+
+// Instances returns the instance names in the order they were configured.
+func (m *MultiClient) Instances() []string {
+	names := make([]string, 0, len(m.instances))
+	for _, inst := range m.instances {
+		names = append(names, inst.Name)
+	}
+
+	return names
+}
+
+// Close closes every instance client.
+func (m *MultiClient) Close() error {
+	var errs []error
+	for _, inst := range m.instances {
+		if err := inst.Client.Close(); err != nil {
+			errs = append(errs, &InstanceError{Instance: inst.Name, Err: err})
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// ListMessages lists messages on every instance. opts is applied to each
+// instance separately, so Limit bounds the messages per instance.
+func (m *MultiClient) ListMessages(ctx context.Context, opts *ListOptions) (*MultiMessagesResponse, error) {
+	results, errs := fanOut(ctx, m.instances, func(ctx context.Context, c Client) (*MessagesResponse, error) {
+		return c.ListMessages(ctx, opts)
+	})
+
+	return m.mergeMessages(results, errs)
+}
+
+// SearchMessages searches messages on every instance. opts is applied to each
+// instance separately, so Limit bounds the messages per instance.
+func (m *MultiClient) SearchMessages(ctx context.Context, query string, opts *SearchOptions) (*MultiMessagesResponse, error) {
+	if query == "" {
+		return nil, NewValidationError("search query cannot be empty")
+	}
+
+	results, errs := fanOut(ctx, m.instances, func(ctx context.Context, c Client) (*MessagesResponse, error) {
+		return c.SearchMessages(ctx, query, opts)
+	})
+
+	return m.mergeMessages(results, errs)
+}
+
+// GetStats retrieves the statistics of every instance and sums their totals.
+func (m *MultiClient) GetStats(ctx context.Context) (*MultiStatsResponse, error) {
+	results, errs := fanOut(ctx, m.instances, func(ctx context.Context, c Client) (*Stats, error) {
+		return c.GetStats(ctx)
+	})
+
+	resp := &MultiStatsResponse{Stats: results, Errors: errs}
+	for _, stats := range results {
+		resp.Total += stats.Total
+		resp.Unread += stats.Unread
+	}
+
+	return resp, m.allFailed(errs)
+}
+
+// DeleteSearchResults deletes the messages matching query on every instance.
+func (m *MultiClient) DeleteSearchResults(ctx context.Context, query string) (*MultiResult, error) {
+	if query == "" {
+		return nil, NewValidationError("search query cannot be empty")
+	}
+
+	results, errs := fanOut(ctx, m.instances, func(ctx context.Context, c Client) (struct{}, error) {
+		return struct{}{}, c.DeleteSearchResults(ctx, query)
+	})
+
+	resp := &MultiResult{Errors: errs}
+	for _, inst := range m.instances {
+		if _, ok := results[inst.Name]; ok {
+			resp.Succeeded = append(resp.Succeeded, inst.Name)
+		}
+	}
+
+	return resp, m.allFailed(errs)
+}
+
+func (m *MultiClient) mergeMessages(results map[string]*MessagesResponse, errs map[string]error) (*MultiMessagesResponse, error) {
+	resp := &MultiMessagesResponse{Errors: errs}
+
+	for name, result := range results {
+		resp.Total += result.Total
+		resp.Unread += result.Unread
+
+		for _, msg := range result.Messages {
+			resp.Messages = append(resp.Messages, LabeledMessage{MessageSummary: msg, Instance: name})
+		}
+	}
+
+	sort.SliceStable(resp.Messages, func(i, j int) bool {
+		a, b := resp.Messages[i], resp.Messages[j]
+		if !a.Created.Equal(b.Created) {
+			return a.Created.After(b.Created)
+		}
+		if a.Instance != b.Instance {
+			return a.Instance < b.Instance
+		}
+
+		return a.ID < b.ID
+	})
+
+	return resp, m.allFailed(errs)
+}
+
+// allFailed returns the joined instance errors if no instance succeeded.
+func (m *MultiClient) allFailed(errs map[string]error) error {
+	if len(errs) < len(m.instances) {
+		return nil
+	}
+
+	joined := make([]error, 0, len(errs))
+	for _, inst := range m.instances {
+		joined = append(joined, errs[inst.Name])
+	}
+
+	return errors.Join(joined...)
+}
+
+// fanOut calls fn concurrently for every instance and collects the results and
+// errors by instance name. Errors are wrapped in *InstanceError.
+func fanOut[T any](ctx context.Context, instances []Instance, fn func(context.Context, Client) (T, error)) (map[string]T, map[string]error) {
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		results = make(map[string]T, len(instances))
+		errs    = make(map[string]error)
+	)
+
+	for _, inst := range instances {
+		wg.Go(func() {
+			res, err := fn(ctx, inst.Client)
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			if err != nil {
+				errs[inst.Name] = &InstanceError{Instance: inst.Name, Err: err}
+
+				return
+			}
+			results[inst.Name] = res
+		})
+	}
+
+	wg.Wait()
+
+	return results, errs
+}
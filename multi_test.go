@@ -0,0 +1,206 @@
+package mailpitclient
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// newMultiTestInstance starts a fake Mailpit instance that serves the given
+// messages and stats, or fails every request with status if it is not zero.
+func newMultiTestInstance(t *testing.T, name string, status int, messages []MessageSummary, deletes *atomic.Int32) Instance {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if status != 0 {
+			w.WriteHeader(status)
+			_, _ = w.Write([]byte("instance unavailable"))
+
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+
+		switch {
+		case r.URL.Path == "/api/v1/stats":
+			_ = json.NewEncoder(w).Encode(Stats{Total: len(messages), Unread: 1})
+		case r.URL.Path == "/api/v1/search" && r.Method == http.MethodDelete:
+			deletes.Add(1)
+		case r.URL.Path == "/api/v1/messages" || r.URL.Path == "/api/v1/search":
+			_ = json.NewEncoder(w).Encode(MessagesResponse{Messages: messages, Total: len(messages), Unread: 1})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	c, err := NewClient(&Config{
+		BaseURL:    server.URL,
+		APIPath:    "/api/v1",
+		MaxRetries: 0,
+		HTTPClient: &http.Client{Timeout: 5 * time.Second},
+	})
+	require.NoError(t, err)
+
+	return Instance{Name: name, Client: c}
+}
+
+func newMultiTestClient(t *testing.T, deletes *atomic.Int32) *MultiClient {
+	t.Helper()
+
+	base := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	m, err := NewMultiClient(
+		newMultiTestInstance(t, "billing", 0, []MessageSummary{
+			{ID: "b1", Created: base.Add(1 * time.Minute)},
+			{ID: "b2", Created: base.Add(3 * time.Minute)},
+		}, deletes),
+		newMultiTestInstance(t, "auth", 0, []MessageSummary{
+			{ID: "a1", Created: base.Add(2 * time.Minute)},
+		}, deletes),
+		newMultiTestInstance(t, "broken", http.StatusBadGateway, nil, deletes),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = m.Close() })
+
+	return m
+}
+
+func TestNewMultiClient(t *testing.T) {
+	t.Parallel()
+
+	c, err := NewClient(&Config{BaseURL: "http://localhost:8025"})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name      string
+		instances []Instance
+		wantErr   bool
+	}{
+		{name: "valid", instances: []Instance{{Name: "a", Client: c}, {Name: "b", Client: c}}},
+		{name: "no instances", wantErr: true},
+		{name: "empty name", instances: []Instance{{Client: c}}, wantErr: true},
+		{name: "nil client", instances: []Instance{{Name: "a"}}, wantErr: true},
+		{name: "duplicate name", instances: []Instance{{Name: "a", Client: c}, {Name: "a", Client: c}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			m, err := NewMultiClient(tt.instances...)
+			if tt.wantErr {
+				require.Error(t, err)
+				require.Nil(t, m)
+
+				var mailpitErr *Error
+				require.ErrorAs(t, err, &mailpitErr)
+				require.Equal(t, ErrorTypeConfig, mailpitErr.Type)
+
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, []string{"a", "b"}, m.Instances())
+		})
+	}
+}
+
+func TestMultiClient_ListAndSearchMessages(t *testing.T) {
+	t.Parallel()
+
+	m := newMultiTestClient(t, &atomic.Int32{})
+
+	for name, call := range map[string]func(context.Context) (*MultiMessagesResponse, error){
+		"list": func(ctx context.Context) (*MultiMessagesResponse, error) {
+			return m.ListMessages(ctx, nil)
+		},
+		"search": func(ctx context.Context) (*MultiMessagesResponse, error) {
+			return m.SearchMessages(ctx, "subject:welcome", nil)
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			resp, err := call(t.Context())
+			require.NoError(t, err)
+
+			var got []string
+			for _, msg := range resp.Messages {
+				got = append(got, msg.Instance+"/"+msg.ID)
+			}
+			require.Equal(t, []string{"billing/b2", "auth/a1", "billing/b1"}, got)
+			require.Equal(t, 3, resp.Total)
+			require.Equal(t, 2, resp.Unread)
+
+			require.Len(t, resp.Errors, 1)
+			require.ErrorIs(t, resp.Errors["broken"], ErrServerUnavailable)
+
+			var instErr *InstanceError
+			require.ErrorAs(t, resp.Errors["broken"], &instErr)
+			require.Equal(t, "broken", instErr.Instance)
+		})
+	}
+}
+
+func TestMultiClient_SearchMessages_EmptyQuery(t *testing.T) {
+	t.Parallel()
+
+	m := newMultiTestClient(t, &atomic.Int32{})
+
+	_, err := m.SearchMessages(t.Context(), "", nil)
+	require.Error(t, err)
+
+	_, err = m.DeleteSearchResults(t.Context(), "")
+	require.Error(t, err)
+}
+
+func TestMultiClient_GetStats(t *testing.T) {
+	t.Parallel()
+
+	m := newMultiTestClient(t, &atomic.Int32{})
+
+	resp, err := m.GetStats(t.Context())
+	require.NoError(t, err)
+	require.Equal(t, 3, resp.Total)
+	require.Equal(t, 2, resp.Unread)
+	require.Len(t, resp.Stats, 2)
+	require.Equal(t, 2, resp.Stats["billing"].Total)
+	require.Contains(t, resp.Errors, "broken")
+}
+
+func TestMultiClient_DeleteSearchResults(t *testing.T) {
+	t.Parallel()
+
+	var deletes atomic.Int32
+	m := newMultiTestClient(t, &deletes)
+
+	resp, err := m.DeleteSearchResults(t.Context(), "is:read")
+	require.NoError(t, err)
+	require.Equal(t, []string{"billing", "auth"}, resp.Succeeded)
+	require.Contains(t, resp.Errors, "broken")
+	require.Equal(t, int32(2), deletes.Load())
+}
+
+func TestMultiClient_AllInstancesFail(t *testing.T) {
+	t.Parallel()
+
+	m, err := NewMultiClient(
+		newMultiTestInstance(t, "one", http.StatusServiceUnavailable, nil, nil),
+		newMultiTestInstance(t, "two", http.StatusNotFound, nil, nil),
+	)
+	require.NoError(t, err)
+
+	resp, err := m.ListMessages(t.Context(), nil)
+	require.Error(t, err)
+	require.ErrorIs(t, err, ErrServerUnavailable)
+	require.ErrorIs(t, err, ErrNotFound)
+	require.Len(t, resp.Errors, 2)
+	require.Empty(t, resp.Messages)
+}
@@ -0,0 +1,88 @@
+package mailpitclient
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// readStateScanLimit is how many of the newest messages are scanned for a
+// message's read state when it cannot be found by its Message-ID header.
+const readStateScanLimit = 250
+
+// readStatusRequest is the body of Mailpit's "set read status" endpoint.
+type readStatusRequest struct {
+	IDs  []string `json:"IDs"`
+	Read bool     `json:"Read"`
+}
+
+type preserveReadStateKey struct{}
+
+// PreserveReadState returns a context that keeps GetMessage, and helpers
+// built on it such as MessageSnapshot and the Extract functions, from marking
+// unread messages as read. Use Config.PreserveReadState to enable this for every call.
+//
+// Mailpit has no read-only route for message details, so the client looks up
+// the message's read state before fetching it and marks it unread again
+// afterwards. The message is briefly reported as read in between. Messages
+// without a Message-ID header are only found among the newest 250 messages;
+// for older ones GetMessage fails instead of fetching the message.
+func PreserveReadState(ctx context.Context) context.Context {
+	return context.WithValue(ctx, preserveReadStateKey{}, true)
+}
+
+func readStatePreserved(ctx context.Context) bool {
+	preserve, _ := ctx.Value(preserveReadStateKey{}).(bool)
+
+	return preserve
+}
+
+// readState reports whether a message is read without fetching it, which
+// would mark it read. It searches by the Message-ID header, read from the
+// non-mutating headers endpoint, and falls back to the newest messages.
+func (c *client) readState(ctx context.Context, id string) (bool, error) {
+	headers, err := c.GetMessageHeaders(ctx, id)
+	if err != nil {
+		return false, err
+	}
+
+	if mid := strings.Trim(strings.Join(headerValues(headers, "Message-ID"), ""), "<> "); mid != "" {
+		result, err := c.SearchMessages(ctx, fmt.Sprintf("message-id:%q", mid), nil)
+		if err != nil {
+			return false, err
+		}
+
+		for _, m := range result.Messages {
+			if m.ID == id {
+				return m.Read, nil
+			}
+		}
+	}
+
+	// Scanning the whole mailbox would cost a request per page on every
+	// GetMessage, so only the newest messages are checked.
+	page, err := c.ListMessages(ctx, &ListOptions{Limit: readStateScanLimit})
+	if err != nil {
+		return false, err
+	}
+
+	for _, m := range page.Messages {
+		if m.ID == id {
+			return m.Read, nil
+		}
+	}
+
+	if page.Total <= len(page.Messages) {
+		return false, &Error{
+			Type:    ErrorTypeValidation,
+			Message: fmt.Sprintf("message %s not found while looking up its read state", id),
+			Cause:   ErrNotFound,
+		}
+	}
+
+	return false, &Error{
+		Type: ErrorTypeValidation,
+		Message: fmt.Sprintf("cannot look up the read state of message %s: it has no searchable Message-ID "+
+			"and is not among the newest %d messages", id, readStateScanLimit),
+	}
+}
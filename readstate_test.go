@@ -0,0 +1,171 @@
+package mailpitclient
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// readStateServer is a fake Mailpit that, like the real one, marks a message
+// read when its details are fetched.
+type readStateServer struct {
+	read      map[string]bool
+	messageID map[string]string
+	puts      int
+	// older is the number of messages beyond the listed ones.
+	older int
+	mu    sync.Mutex
+}
+
+func (s *readStateServer) isRead(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.read[id]
+}
+
+func (s *readStateServer) summaries(match func(id string) bool) MessagesResponse {
+	var resp MessagesResponse
+	for _, id := range []string{"unread-1", "read-1", "no-mid"} {
+		if match(id) {
+			resp.Messages = append(resp.Messages, MessageSummary{ID: id, Read: s.read[id]})
+		}
+	}
+	resp.Total = len(resp.Messages) + s.older
+
+	return resp
+}
+
+func newReadStateTestClient(t *testing.T, state *readStateServer, preserve bool) Client {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		state.mu.Lock()
+		defer state.mu.Unlock()
+
+		path := strings.TrimPrefix(r.URL.Path, "/api/v1")
+		id := strings.TrimSuffix(strings.TrimPrefix(path, "/message/"), "/headers")
+
+		switch {
+		case r.Method == http.MethodPut && path == "/messages":
+			var body readStatusRequest
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			for _, id := range body.IDs {
+				state.read[id] = body.Read
+			}
+			state.puts++
+		case path == "/messages":
+			_ = json.NewEncoder(w).Encode(state.summaries(func(string) bool { return true }))
+		case path == "/search":
+			query := r.URL.Query().Get("query")
+			_ = json.NewEncoder(w).Encode(state.summaries(func(id string) bool {
+				return state.messageID[id] != "" && query == `message-id:"`+state.messageID[id]+`"`
+			}))
+		case strings.HasSuffix(path, "/headers"):
+			if _, ok := state.read[id]; !ok {
+				w.WriteHeader(http.StatusNotFound)
+
+				return
+			}
+			headers := map[string][]string{"Subject": {"Hi"}}
+			if mid := state.messageID[id]; mid != "" {
+				headers["Message-Id"] = []string{"<" + mid + ">"}
+			}
+			_ = json.NewEncoder(w).Encode(headers)
+		case strings.HasPrefix(path, "/message/"):
+			if _, ok := state.read[id]; !ok {
+				w.WriteHeader(http.StatusNotFound)
+
+				return
+			}
+			state.read[id] = true
+			_ = json.NewEncoder(w).Encode(Message{ID: id, Read: true})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	c, err := NewClient(&Config{
+		BaseURL:           server.URL,
+		APIPath:           "/api/v1",
+		MaxRetries:        0,
+		HTTPClient:        &http.Client{Timeout: 5 * time.Second},
+		PreserveReadState: preserve,
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = c.Close() })
+
+	return c
+}
+
+func TestClient_GetMessage_PreserveReadState(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		id         string
+		perCall    bool
+		onClient   bool
+		wantRead   bool
+		wantUpdate bool
+	}{
+		{name: "default marks read", id: "unread-1", wantRead: true},
+		{name: "per call keeps unread", id: "unread-1", perCall: true, wantUpdate: true},
+		{name: "client option keeps unread", id: "unread-1", onClient: true, wantUpdate: true},
+		{name: "read message stays read", id: "read-1", onClient: true, wantRead: true},
+		{name: "fallback without Message-ID", id: "no-mid", onClient: true, wantUpdate: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			state := &readStateServer{
+				read:      map[string]bool{"unread-1": false, "read-1": true, "no-mid": false},
+				messageID: map[string]string{"unread-1": "u1@example.com", "read-1": "r1@example.com"},
+			}
+			c := newReadStateTestClient(t, state, tt.onClient)
+
+			ctx := t.Context()
+			if tt.perCall {
+				ctx = PreserveReadState(ctx)
+			}
+
+			msg, err := c.GetMessage(ctx, tt.id)
+			require.NoError(t, err)
+			require.Equal(t, tt.wantRead, msg.Read)
+			require.Equal(t, tt.wantRead, state.isRead(tt.id))
+			require.Equal(t, tt.wantUpdate, state.puts > 0)
+		})
+	}
+}
+
+func TestClient_GetMessage_PreserveReadStateNotFound(t *testing.T) {
+	t.Parallel()
+
+	state := &readStateServer{read: map[string]bool{}, messageID: map[string]string{}}
+	c := newReadStateTestClient(t, state, true)
+
+	_, err := c.GetMessage(t.Context(), "missing")
+	require.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestClient_GetMessage_PreserveReadStateScanLimit(t *testing.T) {
+	t.Parallel()
+
+	// "old" has no Message-ID and is not among the newest messages.
+	state := &readStateServer{read: map[string]bool{"old": false}, messageID: map[string]string{}, older: 1000}
+	c := newReadStateTestClient(t, state, true)
+
+	_, err := c.GetMessage(t.Context(), "old")
+	require.ErrorContains(t, err, "not among the newest")
+	require.NotErrorIs(t, err, ErrNotFound)
+	require.False(t, state.isRead("old"), "the message is not fetched")
+}
@@ -0,0 +1,226 @@
+package mailpitclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// releaseSearchPageSize is the number of search results fetched per request
+// when resolving a release query.
+const releaseSearchPageSize = 250
+
+// ReleaseRequest describes a set of messages to release and the recipients to
+// release them to. Messages are selected by ID, by search query, or both.
+type ReleaseRequest struct {
+	// Query releases every message matching the Mailpit search query.
+	Query string
+	// MessageIDs lists individual messages to release.
+	MessageIDs []string
+	// To lists the recipients each message is released to.
+	To []string
+}
+
+// ReleaseResult is the outcome of releasing one message to one recipient.
+type ReleaseResult struct {
+	Err       error
+	MessageID string
+	Recipient string
+}
+
+// ReleaseReport holds the per-recipient outcome of a release.
+type ReleaseReport struct {
+	Results []ReleaseResult
+}
+
+// Released returns the results that succeeded.
+func (r *ReleaseReport) Released() []ReleaseResult {
+	return r.filter(true)
+}
+
+// Failed returns the results that failed, including recipients rejected by
+// the relay allow and block lists.
+func (r *ReleaseReport) Failed() []ReleaseResult {
+	return r.filter(false)
+}
+
+func (r *ReleaseReport) filter(ok bool) []ReleaseResult {
+	var out []ReleaseResult
+	for _, res := range r.Results {
+		if (res.Err == nil) == ok {
+			out = append(out, res)
+		}
+	}
+
+	return out
+}
+
+// CheckRecipient verifies that the relay configuration allows a message to be
+// released to addr. Recipients must match AllowedRecipients (if set) and must
+// not match BlockedRecipients (if set); both are case-insensitive regular
+// expressions, as in Mailpit.
+func (mr *MessageRelay) CheckRecipient(addr string) error {
+	if !mr.Enabled {
+		return &Error{
+			Type:    ErrorTypeValidation,
+			Message: "message relay is not enabled",
+			Cause:   ErrRelayDisabled,
+		}
+	}
+
+	allowed := mr.AllowedRecipients
+	if allowed == "" {
+		allowed = mr.RecipientAllowlist
+	}
+
+	if allowed != "" {
+		re, err := compileRelayPattern("AllowedRecipients", allowed)
+		if err != nil {
+			return err
+		}
+
+		if !re.MatchString(addr) {
+			return &Error{
+				Type:    ErrorTypeValidation,
+				Message: fmt.Sprintf("recipient %s does not match allowed recipients %q", addr, allowed),
+				Cause:   ErrRecipientNotAllowed,
+			}
+		}
+	}
+
+	if mr.BlockedRecipients != "" {
+		re, err := compileRelayPattern("BlockedRecipients", mr.BlockedRecipients)
+		if err != nil {
+			return err
+		}
+
+		if re.MatchString(addr) {
+			return &Error{
+				Type:    ErrorTypeValidation,
+				Message: fmt.Sprintf("recipient %s matches blocked recipients %q", addr, mr.BlockedRecipients),
+				Cause:   ErrRecipientNotAllowed,
+			}
+		}
+	}
+
+	return nil
+}
+
+func compileRelayPattern(name, pattern string) (*regexp.Regexp, error) {
+	re, err := regexp.Compile("(?i)" + pattern)
+	if err != nil {
+		return nil, &Error{
+			Type:    ErrorTypeConfig,
+			Message: fmt.Sprintf("invalid %s pattern %q: %v", name, pattern, err),
+			Cause:   err,
+		}
+	}
+
+	return re, nil
+}
+
+// Release releases messages to real recipients through Mailpit's SMTP relay.
+//
+// The relay configuration is read first: if relay is disabled an error
+// matching ErrRelayDisabled is returned and nothing is released. Each message
+// is then released to each recipient separately so that the report contains
+// one result per message and recipient. Recipients rejected by the relay
+// allow or block lists are reported with an error matching
+// ErrRecipientNotAllowed without contacting the server.
+func Release(ctx context.Context, c Client, req *ReleaseRequest) (*ReleaseReport, error) {
+	if c == nil {
+		return nil, NewValidationError("client cannot be nil")
+	}
+	if req == nil {
+		return nil, NewValidationError("release request cannot be nil")
+	}
+	if len(req.To) == 0 {
+		return nil, NewValidationError("at least one recipient is required")
+	}
+	if len(req.MessageIDs) == 0 && req.Query == "" {
+		return nil, NewValidationError("message IDs or a search query are required")
+	}
+
+	webUI, err := c.GetWebUIConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	relay := webUI.MessageRelay
+	if !relay.Enabled {
+		return nil, relay.CheckRecipient("")
+	}
+
+	// Validate all recipients up front so an invalid pattern fails the whole
+	// release rather than every result.
+	recipientErrs := make(map[string]error, len(req.To))
+	for _, rcpt := range req.To {
+		rcptErr := relay.CheckRecipient(strings.TrimSpace(rcpt))
+
+		var mailpitErr *Error
+		if errors.As(rcptErr, &mailpitErr) && mailpitErr.IsType(ErrorTypeConfig) {
+			return nil, rcptErr
+		}
+
+		recipientErrs[rcpt] = rcptErr
+	}
+
+	ids, err := resolveReleaseIDs(ctx, c, req)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &ReleaseReport{Results: make([]ReleaseResult, 0, len(ids)*len(req.To))}
+	for _, id := range ids {
+		for _, rcpt := range req.To {
+			res := ReleaseResult{MessageID: id, Recipient: rcpt, Err: recipientErrs[rcpt]}
+			if res.Err == nil {
+				res.Err = c.ReleaseMessage(ctx, id, &ReleaseMessageRequest{To: []string{strings.TrimSpace(rcpt)}})
+			}
+
+			report.Results = append(report.Results, res)
+		}
+	}
+
+	return report, nil
+}
+
+// resolveReleaseIDs returns the explicitly requested IDs followed by every
+// message matching the query, without duplicates.
+func resolveReleaseIDs(ctx context.Context, c Client, req *ReleaseRequest) ([]string, error) {
+	seen := make(map[string]struct{}, len(req.MessageIDs))
+	ids := make([]string, 0, len(req.MessageIDs))
+
+	add := func(id string) {
+		if _, ok := seen[id]; ok || id == "" {
+			return
+		}
+		seen[id] = struct{}{}
+		ids = append(ids, id)
+	}
+
+	for _, id := range req.MessageIDs {
+		add(id)
+	}
+
+	if req.Query == "" {
+		return ids, nil
+	}
+
+	for start := 0; ; start += releaseSearchPageSize {
+		resp, err := c.SearchMessages(ctx, req.Query, &SearchOptions{Start: start, Limit: releaseSearchPageSize})
+		if err != nil {
+			return nil, err
+		}
+
+		for i := range resp.Messages {
+			add(resp.Messages[i].ID)
+		}
+
+		if len(resp.Messages) < releaseSearchPageSize {
+			return ids, nil
+		}
+	}
+}
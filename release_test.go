@@ -0,0 +1,256 @@
+package mailpitclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// releaseServer is a fake Mailpit relay that records every release request.
+type releaseServer struct {
+	relay    MessageRelay
+	search   []string
+	released map[string][]string
+	mu       sync.Mutex
+}
+
+func newReleaseTestClient(t *testing.T, state *releaseServer) Client {
+	t.Helper()
+
+	state.released = make(map[string][]string)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		state.mu.Lock()
+		defer state.mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+
+		switch {
+		case r.URL.Path == "/api/v1/webui":
+			_ = json.NewEncoder(w).Encode(WebUIConfig{MessageRelay: state.relay})
+		case r.URL.Path == "/api/v1/search":
+			resp := MessagesResponse{}
+			for _, id := range state.search {
+				resp.Messages = append(resp.Messages, MessageSummary{ID: id})
+			}
+			_ = json.NewEncoder(w).Encode(resp)
+		case strings.HasSuffix(r.URL.Path, "/release"):
+			id := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/api/v1/message/"), "/release")
+			if id == "missing" {
+				w.WriteHeader(http.StatusNotFound)
+				_, _ = w.Write([]byte("message not found"))
+
+				return
+			}
+
+			var req ReleaseMessageRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			state.released[id] = append(state.released[id], req.To...)
+			_, _ = w.Write([]byte("ok"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	c, err := NewClient(&Config{
+		BaseURL:    server.URL,
+		APIPath:    "/api/v1",
+		MaxRetries: 0,
+		HTTPClient: &http.Client{Timeout: 5 * time.Second},
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = c.Close() })
+
+	return c
+}
+
+func TestMessageRelay_CheckRecipient(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		relay    MessageRelay
+		addr     string
+		expected error
+	}{
+		{
+			name:     "relay disabled",
+			relay:    MessageRelay{},
+			addr:     "user@example.com",
+			expected: ErrRelayDisabled,
+		},
+		{
+			name:  "no restrictions",
+			relay: MessageRelay{Enabled: true},
+			addr:  "user@example.com",
+		},
+		{
+			name:  "allowed recipient matches case-insensitively",
+			relay: MessageRelay{Enabled: true, AllowedRecipients: `@example\.com$`},
+			addr:  "User@EXAMPLE.com",
+		},
+		{
+			name:     "recipient outside allow list",
+			relay:    MessageRelay{Enabled: true, AllowedRecipients: `@example\.com$`},
+			addr:     "user@other.org",
+			expected: ErrRecipientNotAllowed,
+		},
+		{
+			name:     "legacy allow list is used as fallback",
+			relay:    MessageRelay{Enabled: true, RecipientAllowlist: `@example\.com$`},
+			addr:     "user@other.org",
+			expected: ErrRecipientNotAllowed,
+		},
+		{
+			name:     "blocked recipient",
+			relay:    MessageRelay{Enabled: true, BlockedRecipients: `^ceo@`},
+			addr:     "ceo@example.com",
+			expected: ErrRecipientNotAllowed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := tt.relay.CheckRecipient(tt.addr)
+			if tt.expected == nil {
+				require.NoError(t, err)
+
+				return
+			}
+
+			require.ErrorIs(t, err, tt.expected)
+		})
+	}
+}
+
+func TestMessageRelay_CheckRecipient_InvalidPattern(t *testing.T) {
+	t.Parallel()
+
+	relay := MessageRelay{Enabled: true, BlockedRecipients: "("}
+
+	err := relay.CheckRecipient("user@example.com")
+	require.Error(t, err)
+
+	var mailpitErr *Error
+	require.ErrorAs(t, err, &mailpitErr)
+	require.Equal(t, ErrorTypeConfig, mailpitErr.Type)
+}
+
+func TestRelease(t *testing.T) {
+	t.Parallel()
+
+	state := &releaseServer{
+		relay:  MessageRelay{Enabled: true, BlockedRecipients: `@blocked\.com$`},
+		search: []string{"msg-2", "msg-3"},
+	}
+	c := newReleaseTestClient(t, state)
+
+	report, err := Release(context.Background(), c, &ReleaseRequest{
+		MessageIDs: []string{"msg-1", "msg-2", "missing"},
+		Query:      "subject:review",
+		To:         []string{"alice@example.com", "bob@blocked.com"},
+	})
+	require.NoError(t, err)
+
+	// msg-2 is requested both by ID and by query but released only once.
+	require.Len(t, report.Results, 8)
+	require.Len(t, report.Released(), 3)
+	require.Len(t, report.Failed(), 5)
+
+	for _, res := range report.Failed() {
+		switch res.Recipient {
+		case "bob@blocked.com":
+			require.ErrorIs(t, res.Err, ErrRecipientNotAllowed)
+		default:
+			require.Equal(t, "missing", res.MessageID)
+			require.ErrorIs(t, res.Err, ErrNotFound)
+		}
+	}
+
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	require.Equal(t, map[string][]string{
+		"msg-1": {"alice@example.com"},
+		"msg-2": {"alice@example.com"},
+		"msg-3": {"alice@example.com"},
+	}, state.released)
+}
+
+func TestRelease_Errors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		relay     MessageRelay
+		req       *ReleaseRequest
+		errorType ErrorType
+		sentinel  error
+	}{
+		{
+			name:      "nil request",
+			relay:     MessageRelay{Enabled: true},
+			errorType: ErrorTypeValidation,
+		},
+		{
+			name:      "no recipients",
+			relay:     MessageRelay{Enabled: true},
+			req:       &ReleaseRequest{MessageIDs: []string{"msg-1"}},
+			errorType: ErrorTypeValidation,
+		},
+		{
+			name:      "no messages",
+			relay:     MessageRelay{Enabled: true},
+			req:       &ReleaseRequest{To: []string{"alice@example.com"}},
+			errorType: ErrorTypeValidation,
+		},
+		{
+			name:      "relay disabled",
+			relay:     MessageRelay{},
+			req:       &ReleaseRequest{MessageIDs: []string{"msg-1"}, To: []string{"alice@example.com"}},
+			errorType: ErrorTypeValidation,
+			sentinel:  ErrRelayDisabled,
+		},
+		{
+			name:      "invalid allow list",
+			relay:     MessageRelay{Enabled: true, AllowedRecipients: "["},
+			req:       &ReleaseRequest{MessageIDs: []string{"msg-1"}, To: []string{"alice@example.com"}},
+			errorType: ErrorTypeConfig,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			state := &releaseServer{relay: tt.relay}
+			c := newReleaseTestClient(t, state)
+
+			report, err := Release(context.Background(), c, tt.req)
+			require.Error(t, err)
+			require.Nil(t, report)
+
+			var mailpitErr *Error
+			require.True(t, errors.As(err, &mailpitErr))
+			require.Equal(t, tt.errorType, mailpitErr.Type)
+			if tt.sentinel != nil {
+				require.ErrorIs(t, err, tt.sentinel)
+			}
+
+			state.mu.Lock()
+			defer state.mu.Unlock()
+			require.Empty(t, state.released)
+		})
+	}
+}
@@ -0,0 +1,406 @@
+package mailpitclient
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ReportFinding describes a single problem found by a check.
+type ReportFinding struct {
+	Message string `json:"message"`
+	// Snippet is an optional extract of the message source related to the finding.
+	Snippet string `json:"snippet,omitempty"`
+}
+
+// ReportCase is the outcome of one check or assertion against one message.
+type ReportCase struct {
+	Name      string          `json:"name"`
+	MessageID string          `json:"message_id"`
+	Subject   string          `json:"subject"`
+	Error     string          `json:"error,omitempty"`
+	Findings  []ReportFinding `json:"findings,omitempty"`
+}
+
+// Passed reports whether the case ran successfully and produced no findings.
+func (rc *ReportCase) Passed() bool {
+	return rc.Error == "" && len(rc.Findings) == 0
+}
+
+// Reporter collects the results of email checks and assertions and renders
+// them as JUnit XML or Markdown for CI systems. Results are grouped by
+// message subject. A Reporter is safe for concurrent use.
+type Reporter struct {
+	cases []ReportCase
+	mu    sync.Mutex
+}
+
+// NewReporter creates an empty Reporter.
+func NewReporter() *Reporter {
+	return &Reporter{}
+}
+
+// Add records a case.
+func (r *Reporter) Add(rc ReportCase) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.cases = append(r.cases, rc)
+}
+
+// Cases returns a copy of all recorded cases.
+func (r *Reporter) Cases() []ReportCase {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	out := make([]ReportCase, len(r.cases))
+	copy(out, r.cases)
+
+	return out
+}
+
+// Passed reports whether every recorded case passed.
+func (r *Reporter) Passed() bool {
+	for _, rc := range r.Cases() {
+		if !rc.Passed() {
+			return false
+		}
+	}
+
+	return true
+}
+
+// AddAssertion records the outcome of a custom assertion about a message.
+// A nil err records a passing case.
+func (r *Reporter) AddAssertion(messageID, subject, name string, err error) {
+	rc := ReportCase{Name: name, MessageID: messageID, Subject: subject}
+	if err != nil {
+		rc.Findings = []ReportFinding{{Message: err.Error()}}
+	}
+
+	r.Add(rc)
+}
+
+// AddHTMLCheck records the features that are unsupported by at least one
+// email client, with the support of each affected client as the snippet.
+func (r *Reporter) AddHTMLCheck(messageID, subject string, check *HTMLCheckResponse) {
+	r.Add(ReportCase{
+		Name:      string(LintCheckHTML),
+		MessageID: messageID,
+		Subject:   subject,
+		Findings:  htmlFindings(check),
+	})
+}
+
+// AddLinkCheck records every broken link found in a message.
+func (r *Reporter) AddLinkCheck(messageID, subject string, check *LinkCheckResponse) {
+	var findings []ReportFinding
+	for _, link := range check.FailingLinks() {
+		findings = append(findings, ReportFinding{
+			Message: fmt.Sprintf("broken link %s: %d %s", link.URL, link.StatusCode, link.Status),
+		})
+	}
+
+	r.Add(ReportCase{
+		Name:      string(LintCheckLinks),
+		MessageID: messageID,
+		Subject:   subject,
+		Findings:  findings,
+	})
+}
+
+// AddSpamAssassinCheck records a finding if the message is spam according to
+// SpamAssassinCheckResponse.IsSpam with the given threshold. The triggered
+// rules are included as the snippet.
+func (r *Reporter) AddSpamAssassinCheck(messageID, subject string, check *SpamAssassinCheckResponse, threshold float64) {
+	rc := ReportCase{Name: string(LintCheckSpam), MessageID: messageID, Subject: subject}
+
+	switch {
+	case check == nil:
+	case check.Error != "":
+		rc.Error = check.Error
+	case check.IsSpam(threshold):
+		rc.Findings = []ReportFinding{{
+			Message: fmt.Sprintf("spam score %.2f (threshold %.2f)", check.Score, threshold),
+			Snippet: spamRulesSnippet(check),
+		}}
+	}
+
+	r.Add(rc)
+}
+
+// AddLintReport records one case per check in a LintReport. Failing HTML and
+// SpamAssassin checks are enriched with unsupported features and triggered
+// rules.
+func (r *Reporter) AddLintReport(report *LintReport) {
+	for _, res := range report.Results {
+		rc := ReportCase{
+			Name:      string(res.Check),
+			MessageID: report.MessageID,
+			Subject:   report.Subject,
+			Error:     res.Error,
+		}
+
+		for _, f := range res.Failures {
+			rc.Findings = append(rc.Findings, ReportFinding{Message: f})
+		}
+
+		if len(rc.Findings) > 0 {
+			switch res.Check {
+			case LintCheckHTML:
+				rc.Findings = append(rc.Findings, htmlFindings(report.HTMLCheck)...)
+			case LintCheckSpam:
+				if report.SpamAssassinCheck != nil {
+					rc.Findings[0].Snippet = spamRulesSnippet(report.SpamAssassinCheck)
+				}
+			case LintCheckHeaders, LintCheckLinks:
+			}
+		}
+
+		r.Add(rc)
+	}
+}
+
+// WriteJUnit writes all cases as JUnit XML, with one test suite per subject.
+func (r *Reporter) WriteJUnit(w io.Writer) error {
+	var suites junitSuites
+
+	for _, group := range r.groups() {
+		suite := junitSuite{Name: group.subject, Tests: len(group.cases)}
+
+		for _, rc := range group.cases {
+			tc := junitCase{Name: rc.Name, ClassName: rc.MessageID}
+
+			switch {
+			case rc.Error != "":
+				suite.Errors++
+				tc.Error = &junitFailure{Message: rc.Error, Text: rc.Error}
+			case len(rc.Findings) > 0:
+				suite.Failures++
+				tc.Failure = &junitFailure{
+					Message: rc.Findings[0].Message,
+					Text:    findingsText(rc.Findings),
+				}
+			}
+
+			suite.Cases = append(suite.Cases, tc)
+		}
+
+		suites.Tests += suite.Tests
+		suites.Failures += suite.Failures
+		suites.Errors += suite.Errors
+		suites.Suites = append(suites.Suites, suite)
+	}
+
+	out, err := xml.MarshalIndent(suites, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if _, err = io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	_, err = w.Write(append(out, '\n'))
+
+	return err
+}
+
+// WriteMarkdown writes a Markdown summary of all cases, suitable for CI job
+// summaries such as $GITHUB_STEP_SUMMARY.
+func (r *Reporter) WriteMarkdown(w io.Writer) error {
+	var (
+		b              strings.Builder
+		passed, failed int
+	)
+
+	groups := r.groups()
+	for _, group := range groups {
+		for _, rc := range group.cases {
+			if rc.Passed() {
+				passed++
+			} else {
+				failed++
+			}
+		}
+	}
+
+	fmt.Fprintf(&b, "## Email checks: %d passed, %d failed\n", passed, failed)
+
+	for _, group := range groups {
+		subject := group.subject
+		if subject == "" {
+			subject = "(no subject)"
+		}
+
+		fmt.Fprintf(&b, "\n### %s\n\n", subject)
+
+		for _, rc := range group.cases {
+			switch {
+			case rc.Error != "":
+				fmt.Fprintf(&b, "- :warning: `%s` (%s): %s\n", rc.Name, rc.MessageID, rc.Error)
+			case len(rc.Findings) > 0:
+				fmt.Fprintf(&b, "- :x: `%s` (%s)\n", rc.Name, rc.MessageID)
+				for _, f := range rc.Findings {
+					fmt.Fprintf(&b, "  - %s\n", strings.ReplaceAll(f.Message, "\n", "\n    "))
+					if f.Snippet != "" {
+						fence := markdownFence(f.Snippet)
+						fmt.Fprintf(&b, "\n    %s\n    %s\n    %s\n\n", fence, strings.ReplaceAll(f.Snippet, "\n", "\n    "), fence)
+					}
+				}
+			default:
+				fmt.Fprintf(&b, "- :white_check_mark: `%s` (%s)\n", rc.Name, rc.MessageID)
+			}
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+
+	return err
+}
+
+type reportGroup struct {
+	subject string
+	cases   []ReportCase
+}
+
+// groups returns the cases grouped by subject, in order of first appearance.
+func (r *Reporter) groups() []reportGroup {
+	var (
+		groups []reportGroup
+		index  = make(map[string]int)
+	)
+
+	for _, rc := range r.Cases() {
+		i, ok := index[rc.Subject]
+		if !ok {
+			i = len(groups)
+			index[rc.Subject] = i
+			groups = append(groups, reportGroup{subject: rc.Subject})
+		}
+		groups[i].cases = append(groups[i].cases, rc)
+	}
+
+	return groups
+}
+
+func htmlFindings(check *HTMLCheckResponse) []ReportFinding {
+	if check == nil {
+		return nil
+	}
+
+	var findings []ReportFinding
+	for _, w := range check.Warnings {
+		var clients []string
+		for _, res := range w.Results {
+			if res.Support == HTMLCheckSupportNo {
+				clients = append(clients, fmt.Sprintf("%s (%s)", res.Name, res.Platform))
+			}
+		}
+
+		if len(clients) == 0 {
+			continue
+		}
+
+		sort.Strings(clients)
+		findings = append(findings, ReportFinding{
+			Message: fmt.Sprintf("%s is unsupported in %s", w.Title, strings.Join(clients, ", ")),
+			Snippet: htmlWarningSnippet(w),
+		})
+	}
+
+	return findings
+}
+
+// htmlWarningSnippet lists the clients that do not fully support a feature,
+// with Mailpit's notes and a link to the feature's documentation.
+func htmlWarningSnippet(w HTMLCheckWarning) string {
+	lines := make([]string, 0, len(w.Results)+1)
+	for _, res := range w.Results {
+		if res.Support == HTMLCheckSupportYes {
+			continue
+		}
+
+		line := fmt.Sprintf("%-7s %s (%s)", res.Support, res.Name, res.Platform)
+		if res.Version != "" {
+			line += " " + res.Version
+		}
+		if note := w.NotesByNumber[res.NoteNumber]; note != "" {
+			line += ": " + note
+		}
+		lines = append(lines, line)
+	}
+
+	if w.URL != "" {
+		lines = append(lines, "See "+w.URL)
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func spamRulesSnippet(check *SpamAssassinCheckResponse) string {
+	lines := make([]string, 0, len(check.Rules))
+	for _, rule := range check.TriggeredRules() {
+		lines = append(lines, fmt.Sprintf("%6.2f %s %s", rule.Score, rule.Name, rule.Description))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func findingsText(findings []ReportFinding) string {
+	var b strings.Builder
+	for i, f := range findings {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString(f.Message)
+		if f.Snippet != "" {
+			b.WriteString("\n\n")
+			b.WriteString(f.Snippet)
+			b.WriteString("\n")
+		}
+	}
+
+	return b.String()
+}
+
+func markdownFence(snippet string) string {
+	fence := "```"
+	for strings.Contains(snippet, fence) {
+		fence += "`"
+	}
+
+	return fence
+}
+
+type junitSuites struct {
+	XMLName  xml.Name     `xml:"testsuites"`
+	Suites   []junitSuite `xml:"testsuite"`
+	Tests    int          `xml:"tests,attr"`
+	Failures int          `xml:"failures,attr"`
+	Errors   int          `xml:"errors,attr"`
+}
+
+type junitSuite struct {
+	XMLName  xml.Name    `xml:"testsuite"`
+	Name     string      `xml:"name,attr"`
+	Cases    []junitCase `xml:"testcase"`
+	Tests    int         `xml:"tests,attr"`
+	Failures int         `xml:"failures,attr"`
+	Errors   int         `xml:"errors,attr"`
+}
+
+type junitCase struct {
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	Error     *junitFailure `xml:"error,omitempty"`
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
@@ -0,0 +1,119 @@
+package mailpitclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestReporter(t *testing.T) *Reporter {
+	t.Helper()
+
+	var htmlCheck HTMLCheckResponse
+	require.NoError(t, json.Unmarshal([]byte(htmlCheckFixture), &htmlCheck))
+	htmlCheck.Warnings[0].URL = "https://www.caniemail.com/features/css-display-flex/"
+	htmlCheck.Warnings[0].NotesByNumber = map[string]string{"1": "Ignored in Outlook.com."}
+	htmlCheck.Warnings[0].Results[1].NoteNumber = "1"
+
+	r := NewReporter()
+	r.AddHTMLCheck("msg-1", "Welcome", &htmlCheck)
+	r.AddLinkCheck("msg-1", "Welcome", &LinkCheckResponse{Links: []LinkCheck{
+		{URL: "https://example.com", StatusCode: 200, Status: "OK"},
+	}})
+	r.AddSpamAssassinCheck("msg-2", "Password reset", &SpamAssassinCheckResponse{
+		Score: 6.1,
+		Rules: []SpamAssassinRule{{Name: "MISSING_DATE", Description: "Missing Date: header", Score: 1.4}},
+	}, 5)
+	r.AddAssertion("msg-2", "Password reset", "contains reset link", errors.New("no link matching /reset/"))
+	r.AddAssertion("msg-1", "Welcome", "greets user", nil)
+
+	return r
+}
+
+func TestReporter_Passed(t *testing.T) {
+	t.Parallel()
+
+	r := NewReporter()
+	require.True(t, r.Passed())
+
+	r.AddAssertion("msg-1", "Welcome", "ok", nil)
+	require.True(t, r.Passed())
+
+	r.AddSpamAssassinCheck("msg-1", "Welcome", &SpamAssassinCheckResponse{Error: "SpamAssassin is not enabled"}, 5)
+	require.False(t, r.Passed())
+	require.Len(t, r.Cases(), 2)
+}
+
+func TestReporter_WriteJUnit(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	require.NoError(t, newTestReporter(t).WriteJUnit(&buf))
+
+	var suites junitSuites
+	require.NoError(t, xml.Unmarshal(buf.Bytes(), &suites))
+	require.Equal(t, 5, suites.Tests)
+	require.Equal(t, 3, suites.Failures)
+	require.Len(t, suites.Suites, 2)
+
+	welcome := suites.Suites[0]
+	require.Equal(t, "Welcome", welcome.Name)
+	require.Len(t, welcome.Cases, 3)
+	require.Equal(t, "html", welcome.Cases[0].Name)
+	require.Equal(t, "display:flex is unsupported in Gmail (ios), Outlook (windows)", welcome.Cases[0].Failure.Message)
+	require.Contains(t, welcome.Cases[0].Failure.Text, "no      Outlook (windows): Ignored in Outlook.com.")
+	require.Contains(t, welcome.Cases[0].Failure.Text, "See https://www.caniemail.com/features/css-display-flex/")
+	require.Nil(t, welcome.Cases[1].Failure)
+
+	reset := suites.Suites[1]
+	require.Equal(t, "Password reset", reset.Name)
+	require.Equal(t, 2, reset.Failures)
+	require.Contains(t, reset.Cases[0].Failure.Text, "MISSING_DATE")
+}
+
+func TestReporter_WriteMarkdown(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	require.NoError(t, newTestReporter(t).WriteMarkdown(&buf))
+
+	md := buf.String()
+	require.True(t, strings.HasPrefix(md, "## Email checks: 2 passed, 3 failed\n"))
+	require.Contains(t, md, "### Welcome\n")
+	require.Contains(t, md, "### Password reset\n")
+	require.Contains(t, md, "- :x: `html` (msg-1)")
+	require.Contains(t, md, "    ```\n    no      Gmail (ios)\n    no      Outlook (windows): Ignored in Outlook.com.\n")
+	require.Contains(t, md, "- :white_check_mark: `links` (msg-1)")
+	require.Contains(t, md, "  - no link matching /reset/")
+	require.Less(t, strings.Index(md, "### Welcome"), strings.Index(md, "### Password reset"))
+}
+
+func TestReporter_AddLintReport(t *testing.T) {
+	t.Parallel()
+
+	r := NewReporter()
+	r.AddLintReport(&LintReport{
+		MessageID: "msg-1",
+		Subject:   "Invoice",
+		HTMLCheck: &HTMLCheckResponse{Warnings: []HTMLCheckWarning{{
+			Title:   "<table> element",
+			Results: []HTMLCheckResult{{Name: "Outlook", Platform: "windows", Support: HTMLCheckSupportNo}},
+		}}},
+		Results: []LintResult{
+			{Check: LintCheckHeaders},
+			{Check: LintCheckHTML, Failures: []string{"HTML compatibility 70.0% is below minimum 80.0%"}},
+		},
+	})
+
+	cases := r.Cases()
+	require.Len(t, cases, 2)
+	require.True(t, cases[0].Passed())
+	require.Len(t, cases[1].Findings, 2)
+	require.Equal(t, "<table> element is unsupported in Outlook (windows)", cases[1].Findings[1].Message)
+	require.Equal(t, "no      Outlook (windows)", cases[1].Findings[1].Snippet)
+}
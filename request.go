@@ -0,0 +1,150 @@
+package mailpitclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// RequestOption configures a request made with Client.Do or Client.DoRaw.
+type RequestOption func(*requestOptions)
+
+type requestOptions struct {
+	header http.Header
+	query  url.Values
+	web    bool
+}
+
+// WithQuery adds query parameters to the request.
+func WithQuery(query url.Values) RequestOption {
+	return func(o *requestOptions) {
+		for key, values := range query {
+			for _, v := range values {
+				o.query.Add(key, v)
+			}
+		}
+	}
+}
+
+// WithHeader sets a request header, overriding the client's defaults such as
+// Content-Type and Accept.
+func WithHeader(key, value string) RequestOption {
+	return func(o *requestOptions) {
+		o.header.Set(key, value)
+	}
+}
+
+// WithWebRoute resolves the path against the webroot instead of the API
+// path, for routes such as "/view/{ID}.html" or "/readyz".
+func WithWebRoute() RequestOption {
+	return func(o *requestOptions) {
+		o.web = true
+	}
+}
+
+// Do sends a request to path, relative to the configured API path (e.g.
+// "/message/{ID}/new-feature"), using the client's authentication, retries
+// and error handling. Callers are responsible for escaping path segments,
+// for example with url.PathEscape.
+//
+// in is the request body: nil sends none, an io.Reader, []byte or string is
+// sent as is, and anything else is encoded as JSON. out receives the
+// response: nil discards it, *[]byte and *string receive the raw body, an
+// io.Writer has the body copied to it, and anything else is decoded from
+// JSON. Non-2xx responses are returned as *Error.
+func (c *client) Do(ctx context.Context, method, path string, in, out any, opts ...RequestOption) error {
+	body, err := requestBody(in)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.DoRaw(ctx, method, path, body, opts...)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch out := out.(type) {
+	case nil:
+		_, _ = io.Copy(io.Discard, resp.Body)
+
+		return nil
+	case *[]byte:
+		*out, err = io.ReadAll(resp.Body)
+	case *string:
+		var data []byte
+		data, err = io.ReadAll(resp.Body)
+		*out = string(data)
+	case io.Writer:
+		_, err = io.Copy(out, resp.Body)
+	default:
+		return c.parseResponse(resp, out)
+	}
+
+	if err != nil {
+		return &Error{
+			Type:    ErrorTypeResponse,
+			Message: fmt.Sprintf("failed to read response body: %v", err),
+			Cause:   err,
+		}
+	}
+
+	return nil
+}
+
+// DoRaw is like Do but sends body unchanged and returns the successful
+// response for the caller to read and close.
+func (c *client) DoRaw(ctx context.Context, method, path string, body io.Reader, opts ...RequestOption) (*http.Response, error) {
+	o := &requestOptions{header: make(http.Header), query: make(url.Values)}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+
+	u := c.apiURL + path
+	if o.web {
+		u = c.rootURL + path
+	}
+
+	if len(o.query) > 0 {
+		sep := "?"
+		if strings.Contains(path, "?") {
+			sep = "&"
+		}
+		u += sep + o.query.Encode()
+	}
+
+	return c.do(ctx, method, u, body, o.header)
+}
+
+func requestBody(in any) (io.Reader, error) {
+	switch in := in.(type) {
+	case nil:
+		return nil, nil //nolint:nilnil // no body is a valid request
+	case io.Reader:
+		return in, nil
+	case []byte:
+		return bytes.NewReader(in), nil
+	case string:
+		return strings.NewReader(in), nil
+	default:
+		var buf bytes.Buffer
+		if err := json.NewEncoder(&buf).Encode(in); err != nil {
+			return nil, &Error{
+				Type:    ErrorTypeRequest,
+				Message: fmt.Sprintf("failed to encode request body: %v", err),
+				Cause:   err,
+			}
+		}
+
+		return &buf, nil
+	}
+}
@@ -0,0 +1,169 @@
+package mailpitclient
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type doRequest struct {
+	Method      string
+	URI         string
+	Body        string
+	ContentType string
+	Auth        string
+}
+
+func newDoTestClient(t *testing.T, handler func(w http.ResponseWriter, r *http.Request)) (Client, func() []doRequest) {
+	t.Helper()
+
+	var (
+		mu       sync.Mutex
+		requests []doRequest
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		requests = append(requests, doRequest{
+			Method:      r.Method,
+			URI:         r.URL.RequestURI(),
+			Body:        string(body),
+			ContentType: r.Header.Get("Content-Type"),
+			Auth:        r.Header.Get("Authorization"),
+		})
+		mu.Unlock()
+
+		handler(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	c, err := NewClient(&Config{
+		BaseURL:    server.URL + "/mailpit",
+		APIPath:    "/api/v1",
+		APIKey:     "secret",
+		MaxRetries: 1,
+		RetryDelay: time.Millisecond,
+		HTTPClient: &http.Client{Timeout: 5 * time.Second},
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = c.Close() })
+
+	return c, func() []doRequest {
+		mu.Lock()
+		defer mu.Unlock()
+
+		return append([]doRequest(nil), requests...)
+	}
+}
+
+func TestClient_Do(t *testing.T) {
+	t.Parallel()
+
+	c, requests := newDoTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/mailpit/api/v1/future":
+			_, _ = w.Write([]byte(`{"Answer":42}`))
+		case "/mailpit/view/msg-1.txt":
+			_, _ = w.Write([]byte("plain text"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+
+	ctx := t.Context()
+
+	var out struct{ Answer int }
+	err := c.Do(ctx, http.MethodPost, "/future", map[string]string{"Q": "life"}, &out,
+		WithQuery(url.Values{"verbose": {"1"}}))
+	require.NoError(t, err)
+	require.Equal(t, 42, out.Answer)
+
+	var raw []byte
+	require.NoError(t, c.Do(ctx, http.MethodPut, "future?a=1", []byte("raw"), &raw,
+		WithQuery(url.Values{"b": {"2"}}), WithHeader("Content-Type", "text/plain")))
+	require.JSONEq(t, `{"Answer":42}`, string(raw))
+
+	var text string
+	require.NoError(t, c.Do(ctx, http.MethodGet, "/view/msg-1.txt", nil, &text, WithWebRoute()))
+	require.Equal(t, "plain text", text)
+
+	var buf bytes.Buffer
+	require.NoError(t, c.Do(ctx, http.MethodGet, "/future", nil, &buf))
+	require.JSONEq(t, `{"Answer":42}`, buf.String())
+
+	require.NoError(t, c.Do(ctx, http.MethodDelete, "/future", strings.NewReader("x"), nil))
+
+	err = c.Do(ctx, http.MethodGet, "/missing", nil, nil)
+	require.ErrorIs(t, err, ErrNotFound)
+
+	var mailpitErr *Error
+	require.ErrorAs(t, err, &mailpitErr)
+	require.Equal(t, http.StatusNotFound, mailpitErr.StatusCode)
+
+	got := requests()
+	require.Equal(t, doRequest{
+		Method:      http.MethodPost,
+		URI:         "/mailpit/api/v1/future?verbose=1",
+		Body:        "{\"Q\":\"life\"}\n",
+		ContentType: "application/json",
+		Auth:        "Bearer secret",
+	}, got[0])
+	require.Equal(t, "/mailpit/api/v1/future?a=1&b=2", got[1].URI)
+	require.Equal(t, "raw", got[1].Body)
+	require.Equal(t, "text/plain", got[1].ContentType)
+	require.Equal(t, "/mailpit/view/msg-1.txt", got[2].URI)
+	require.Equal(t, "x", got[4].Body)
+}
+
+func TestClient_DoRaw(t *testing.T) {
+	t.Parallel()
+
+	var attempts int
+	c, requests := newDoTestClient(t, func(w http.ResponseWriter, _ *http.Request) {
+		attempts++
+		if attempts == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+
+			return
+		}
+
+		w.Header().Set("X-Mailpit", "yes")
+		_, _ = w.Write([]byte("ok"))
+	})
+
+	resp, err := c.DoRaw(t.Context(), http.MethodPost, "/future", strings.NewReader("payload"))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.Equal(t, "ok", string(body))
+	require.Equal(t, "yes", resp.Header.Get("X-Mailpit"))
+
+	got := requests()
+	require.Len(t, got, 2)
+	require.Equal(t, "payload", got[1].Body, "the body is resent on retry")
+}
+
+func TestClient_Do_InvalidBody(t *testing.T) {
+	t.Parallel()
+
+	c, requests := newDoTestClient(t, func(http.ResponseWriter, *http.Request) {})
+
+	err := c.Do(t.Context(), http.MethodPost, "/future", map[string]any{"f": func() {}}, nil)
+	require.Error(t, err)
+
+	var mailpitErr *Error
+	require.ErrorAs(t, err, &mailpitErr)
+	require.Equal(t, ErrorTypeRequest, mailpitErr.Type)
+	require.Empty(t, requests())
+}
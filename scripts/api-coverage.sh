@@ -78,6 +78,48 @@ update_spec_url() {
     log_info "Backup saved as e2e_api_coverage_test.go.bak"
 }
 
+# Function to run the offline contract tests against the pinned spec
+run_contract_test() {
+    log_info "Running contract tests against the pinned OpenAPI specification..."
+    cd "$PROJECT_DIR"
+
+    if go test -v -run 'TestContract' -timeout=1m; then
+        log_success "Contract tests passed!"
+        return 0
+    else
+        log_error "Contract tests failed!"
+        return 1
+    fi
+}
+
+# Function to pin the OpenAPI specification of a Mailpit release
+pin_spec() {
+    local version="$1"
+    if [ -z "$version" ]; then
+        log_error "Please provide the Mailpit version to pin"
+        echo "Usage: $0 pin-spec <version>"
+        exit 1
+    fi
+
+    local url="https://raw.githubusercontent.com/axllent/mailpit/$version/server/ui/api/v1/swagger.json"
+    local spec="$PROJECT_DIR/testdata/mailpit-swagger.json"
+
+    log_info "Downloading OpenAPI specification for Mailpit $version..."
+    if ! curl -fsSL "$url" -o "$spec.tmp"; then
+        rm -f "$spec.tmp"
+        log_error "Failed to download $url"
+        exit 1
+    fi
+    mv "$spec.tmp" "$spec"
+
+    sed -i.bak "s|pinnedMailpitVersion = \".*\"|pinnedMailpitVersion = \"$version\"|" \
+        "$PROJECT_DIR/contract_test.go"
+    rm -f "$PROJECT_DIR/contract_test.go.bak"
+
+    log_success "Pinned OpenAPI specification to Mailpit $version"
+    log_info "Run '$0 contract' and update the drift lists in contract_test.go"
+}
+
 # Function to generate method stubs for missing routes
 generate_method_stubs() {
     log_info "This feature will be implemented to generate method stubs for missing routes"
@@ -121,6 +163,8 @@ show_usage() {
     echo "Commands:"
     echo "  test              Run the full API coverage test"
     echo "  test-offline      Run the offline API coverage test (faster)"
+    echo "  contract          Run the offline contract tests against the pinned spec"
+    echo "  pin-spec          Pin the OpenAPI specification of a Mailpit release"
     echo "  update-spec-url   Update the OpenAPI specification URL"
     echo "  generate-stubs    Generate method stubs for missing routes"
     echo "  check-deps        Check and update Go dependencies"
@@ -129,6 +173,8 @@ show_usage() {
     echo "Examples:"
     echo "  $0 test"
     echo "  $0 test-offline"
+    echo "  $0 contract"
+    echo "  $0 pin-spec v1.27.0"
     echo "  $0 update-spec-url https://example.com/new-swagger.json"
     echo "  $0 generate-stubs"
     echo "  $0 check-deps"
@@ -142,6 +188,12 @@ case "${1:-help}" in
     "test-offline")
         run_offline_coverage_test
         ;;
+    "contract")
+        run_contract_test
+        ;;
+    "pin-spec")
+        pin_spec "$2"
+        ;;
     "update-spec-url")
         update_spec_url "$2"
         ;;
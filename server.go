@@ -23,12 +23,12 @@ func (c *client) GetServerInfo(ctx context.Context) (*ServerInfo, error) {
 	return &info, nil
 }
 
-// HealthCheck performs a health check against the server.
+// HealthCheck performs a health check against the server's /livez route.
 // This is a simple check that verifies the server is responding.
 func (c *client) HealthCheck(ctx context.Context) error {
-	endpoint := "/info"
+	endpoint := "/livez"
 
-	resp, err := c.makeRequest(ctx, http.MethodGet, endpoint, nil)
+	resp, err := c.makeWebRequest(ctx, http.MethodGet, endpoint)
 	if err != nil {
 		return err
 	}
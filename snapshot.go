@@ -0,0 +1,187 @@
+package mailpitclient
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// SnapshotMask replaces every match of Pattern in a rendered snapshot with
+// Replacement, which may reference capture groups ("$1").
+type SnapshotMask struct {
+	Pattern     *regexp.Regexp
+	Replacement string
+}
+
+// DefaultSnapshotMasks mask data that changes on every send: RFC 5322 and
+// ISO 8601 timestamps, Message-ID, In-Reply-To and References header values
+// and MIME boundaries.
+func DefaultSnapshotMasks() []SnapshotMask {
+	return []SnapshotMask{
+		{
+			Pattern:     regexp.MustCompile(`(?im)^(Message-ID|In-Reply-To|References): .*$`),
+			Replacement: "$1: <MESSAGE-ID>",
+		},
+		{
+			Pattern:     regexp.MustCompile(`(?i)boundary=("[^"]*"|[^\s;]+)`),
+			Replacement: `boundary="<BOUNDARY>"`,
+		},
+		{
+			Pattern: regexp.MustCompile(
+				`(?:(?:Mon|Tue|Wed|Thu|Fri|Sat|Sun), )?\d{1,2} (?:Jan|Feb|Mar|Apr|May|Jun|Jul|Aug|Sep|Oct|Nov|Dec) \d{4} ` +
+					`\d{2}:\d{2}(?::\d{2})? (?:[+-]\d{4}|[A-Z]{2,4})(?: \([A-Z]{2,5}\))?`),
+			Replacement: "<DATE>",
+		},
+		{
+			Pattern:     regexp.MustCompile(`\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}:\d{2}(?:\.\d+)?(?:Z|[+-]\d{2}:?\d{2})?`),
+			Replacement: "<DATE>",
+		},
+	}
+}
+
+// SnapshotOptions configures RenderSnapshot and MessageSnapshot.
+type SnapshotOptions struct {
+	// Headers lists additional raw headers to include, in order, such as
+	// "List-Unsubscribe" or "X-Campaign". MessageSnapshot only fetches the
+	// message headers when this is set.
+	Headers []string
+	// Masks are applied after the default masks.
+	Masks []SnapshotMask
+	// NoDefaultMasks disables DefaultSnapshotMasks.
+	NoDefaultMasks bool
+	// SkipText omits the text body section.
+	SkipText bool
+	// SkipHTML omits the HTML body section.
+	SkipHTML bool
+}
+
+// RenderSnapshot renders msg into a stable, human-readable representation
+// suitable for golden file comparison: the headers of interest, the text body,
+// the HTML body converted with HTMLToText and the attachment list. headers are
+// the raw message headers and are only consulted for opts.Headers.
+//
+// Volatile data is masked: the message's Mailpit ID is replaced with "<ID>",
+// its Message-ID with "<MESSAGE-ID>", and DefaultSnapshotMasks followed by
+// opts.Masks are applied. Line endings are normalised to "\n" and trailing
+// whitespace is removed from every line.
+func RenderSnapshot(msg *Message, headers map[string][]string, opts *SnapshotOptions) string {
+	if opts == nil {
+		opts = &SnapshotOptions{}
+	}
+
+	var b strings.Builder
+
+	writeSnapshotHeader(&b, "Subject", msg.Subject)
+	writeSnapshotHeader(&b, "From", formatSnapshotAddress(msg.From))
+	writeSnapshotHeader(&b, "To", formatSnapshotAddresses(msg.To))
+	writeSnapshotHeader(&b, "Cc", formatSnapshotAddresses(msg.Cc))
+	writeSnapshotHeader(&b, "Bcc", formatSnapshotAddresses(msg.Bcc))
+	writeSnapshotHeader(&b, "Reply-To", formatSnapshotAddresses(msg.ReplyTo))
+	for _, name := range opts.Headers {
+		writeSnapshotHeader(&b, name, strings.Join(headerValues(headers, name), ", "))
+	}
+
+	if !opts.SkipText && strings.TrimSpace(msg.Text) != "" {
+		writeSnapshotSection(&b, "text", msg.Text)
+	}
+
+	if !opts.SkipHTML && strings.TrimSpace(msg.HTML) != "" {
+		writeSnapshotSection(&b, "html", HTMLToText(msg.HTML))
+	}
+
+	if len(msg.Attachments) > 0 || len(msg.Inline) > 0 {
+		var parts strings.Builder
+		for _, a := range msg.Attachments {
+			fmt.Fprintf(&parts, "%s (%s, %d bytes)\n", a.FileName, a.ContentType, a.Size)
+		}
+		for _, a := range msg.Inline {
+			fmt.Fprintf(&parts, "%s (%s, %d bytes, inline)\n", a.FileName, a.ContentType, a.Size)
+		}
+		writeSnapshotSection(&b, "attachments", parts.String())
+	}
+
+	out := b.String()
+	if msg.ID != "" {
+		out = strings.ReplaceAll(out, msg.ID, "<ID>")
+	}
+	if msg.MessageID != "" {
+		out = strings.ReplaceAll(out, msg.MessageID, "<MESSAGE-ID>")
+	}
+
+	masks := opts.Masks
+	if !opts.NoDefaultMasks {
+		masks = append(DefaultSnapshotMasks(), masks...)
+	}
+	for _, m := range masks {
+		if m.Pattern != nil {
+			out = m.Pattern.ReplaceAllString(out, m.Replacement)
+		}
+	}
+
+	return out
+}
+
+// MessageSnapshot fetches a message and renders it with RenderSnapshot.
+func MessageSnapshot(ctx context.Context, c Client, id string, opts *SnapshotOptions) (string, error) {
+	msg, err := fetchMessage(ctx, c, id)
+	if err != nil {
+		return "", err
+	}
+
+	var headers map[string][]string
+	if opts != nil && len(opts.Headers) > 0 {
+		if headers, err = c.GetMessageHeaders(ctx, id); err != nil {
+			return "", err
+		}
+	}
+
+	return RenderSnapshot(msg, headers, opts), nil
+}
+
+func writeSnapshotHeader(b *strings.Builder, name, value string) {
+	if value == "" {
+		return
+	}
+
+	b.WriteString(name + ": " + value + "\n")
+}
+
+func writeSnapshotSection(b *strings.Builder, name, body string) {
+	body = strings.ReplaceAll(body, "\r\n", "\n")
+	lines := strings.Split(strings.Trim(body, "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t\r")
+	}
+
+	b.WriteString("\n--- " + name + " ---\n")
+	b.WriteString(strings.Join(lines, "\n") + "\n")
+}
+
+// headerValues looks up a header case-insensitively.
+func headerValues(headers map[string][]string, name string) []string {
+	for k, v := range headers {
+		if strings.EqualFold(k, name) {
+			return v
+		}
+	}
+
+	return nil
+}
+
+func formatSnapshotAddress(a Address) string {
+	if a.Name == "" {
+		return a.Address
+	}
+
+	return a.Name + " <" + a.Address + ">"
+}
+
+func formatSnapshotAddresses(addrs []Address) string {
+	formatted := make([]string, 0, len(addrs))
+	for _, a := range addrs {
+		formatted = append(formatted, formatSnapshotAddress(a))
+	}
+
+	return strings.Join(formatted, ", ")
+}
@@ -0,0 +1,161 @@
+package mailpitclient
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func snapshotTestMessage() *Message {
+	return &Message{
+		ID:        "Zq8rTmVj3kWb",
+		MessageID: "20250101.abc@example.com",
+		Subject:   "Your order #1042",
+		From:      Address{Name: "Shop", Address: "shop@example.com"},
+		To:        []Address{{Name: "Alice", Address: "alice@example.com"}, {Address: "bob@example.com"}},
+		ReplyTo:   []Address{{Address: "support@example.com"}},
+		Text: "Hi Alice,  \r\nOrdered on Mon, 06 Jan 2025 10:11:12 +0000.\r\n" +
+			"View: https://mailpit.local/view/Zq8rTmVj3kWb\r\n",
+		HTML: `<p>Hi Alice,</p><p>Shipped at 2025-01-06T10:11:12.345Z</p>` +
+			`<a href="https://example.com/track?m=20250101.abc@example.com">Track</a>`,
+		Attachments: AttachmentList{{FileName: "invoice.pdf", ContentType: "application/pdf", Size: 1024}},
+		Inline:      AttachmentList{{FileName: "logo.png", ContentType: "image/png", Size: 256}},
+	}
+}
+
+func TestRenderSnapshot(t *testing.T) {
+	t.Parallel()
+
+	headers := map[string][]string{
+		"Message-Id":       {"<20250101.abc@example.com>"},
+		"List-Unsubscribe": {"<https://example.com/unsub?u=7>"},
+		"Content-Type":     {`multipart/alternative; boundary="b1_2f7a9c"`},
+	}
+
+	tests := []struct {
+		name     string
+		opts     *SnapshotOptions
+		expected string
+	}{
+		{
+			name: "defaults",
+			expected: `Subject: Your order #1042
+From: Shop <shop@example.com>
+To: Alice <alice@example.com>, bob@example.com
+Reply-To: support@example.com
+
+--- text ---
+Hi Alice,
+Ordered on <DATE>.
+View: https://mailpit.local/view/<ID>
+
+--- html ---
+Hi Alice,
+
+Shipped at <DATE>
+
+Track (https://example.com/track?m=<MESSAGE-ID>)
+
+--- attachments ---
+invoice.pdf (application/pdf, 1024 bytes)
+logo.png (image/png, 256 bytes, inline)
+`,
+		},
+		{
+			name: "extra headers and custom masks",
+			opts: &SnapshotOptions{
+				Headers:  []string{"message-id", "List-Unsubscribe", "Content-Type", "X-Missing"},
+				Masks:    []SnapshotMask{{Pattern: regexp.MustCompile(`u=\d+`), Replacement: "u=<USER>"}},
+				SkipText: true,
+				SkipHTML: true,
+			},
+			expected: `Subject: Your order #1042
+From: Shop <shop@example.com>
+To: Alice <alice@example.com>, bob@example.com
+Reply-To: support@example.com
+message-id: <MESSAGE-ID>
+List-Unsubscribe: <https://example.com/unsub?u=<USER>>
+Content-Type: multipart/alternative; boundary="<BOUNDARY>"
+
+--- attachments ---
+invoice.pdf (application/pdf, 1024 bytes)
+logo.png (image/png, 256 bytes, inline)
+`,
+		},
+		{
+			name: "default masks disabled",
+			opts: &SnapshotOptions{NoDefaultMasks: true, SkipHTML: true},
+			expected: `Subject: Your order #1042
+From: Shop <shop@example.com>
+To: Alice <alice@example.com>, bob@example.com
+Reply-To: support@example.com
+
+--- text ---
+Hi Alice,
+Ordered on Mon, 06 Jan 2025 10:11:12 +0000.
+View: https://mailpit.local/view/<ID>
+
+--- attachments ---
+invoice.pdf (application/pdf, 1024 bytes)
+logo.png (image/png, 256 bytes, inline)
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			require.Equal(t, tt.expected, RenderSnapshot(snapshotTestMessage(), headers, tt.opts))
+		})
+	}
+}
+
+func TestMessageSnapshot(t *testing.T) {
+	t.Parallel()
+
+	var headerRequests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		switch r.URL.Path {
+		case "/api/v1/message/Zq8rTmVj3kWb":
+			_ = json.NewEncoder(w).Encode(snapshotTestMessage())
+		case "/api/v1/message/Zq8rTmVj3kWb/headers":
+			headerRequests++
+			_ = json.NewEncoder(w).Encode(map[string][]string{"X-Campaign": {"spring"}})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	c, err := NewClient(&Config{
+		BaseURL:    server.URL,
+		APIPath:    "/api/v1",
+		MaxRetries: 0,
+		HTTPClient: &http.Client{Timeout: 5 * time.Second},
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = c.Close() })
+
+	ctx := t.Context()
+
+	snapshot, err := MessageSnapshot(ctx, c, "Zq8rTmVj3kWb", nil)
+	require.NoError(t, err)
+	require.Contains(t, snapshot, "Subject: Your order #1042\n")
+	require.Zero(t, headerRequests, "headers are only fetched when requested")
+
+	snapshot, err = MessageSnapshot(ctx, c, "Zq8rTmVj3kWb", &SnapshotOptions{Headers: []string{"X-Campaign"}})
+	require.NoError(t, err)
+	require.Contains(t, snapshot, "X-Campaign: spring\n")
+	require.Equal(t, 1, headerRequests)
+
+	_, err = MessageSnapshot(ctx, c, "missing", nil)
+	require.ErrorIs(t, err, ErrNotFound)
+}
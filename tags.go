@@ -5,6 +5,7 @@ import (
 	"context"
 	"encoding/json"
 	"net/http"
+	"net/url"
 )
 
 // SetTags sets the list of available tags on the server.
@@ -42,7 +43,7 @@ func (c *client) SetMessageTags(ctx context.Context, tag string, messageIDs []st
 		return NewValidationError("message IDs cannot be empty")
 	}
 
-	endpoint := "/tags/" + tag
+	endpoint := "/tags/" + url.PathEscape(tag)
 
 	var body bytes.Buffer
 	if err := json.NewEncoder(&body).Encode(messageIDs); err != nil {
@@ -68,7 +69,7 @@ func (c *client) DeleteTag(ctx context.Context, tag string) error {
 		return NewValidationError("tag cannot be empty")
 	}
 
-	endpoint := "/tags/" + tag
+	endpoint := "/tags/" + url.PathEscape(tag)
 
 	resp, err := c.makeRequest(ctx, http.MethodDelete, endpoint, nil)
 	if err != nil {
@@ -251,7 +251,7 @@ func (ts *TestSMTP) ClearMessages(tb testing.TB) {
 }
 
 // GetMessages is a helper function to retrieve all messages from mailpit
-func (ts *TestSMTP) GetMessages(tb testing.TB) []Message {
+func (ts *TestSMTP) GetMessages(tb testing.TB) []MessageSummary {
 	tb.Helper()
 	resp, err := ts.MailpitClient.ListMessages(tb.Context(), nil)
 	if err != nil {
@@ -262,7 +262,7 @@ func (ts *TestSMTP) GetMessages(tb testing.TB) []Message {
 }
 
 // WaitForMessages waits for the expected number of messages to arrive
-func (ts *TestSMTP) WaitForMessages(tb testing.TB, expectedCount int, timeout time.Duration) []Message {
+func (ts *TestSMTP) WaitForMessages(tb testing.TB, expectedCount int, timeout time.Duration) []MessageSummary {
 	tb.Helper()
 
 	ctx, cancel := context.WithTimeout(tb.Context(), timeout)
@@ -0,0 +1,174 @@
+package testing
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/CodeLieutenant/mailpitclient"
+)
+
+const (
+	mailpitUIAuthPath   = "/auth/ui.htpasswd"
+	mailpitSendAuthPath = "/auth/send.htpasswd"
+	mailpitUICertPath   = "/certs/ui.crt"
+	mailpitUIKeyPath    = "/certs/ui.key"
+)
+
+// WithSendAPIAuth protects the Send API with its own credentials. Mailpit
+// then rejects the UI credentials for sending; Mailpit.SendClient uses these.
+func WithSendAPIAuth(username, password string) MailpitOption {
+	return func(opts *MailpitOptions) {
+		opts.SendUsername = username
+		opts.SendPassword = password
+	}
+}
+
+// WithHTTPS serves the web UI and API over HTTPS with a certificate issued by
+// ca, or by the CA of WithGeneratedTLS when ca is nil. Mailpit.Client trusts
+// the CA.
+func WithHTTPS(ca *TestCA) MailpitOption {
+	return func(opts *MailpitOptions) {
+		opts.HTTPS = true
+		if ca != nil {
+			opts.CA = ca
+		}
+	}
+}
+
+// prepare writes the files the options need into a temporary directory,
+// which is returned for removal: the certificates issued by the test CA and
+// bcrypt htpasswd files for the UI and Send API credentials.
+func (o *MailpitOptions) prepare() (string, error) {
+	if !o.GenerateTLS && !o.HTTPS && o.UIUsername == "" && o.SendUsername == "" {
+		return "", nil
+	}
+
+	dir, err := os.MkdirTemp("", "mailpit-")
+	if err != nil {
+		return "", fmt.Errorf("failed to create mailpit file directory: %w", err)
+	}
+
+	if err = o.prepareFiles(dir); err != nil {
+		_ = os.RemoveAll(dir)
+
+		return "", err
+	}
+
+	return dir, nil
+}
+
+func (o *MailpitOptions) prepareFiles(dir string) error {
+	if (o.GenerateTLS || o.HTTPS) && o.CA == nil {
+		ca, err := NewTestCA()
+		if err != nil {
+			return err
+		}
+		o.CA = ca
+	}
+
+	var err error
+
+	if o.GenerateTLS {
+		smtpDir := filepath.Join(dir, "smtp")
+		if err = os.Mkdir(smtpDir, 0o700); err != nil {
+			return fmt.Errorf("failed to create certificate directory: %w", err)
+		}
+
+		if o.TLSCert, o.TLSKey, err = o.CA.WriteServerCert(smtpDir, serverCertHosts()...); err != nil {
+			return err
+		}
+	}
+
+	if o.HTTPS {
+		uiDir := filepath.Join(dir, "ui")
+		if err = os.Mkdir(uiDir, 0o700); err != nil {
+			return fmt.Errorf("failed to create certificate directory: %w", err)
+		}
+
+		if o.uiCert, o.uiKey, err = o.CA.WriteServerCert(uiDir, serverCertHosts()...); err != nil {
+			return err
+		}
+	}
+
+	if o.UIUsername != "" {
+		if o.uiAuthFile, err = writeHtpasswd(filepath.Join(dir, "ui.htpasswd"), o.UIUsername, o.UIPassword); err != nil {
+			return err
+		}
+	}
+
+	if o.SendUsername != "" {
+		if o.sendAuthFile, err = writeHtpasswd(filepath.Join(dir, "send.htpasswd"), o.SendUsername, o.SendPassword); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// writeHtpasswd writes an htpasswd file with a bcrypt hash of password.
+func writeHtpasswd(path, username, password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash password for %s: %w", username, err)
+	}
+
+	if err = os.WriteFile(path, []byte(username+":"+string(hash)+"\n"), 0o600); err != nil {
+		return "", fmt.Errorf("failed to write htpasswd file: %w", err)
+	}
+
+	return path, nil
+}
+
+// ClientConfig returns a copy of base pointed at the instance, with the UI
+// credentials unless base sets a username, and an HTTP client trusting the
+// test CA when WithHTTPS is used. A nil base uses the package defaults.
+func (m *Mailpit) ClientConfig(base *mailpitclient.Config) *mailpitclient.Config {
+	config := mailpitclient.Config{
+		APIPath:    "/api/v1",
+		HTTPClient: &http.Client{Timeout: 10 * time.Second},
+	}
+	if base != nil {
+		config = *base
+	}
+
+	config.BaseURL = m.APIURL()
+	if config.Username == "" {
+		config.Username = m.options.UIUsername
+		config.Password = m.options.UIPassword
+	}
+
+	if m.options.HTTPS {
+		config.HTTPClient = m.trustingHTTPClient(config.HTTPClient)
+	}
+
+	return &config
+}
+
+// trustingHTTPClient returns a copy of hc whose transport trusts the test CA.
+func (m *Mailpit) trustingHTTPClient(hc *http.Client) *http.Client {
+	var client http.Client
+	if hc != nil {
+		client = *hc
+	}
+
+	transport, ok := client.Transport.(*http.Transport)
+	switch {
+	case client.Transport == nil:
+		transport = http.DefaultTransport.(*http.Transport).Clone() //nolint:forcetypeassert // documented type
+	case ok:
+		transport = transport.Clone()
+	default:
+		// A custom RoundTripper is responsible for its own TLS configuration.
+		return hc
+	}
+
+	transport.TLSClientConfig = m.options.CA.TLSConfig(m.Host)
+	client.Transport = transport
+
+	return &client
+}
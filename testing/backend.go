@@ -0,0 +1,372 @@
+package testing
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/testcontainers/testcontainers-go"
+)
+
+// Backend selects how RunMailpit starts Mailpit.
+type Backend string
+
+const (
+	// BackendAuto uses Docker when the daemon is reachable and a local
+	// mailpit binary otherwise.
+	BackendAuto Backend = "auto"
+	// BackendDocker runs the Mailpit image with testcontainers.
+	BackendDocker Backend = "docker"
+	// BackendBinary runs a locally installed mailpit binary as a subprocess
+	// on free ports, with its own temporary database.
+	BackendBinary Backend = "binary"
+
+	// BackendEnv selects the backend when no option is given, e.g.
+	// TEST_SMTP_BACKEND=binary.
+	BackendEnv = "TEST_SMTP_BACKEND"
+	// BinaryEnv is the path of the mailpit binary. By default it is looked up
+	// in PATH.
+	BinaryEnv = "TEST_SMTP_MAILPIT_BINARY"
+
+	binaryName         = "mailpit"
+	dockerProbeTimeout = 5 * time.Second
+	binaryStopTimeout  = 10 * time.Second
+)
+
+// ErrNoBackend is returned by RunMailpit when the selected backend is not
+// available. GetTestSMTP and NewMailpit skip the test instead of failing.
+var ErrNoBackend = errors.New("no mailpit backend available")
+
+// WithBackend selects the backend, overriding TEST_SMTP_BACKEND.
+func WithBackend(backend Backend) MailpitOption {
+	return func(opts *MailpitOptions) {
+		opts.Backend = backend
+	}
+}
+
+// WithBinary sets the path of the mailpit binary used by BackendBinary,
+// overriding TEST_SMTP_MAILPIT_BINARY.
+func WithBinary(path string) MailpitOption {
+	return func(opts *MailpitOptions) {
+		opts.Binary = path
+	}
+}
+
+// resolveBackend returns the backend to use: the option, then
+// TEST_SMTP_BACKEND, then auto-detection.
+func (o *MailpitOptions) resolveBackend(ctx context.Context) (Backend, error) {
+	backend := o.Backend
+	if backend == "" {
+		backend = Backend(os.Getenv(BackendEnv))
+	}
+
+	switch backend {
+	case BackendDocker:
+		if err := dockerHealth(ctx); err != nil {
+			return "", fmt.Errorf("%w: docker: %w", ErrNoBackend, err)
+		}
+
+		return BackendDocker, nil
+	case BackendBinary:
+		if _, err := o.binaryPath(); err != nil {
+			return "", err
+		}
+
+		return BackendBinary, nil
+	case "", BackendAuto:
+		dockerErr := dockerHealth(ctx)
+		if dockerErr == nil {
+			return BackendDocker, nil
+		}
+
+		if _, err := o.binaryPath(); err != nil {
+			return "", fmt.Errorf("%w: docker: %w; %w", ErrNoBackend, dockerErr, err)
+		}
+
+		return BackendBinary, nil
+	default:
+		return "", fmt.Errorf("unknown mailpit backend %q (want %s, %s or %s)", backend, BackendAuto, BackendDocker, BackendBinary)
+	}
+}
+
+// binaryPath returns the mailpit binary from the option, TEST_SMTP_MAILPIT_BINARY
+// or PATH.
+func (o *MailpitOptions) binaryPath() (string, error) {
+	name := o.Binary
+	if name == "" {
+		name = os.Getenv(BinaryEnv)
+	}
+	if name == "" {
+		name = binaryName
+	}
+
+	path, err := exec.LookPath(name)
+	if err != nil {
+		return "", fmt.Errorf("%w: mailpit binary: %w", ErrNoBackend, err)
+	}
+
+	return path, nil
+}
+
+var (
+	dockerHealthMu  sync.Mutex
+	dockerHealthErr error
+	dockerProbed    bool
+)
+
+// dockerHealth reports whether the Docker daemon is reachable. The result is
+// cached for the process, as every pooled container would probe again.
+func dockerHealth(ctx context.Context) error {
+	dockerHealthMu.Lock()
+	defer dockerHealthMu.Unlock()
+
+	if !dockerProbed {
+		ctx, cancel := context.WithTimeout(ctx, dockerProbeTimeout)
+		defer cancel()
+
+		dockerHealthErr = probeDocker(ctx)
+		dockerProbed = true
+	}
+
+	return dockerHealthErr
+}
+
+func probeDocker(ctx context.Context) (err error) { //nolint:nonamedreturns // set by the recover
+	// testcontainers panics when no Docker host can be found.
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("docker is not available: %v", r) //nolint:err113 // wraps a panic value
+		}
+	}()
+
+	provider, err := testcontainers.NewDockerProvider()
+	if err != nil {
+		return err
+	}
+
+	return provider.Health(ctx)
+}
+
+// runBinary starts the mailpit binary on free loopback ports with a database
+// in a temporary directory and waits until the API responds.
+func runBinary(ctx context.Context, options MailpitOptions) (*Mailpit, error) {
+	path, err := options.binaryPath()
+	if err != nil {
+		return nil, err
+	}
+
+	env, err := options.binaryEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	dir, err := os.MkdirTemp("", "mailpit-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create mailpit data directory: %w", err)
+	}
+
+	smtpPort, err := freePort()
+	if err != nil {
+		_ = os.RemoveAll(dir)
+
+		return nil, err
+	}
+
+	apiPort, err := freePort()
+	if err != nil {
+		_ = os.RemoveAll(dir)
+
+		return nil, err
+	}
+
+	host := "127.0.0.1"
+	env["MP_SMTP_BIND_ADDR"] = net.JoinHostPort(host, smtpPort)
+	env["MP_UI_BIND_ADDR"] = net.JoinHostPort(host, apiPort)
+	env["MP_DATABASE"] = filepath.Join(dir, "mailpit.db")
+
+	// The process outlives ctx, which only bounds the startup.
+	cmd := exec.Command(path) //nolint:gosec,noctx // binary chosen by the caller
+	cmd.Env = os.Environ()
+	for k, v := range env {
+		cmd.Env = append(cmd.Env, k+"="+v)
+	}
+
+	logs := &lockedBuffer{}
+	cmd.Stdout = logs
+	cmd.Stderr = logs
+
+	if err = cmd.Start(); err != nil {
+		_ = os.RemoveAll(dir)
+
+		return nil, fmt.Errorf("failed to start mailpit binary: %w", err)
+	}
+
+	mp := &Mailpit{
+		Backend:  BackendBinary,
+		Host:     host,
+		SMTPPort: smtpPort,
+		APIPort:  apiPort,
+		options:  options,
+		process:  &mailpitProcess{cmd: cmd, dir: dir, logs: logs, exited: make(chan struct{})},
+	}
+	go mp.process.wait()
+
+	if err = mp.process.ready(ctx, mp); err == nil {
+		err = mp.connect()
+	}
+	if err != nil {
+		_ = mp.process.stop(context.WithoutCancel(ctx))
+
+		return nil, err
+	}
+
+	return mp, nil
+}
+
+// binaryEnv is env with the host paths of the files a container would have
+// copied, which the process reads directly.
+func (o *MailpitOptions) binaryEnv() (map[string]string, error) {
+	env, files, err := o.env()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, f := range files {
+		for k, v := range env {
+			if v == f.ContainerFilePath {
+				env[k] = f.HostFilePath
+			}
+		}
+	}
+
+	return env, nil
+}
+
+// freePort returns a loopback port that was free when checked.
+func freePort() (string, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0") //nolint:noctx // short-lived probe
+	if err != nil {
+		return "", fmt.Errorf("failed to find a free port: %w", err)
+	}
+	defer l.Close()
+
+	_, port, err := net.SplitHostPort(l.Addr().String())
+	if err != nil {
+		return "", fmt.Errorf("failed to find a free port: %w", err)
+	}
+
+	return port, nil
+}
+
+// mailpitProcess is a mailpit binary started by runBinary.
+type mailpitProcess struct {
+	cmd     *exec.Cmd
+	logs    *lockedBuffer
+	exited  chan struct{}
+	waitErr error
+	dir     string
+}
+
+func (p *mailpitProcess) wait() {
+	p.waitErr = p.cmd.Wait()
+	close(p.exited)
+}
+
+// ready polls the info endpoint of mp until it responds with 200 OK, the
+// process exits or the startup timeout elapses.
+func (p *mailpitProcess) ready(ctx context.Context, mp *Mailpit) error {
+	options := mp.options
+	url := mp.APIURL() + "/api/v1/info"
+
+	ctx, cancel := context.WithTimeout(ctx, options.StartupTimeout)
+	defer cancel()
+
+	client := &http.Client{Timeout: time.Second}
+	if options.HTTPS {
+		client = mp.trustingHTTPClient(client)
+	}
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, http.NoBody)
+		if err != nil {
+			return fmt.Errorf("mailpit readiness check: %w", err)
+		}
+		if options.UIUsername != "" {
+			req.SetBasicAuth(options.UIUsername, options.UIPassword)
+		}
+
+		if resp, err := client.Do(req); err == nil {
+			_ = resp.Body.Close()
+			if resp.StatusCode == http.StatusOK {
+				return nil
+			}
+		}
+
+		select {
+		case <-p.exited:
+			return fmt.Errorf("mailpit binary exited during startup: %w\n%s", p.waitErr, p.logs.String())
+		case <-ctx.Done():
+			return fmt.Errorf("mailpit binary did not become ready: %w\n%s", ctx.Err(), p.logs.String())
+		case <-ticker.C:
+		}
+	}
+}
+
+// stop interrupts the process, kills it if it has not exited when ctx is
+// done, and removes its data directory.
+func (p *mailpitProcess) stop(ctx context.Context) error {
+	select {
+	case <-p.exited:
+	default:
+		if err := p.cmd.Process.Signal(os.Interrupt); err != nil {
+			// Interrupt is not supported on Windows.
+			_ = p.cmd.Process.Kill()
+		}
+
+		select {
+		case <-p.exited:
+		case <-ctx.Done():
+			_ = p.cmd.Process.Kill()
+			<-p.exited
+		case <-time.After(binaryStopTimeout):
+			_ = p.cmd.Process.Kill()
+			<-p.exited
+		}
+	}
+
+	if err := os.RemoveAll(p.dir); err != nil {
+		return fmt.Errorf("failed to remove mailpit data directory: %w", err)
+	}
+
+	return nil
+}
+
+// lockedBuffer collects the output of the process.
+type lockedBuffer struct {
+	buf bytes.Buffer
+	mu  sync.Mutex
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.String()
+}
@@ -0,0 +1,234 @@
+package testing
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/CodeLieutenant/mailpitclient"
+)
+
+// fakeMailpitEnv makes the test binary act as a mailpit binary until
+// interrupted. It accepts connections on MP_SMTP_BIND_ADDR and serves the info
+// and send endpoints on MP_UI_BIND_ADDR, checking the htpasswd files and
+// using the TLS certificate like Mailpit does.
+const fakeMailpitEnv = "TEST_FAKE_MAILPIT"
+
+func init() {
+	switch os.Getenv(fakeMailpitEnv) {
+	case "":
+		return
+	case "exit":
+		os.Exit(3)
+	}
+
+	smtp, err := net.Listen("tcp", os.Getenv("MP_SMTP_BIND_ADDR")) //nolint:noctx // fake server
+	if err != nil {
+		os.Exit(1)
+	}
+	defer smtp.Close()
+
+	uiAuth := os.Getenv("MP_UI_AUTH_FILE")
+	sendAuth := os.Getenv("MP_SEND_API_AUTH_FILE")
+	if sendAuth == "" {
+		sendAuth = uiAuth
+	}
+
+	http.HandleFunc("/api/v1/info", func(w http.ResponseWriter, r *http.Request) {
+		if !fakeAuthorized(r, uiAuth) {
+			w.WriteHeader(http.StatusUnauthorized)
+
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(mailpitclient.ServerInfo{
+			Version:  os.Getenv(fakeMailpitEnv),
+			Database: os.Getenv("MP_DATABASE"),
+		})
+	})
+
+	http.HandleFunc("/api/v1/send", func(w http.ResponseWriter, r *http.Request) {
+		if !fakeAuthorized(r, sendAuth) {
+			w.WriteHeader(http.StatusUnauthorized)
+
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(mailpitclient.SendMessageResponse{ID: "sent"})
+	})
+
+	addr := os.Getenv("MP_UI_BIND_ADDR")
+	if cert := os.Getenv("MP_UI_TLS_CERT"); cert != "" {
+		err = http.ListenAndServeTLS(addr, cert, os.Getenv("MP_UI_TLS_KEY"), nil) //nolint:gosec // fake server
+	} else {
+		err = http.ListenAndServe(addr, nil) //nolint:gosec // fake server
+	}
+	if err != nil {
+		os.Exit(1)
+	}
+}
+
+// fakeAuthorized checks the request's basic auth against a one-line htpasswd
+// file, if any.
+func fakeAuthorized(r *http.Request, htpasswd string) bool {
+	if htpasswd == "" {
+		return true
+	}
+
+	data, err := os.ReadFile(htpasswd)
+	if err != nil {
+		return false
+	}
+
+	user, pass, ok := r.BasicAuth()
+	name, hash, _ := strings.Cut(strings.TrimSpace(string(data)), ":")
+
+	return ok && user == name && bcrypt.CompareHashAndPassword([]byte(hash), []byte(pass)) == nil
+}
+
+func TestMailpitOptions_ResolveBackend(t *testing.T) {
+	t.Parallel()
+
+	missing := filepath.Join(t.TempDir(), "mailpit")
+
+	tests := []struct {
+		name      string
+		opts      MailpitOptions
+		expected  Backend
+		noBackend bool
+	}{
+		{
+			name:     "binary",
+			opts:     MailpitOptions{Backend: BackendBinary, Binary: os.Args[0]},
+			expected: BackendBinary,
+		},
+		{
+			name:      "missing binary",
+			opts:      MailpitOptions{Backend: BackendBinary, Binary: missing},
+			noBackend: true,
+		},
+		{
+			name: "unknown backend",
+			opts: MailpitOptions{Backend: "podman"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			backend, err := tt.opts.resolveBackend(t.Context())
+
+			switch {
+			case tt.expected != "":
+				require.NoError(t, err)
+				require.Equal(t, tt.expected, backend)
+			case tt.noBackend:
+				require.ErrorIs(t, err, ErrNoBackend)
+			default:
+				require.Error(t, err)
+				require.NotErrorIs(t, err, ErrNoBackend)
+			}
+		})
+	}
+}
+
+func TestRunMailpit_Binary(t *testing.T) {
+	t.Parallel()
+
+	mp, err := RunMailpit(t.Context(),
+		WithBackend(BackendBinary),
+		WithBinary(os.Args[0]),
+		WithEnv(map[string]string{fakeMailpitEnv: "fake"}),
+		WithUIAuth("admin", "secret"),
+		WithSendAPIAuth("sender", "send-secret"),
+		WithGeneratedTLS(nil, false),
+		WithHTTPS(nil),
+		WithStartupTimeout(10*time.Second),
+	)
+	require.NoError(t, err)
+	require.NotNil(t, mp.TLSConfig())
+	require.FileExists(t, mp.options.TLSCert)
+	require.Equal(t, "https://"+net.JoinHostPort(mp.Host, mp.APIPort), mp.APIURL())
+
+	require.Equal(t, BackendBinary, mp.Backend)
+	require.Nil(t, mp.Container)
+	require.Equal(t, "127.0.0.1", mp.Host)
+
+	conn, err := net.DialTimeout("tcp", mp.SMTPAddr(), time.Second)
+	require.NoError(t, err)
+	require.NoError(t, conn.Close())
+
+	info, err := mp.Client.GetServerInfo(t.Context())
+	require.NoError(t, err)
+	require.Equal(t, "fake", info.Version)
+
+	send := &mailpitclient.SendMessageRequest{
+		From:    mailpitclient.Address{Address: "sender@example.com"},
+		To:      []mailpitclient.Address{{Address: "to@example.com"}},
+		Subject: "Hi",
+	}
+	_, err = mp.Client.SendMessage(t.Context(), send)
+	require.Error(t, err, "UI credentials are not accepted by the Send API")
+	sent, err := mp.SendClient.SendMessage(t.Context(), send)
+	require.NoError(t, err)
+	require.Equal(t, "sent", sent.ID)
+
+	dir := filepath.Dir(info.Database)
+	require.DirExists(t, dir)
+
+	require.NoError(t, mp.Terminate(t.Context()))
+	require.NoDirExists(t, dir)
+	require.NoFileExists(t, mp.options.TLSCert)
+}
+
+func TestRunMailpit_BinaryExits(t *testing.T) {
+	t.Parallel()
+
+	_, err := RunMailpit(t.Context(),
+		WithBackend(BackendBinary),
+		WithBinary(os.Args[0]),
+		WithEnv(map[string]string{fakeMailpitEnv: "exit"}),
+		WithStartupTimeout(time.Minute),
+	)
+	require.ErrorContains(t, err, "exited during startup")
+}
+
+func TestNewMailpit_SkipsWithoutBackend(t *testing.T) {
+	t.Parallel()
+
+	tb := &skipRecorder{TB: t}
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		NewMailpit(tb, WithBackend(BackendBinary), WithBinary(filepath.Join(t.TempDir(), "mailpit")))
+	}()
+	<-done
+
+	require.Contains(t, tb.skipped, ErrNoBackend.Error())
+}
+
+// skipRecorder records Skipf and ends the goroutine like testing.T does.
+type skipRecorder struct {
+	testing.TB
+
+	skipped string
+}
+
+func (r *skipRecorder) Skipf(format string, args ...any) {
+	r.skipped = fmt.Sprintf(format, args...)
+	runtime.Goexit()
+}
@@ -0,0 +1,269 @@
+package testing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+	"text/tabwriter"
+	"time"
+
+	"github.com/CodeLieutenant/mailpitclient"
+)
+
+const (
+	// DumpDirEnv enables DumpOnFailure for every GetTestSMTP call, writing
+	// the dumps below the given directory, e.g. TEST_SMTP_DUMP_DIR=artifacts.
+	DumpDirEnv = "TEST_SMTP_DUMP_DIR"
+
+	// dumpMessageLimit caps the messages a dump fetches.
+	dumpMessageLimit = 50
+	// dumpLogBodyLimit and dumpLogLines keep a dump to the test output
+	// readable; the artefacts directory gets everything.
+	dumpLogBodyLimit = 4 << 10
+	dumpLogLines     = 100
+	dumpTimeout      = 30 * time.Second
+	// dumpClockSkew allows for the container's clock being slightly behind.
+	dumpClockSkew = time.Second
+)
+
+// WithDumpOnFailure registers DumpOnFailure for the test. An empty dir writes
+// the dump to the test output.
+func WithDumpOnFailure(dir string) Option {
+	return func(o *TestSMTPOptions) {
+		o.DumpOnFailure = true
+		o.DumpDir = dir
+	}
+}
+
+// DumpOnFailure dumps the mailbox and Mailpit's logs if the test fails:
+// a summary table with web UI links, and each message's headers and text
+// body, for messages received after this call. With dir set, the dump is
+// written to dir/<test name>, including every message's .eml source and the
+// full logs; otherwise a shortened dump goes to the test output.
+//
+// Pooled containers are shared, so the dump of a parallel test may include
+// messages of tests running at the same time.
+func (ts *TestSMTP) DumpOnFailure(tb testing.TB, dir string) {
+	tb.Helper()
+
+	since := time.Now().Add(-dumpClockSkew)
+
+	tb.Cleanup(func() {
+		if !tb.Failed() {
+			return
+		}
+
+		// The test's context is canceled by now.
+		ctx, cancel := context.WithTimeout(context.Background(), dumpTimeout)
+		defer cancel()
+
+		if err := ts.dump(ctx, tb, dir, since); err != nil {
+			tb.Logf("Failed to dump mailbox: %v", err)
+		}
+	})
+}
+
+// dumpedMessage is a message fetched for a dump.
+type dumpedMessage struct {
+	headers map[string][]string
+	summary mailpitclient.MessageSummary
+	text    string
+	raw     string
+	link    string
+}
+
+func (ts *TestSMTP) dump(ctx context.Context, tb testing.TB, dir string, since time.Time) error {
+	messages, fetchErr := ts.dumpMessages(ctx, since)
+
+	var logs string
+	if ts.Mailpit != nil {
+		var err error
+		if logs, err = ts.Mailpit.Logs(ctx); err != nil {
+			fetchErr = errors.Join(fetchErr, err)
+		}
+	}
+
+	if dir == "" {
+		tb.Logf("Mailpit dump after failure of %s:\n%s", tb.Name(), formatDump(messages, logs, true))
+
+		return fetchErr
+	}
+
+	path := filepath.Join(dir, dumpDirName(tb.Name()))
+	if err := writeDump(path, messages, logs); err != nil {
+		return errors.Join(fetchErr, err)
+	}
+
+	tb.Logf("Mailpit dump after failure written to %s:\n%s", path, formatSummary(messages))
+
+	return fetchErr
+}
+
+// dumpMessages fetches the messages received since since, newest first.
+func (ts *TestSMTP) dumpMessages(ctx context.Context, since time.Time) ([]dumpedMessage, error) {
+	list, err := ts.MailpitClient.ListMessages(ctx, &mailpitclient.ListOptions{Limit: dumpMessageLimit})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list messages: %w", err)
+	}
+
+	// Reading a message would mark it as read.
+	ctx = mailpitclient.PreserveReadState(ctx)
+
+	var (
+		messages []dumpedMessage
+		errs     []error
+	)
+
+	for _, summary := range list.Messages {
+		if summary.Created.Before(since) {
+			continue
+		}
+
+		m := dumpedMessage{summary: summary}
+		if ts.Mailpit != nil {
+			m.link = ts.Mailpit.MessageURL(summary.ID)
+		}
+
+		if msg, err := ts.MailpitClient.GetMessage(ctx, summary.ID); err != nil {
+			errs = append(errs, err)
+		} else {
+			m.text = msg.Text
+		}
+
+		if m.headers, err = ts.MailpitClient.GetMessageHeaders(ctx, summary.ID); err != nil {
+			errs = append(errs, err)
+		}
+
+		if m.raw, err = ts.MailpitClient.GetMessageRaw(ctx, summary.ID); err != nil {
+			errs = append(errs, err)
+		}
+
+		messages = append(messages, m)
+	}
+
+	return messages, errors.Join(errs...)
+}
+
+// formatSummary renders a table of the messages.
+func formatSummary(messages []dumpedMessage) string {
+	if len(messages) == 0 {
+		return "No messages received during the test.\n"
+	}
+
+	var b strings.Builder
+
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "#\tRECEIVED\tFROM\tTO\tSUBJECT\tLINK")
+	for i, m := range messages {
+		to := make([]string, 0, len(m.summary.To))
+		for _, a := range m.summary.To {
+			to = append(to, a.Address)
+		}
+
+		_, _ = fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\n",
+			i+1, m.summary.Created.Format(time.RFC3339), m.summary.From.Address, strings.Join(to, ", "), m.summary.Subject, m.link)
+	}
+	_ = w.Flush()
+
+	return b.String()
+}
+
+// formatMessage renders a message's headers and text body, truncated for
+// the test output when short is set.
+func formatMessage(w io.Writer, i int, m dumpedMessage, short bool) {
+	_, _ = fmt.Fprintf(w, "--- Message %d: %s (%s)\n", i+1, m.summary.Subject, m.summary.ID)
+
+	names := make([]string, 0, len(m.headers))
+	for name := range m.headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		for _, v := range m.headers[name] {
+			_, _ = fmt.Fprintf(w, "%s: %s\n", name, v)
+		}
+	}
+
+	text := m.text
+	if short && len(text) > dumpLogBodyLimit {
+		text = text[:dumpLogBodyLimit] + "\n[truncated]"
+	}
+
+	_, _ = fmt.Fprintf(w, "\n%s\n", strings.TrimRight(text, "\n"))
+}
+
+// formatDump renders the whole dump, keeping the last lines of the logs when
+// short is set.
+func formatDump(messages []dumpedMessage, logs string, short bool) string {
+	var b strings.Builder
+
+	b.WriteString(formatSummary(messages))
+	for i, m := range messages {
+		b.WriteString("\n")
+		formatMessage(&b, i, m, short)
+	}
+
+	if logs != "" {
+		if short {
+			lines := strings.Split(strings.TrimRight(logs, "\n"), "\n")
+			if len(lines) > dumpLogLines {
+				lines = lines[len(lines)-dumpLogLines:]
+			}
+			logs = strings.Join(lines, "\n") + "\n"
+		}
+
+		b.WriteString("\n--- Mailpit logs\n")
+		b.WriteString(logs)
+	}
+
+	return b.String()
+}
+
+// writeDump writes summary.txt, <n>-<id>.txt and <n>-<id>.eml per message,
+// and mailpit.log to dir.
+func writeDump(dir string, messages []dumpedMessage, logs string) error {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return fmt.Errorf("failed to create dump directory: %w", err)
+	}
+
+	files := map[string]string{
+		"summary.txt": formatSummary(messages),
+		"mailpit.log": logs,
+	}
+
+	for i, m := range messages {
+		var b strings.Builder
+		if m.link != "" {
+			_, _ = fmt.Fprintf(&b, "Link: %s\n", m.link)
+		}
+		formatMessage(&b, i, m, false)
+
+		base := fmt.Sprintf("%02d-%s", i+1, dumpDirName(m.summary.ID))
+		files[base+".txt"] = b.String()
+		files[base+".eml"] = m.raw
+	}
+
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			return fmt.Errorf("failed to write dump: %w", err)
+		}
+	}
+
+	return nil
+}
+
+var unsafePathChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// dumpDirName turns a test name such as "TestSend/with_attachment" into a
+// file name.
+func dumpDirName(name string) string {
+	return unsafePathChars.ReplaceAllString(name, "_")
+}
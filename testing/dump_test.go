@@ -0,0 +1,175 @@
+package testing
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/CodeLieutenant/mailpitclient"
+)
+
+// dumpRecorder captures the cleanups and logs of DumpOnFailure.
+type dumpRecorder struct {
+	testing.TB
+
+	cleanups []func()
+	logs     []string
+	failed   bool
+}
+
+func (r *dumpRecorder) Cleanup(f func()) { r.cleanups = append(r.cleanups, f) }
+func (r *dumpRecorder) Failed() bool     { return r.failed }
+func (r *dumpRecorder) Logf(format string, args ...any) {
+	r.logs = append(r.logs, fmt.Sprintf(format, args...))
+}
+func (r *dumpRecorder) runCleanups() {
+	for _, f := range r.cleanups {
+		f()
+	}
+}
+func (r *dumpRecorder) output() string                    { return strings.Join(r.logs, "\n") }
+func (r *dumpRecorder) Name() string                      { return "TestSignup/welcome mail" }
+func (r *dumpRecorder) Errorf(format string, args ...any) { r.Logf(format, args...) }
+
+// newDumpTestSMTP serves a mailbox with a message received now and one
+// received an hour ago.
+func newDumpTestSMTP(t *testing.T) *TestSMTP {
+	t.Helper()
+
+	now := time.Now().UTC()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/messages", func(w http.ResponseWriter, _ *http.Request) {
+		_ = json.NewEncoder(w).Encode(mailpitclient.MessagesResponse{
+			Total: 2,
+			Messages: []mailpitclient.MessageSummary{
+				{
+					ID:      "new",
+					Created: now,
+					From:    mailpitclient.Address{Address: "app@example.com"},
+					To:      []mailpitclient.Address{{Address: "alice@example.com"}},
+					Subject: "Welcome",
+				},
+				{ID: "old", Created: now.Add(-time.Hour), Subject: "Earlier test"},
+			},
+		})
+	})
+	mux.HandleFunc("/api/v1/message/new", func(w http.ResponseWriter, _ *http.Request) {
+		_ = json.NewEncoder(w).Encode(mailpitclient.Message{ID: "new", Text: "Hello Alice\n"})
+	})
+	mux.HandleFunc("/api/v1/message/new/headers", func(w http.ResponseWriter, _ *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string][]string{"Subject": {"Welcome"}, "From": {"app@example.com"}})
+	})
+	mux.HandleFunc("/view/new.raw", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("Subject: Welcome\r\n\r\nHello Alice\r\n"))
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	u, err := url.Parse(server.URL)
+	require.NoError(t, err)
+	host, port, err := net.SplitHostPort(u.Host)
+	require.NoError(t, err)
+
+	client, err := mailpitclient.NewClient(&mailpitclient.Config{BaseURL: server.URL, APIPath: "/api/v1"})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = client.Close() })
+
+	logs := &lockedBuffer{}
+	_, _ = logs.Write([]byte("INFO starting\nERRO smtpd: rejected\n"))
+
+	mp := &Mailpit{Host: host, APIPort: port, process: &mailpitProcess{logs: logs}}
+
+	return &TestSMTP{Mailpit: mp, MailpitClient: client}
+}
+
+func TestTestSMTP_DumpOnFailure(t *testing.T) {
+	t.Parallel()
+
+	t.Run("passing test", func(t *testing.T) {
+		t.Parallel()
+
+		ts := newDumpTestSMTP(t)
+		rec := &dumpRecorder{TB: t}
+		ts.DumpOnFailure(rec, "")
+		rec.runCleanups()
+
+		require.Empty(t, rec.logs)
+	})
+
+	t.Run("test output", func(t *testing.T) {
+		t.Parallel()
+
+		ts := newDumpTestSMTP(t)
+		rec := &dumpRecorder{TB: t, failed: true}
+		ts.DumpOnFailure(rec, "")
+		rec.runCleanups()
+
+		out := rec.output()
+		require.Contains(t, out, "Mailpit dump after failure of TestSignup/welcome mail")
+		require.Contains(t, out, "alice@example.com")
+		require.Contains(t, out, ts.Mailpit.APIURL()+"/view/new")
+		require.Contains(t, out, "Subject: Welcome")
+		require.Contains(t, out, "Hello Alice")
+		require.Contains(t, out, "ERRO smtpd: rejected")
+		require.NotContains(t, out, "Earlier test", "messages from before the test are left out")
+		require.NotContains(t, out, "Failed to dump")
+	})
+
+	t.Run("artefacts directory", func(t *testing.T) {
+		t.Parallel()
+
+		ts := newDumpTestSMTP(t)
+		dir := t.TempDir()
+		rec := &dumpRecorder{TB: t, failed: true}
+		ts.DumpOnFailure(rec, dir)
+		rec.runCleanups()
+
+		path := filepath.Join(dir, "TestSignup_welcome_mail")
+		require.Contains(t, rec.output(), "written to "+path)
+
+		eml, err := os.ReadFile(filepath.Join(path, "01-new.eml"))
+		require.NoError(t, err)
+		require.Equal(t, "Subject: Welcome\r\n\r\nHello Alice\r\n", string(eml))
+
+		text, err := os.ReadFile(filepath.Join(path, "01-new.txt"))
+		require.NoError(t, err)
+		require.Contains(t, string(text), "Link: "+ts.Mailpit.APIURL()+"/view/new")
+		require.Contains(t, string(text), "From: app@example.com")
+
+		logs, err := os.ReadFile(filepath.Join(path, "mailpit.log"))
+		require.NoError(t, err)
+		require.Equal(t, "INFO starting\nERRO smtpd: rejected\n", string(logs))
+
+		require.FileExists(t, filepath.Join(path, "summary.txt"))
+		require.NoFileExists(t, filepath.Join(path, "02-old.eml"))
+	})
+}
+
+func TestFormatDump_TruncatesForTestOutput(t *testing.T) {
+	t.Parallel()
+
+	logs := strings.Repeat("line\n", dumpLogLines+10) + "last\n"
+	messages := []dumpedMessage{{summary: mailpitclient.MessageSummary{ID: "1"}, text: strings.Repeat("x", dumpLogBodyLimit+1)}}
+
+	short := formatDump(messages, logs, true)
+	require.Contains(t, short, "[truncated]")
+	require.Equal(t, dumpLogLines, strings.Count(short[strings.Index(short, "--- Mailpit logs"):], "\n")-1)
+
+	full := formatDump(messages, logs, false)
+	require.NotContains(t, full, "[truncated]")
+	require.Contains(t, full, logs)
+
+	require.Equal(t, "No messages received during the test.\n", formatSummary(nil))
+}
@@ -0,0 +1,745 @@
+package testing
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"math"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+
+	"github.com/CodeLieutenant/mailpitclient"
+)
+
+const (
+	// DefaultMailpitImage is the image RunMailpit starts, without a tag.
+	DefaultMailpitImage = "axllent/mailpit"
+	// DefaultMailpitVersion is the image tag RunMailpit starts by default.
+	DefaultMailpitVersion = "latest"
+
+	// DefaultMailpitStartupTimeout is how long RunMailpit waits for Mailpit
+	// to accept connections.
+	DefaultMailpitStartupTimeout = 30 * time.Second
+
+	mailpitSMTPPort = "1025/tcp"
+	mailpitHTTPPort = "8025/tcp"
+
+	mailpitCertPath = "/certs/smtp.crt"
+	mailpitKeyPath  = "/certs/smtp.key"
+
+	// dockerHostAlias lets the container reach servers on the host, such as
+	// an SMTPSink relay target or a webhook receiver, on every platform.
+	dockerHostAlias = "host.docker.internal"
+)
+
+var errImplicitTLSWithoutCert = errors.New("mailpit TLS: implicit TLS needs a certificate")
+
+// RelayConfig configures the SMTP server Mailpit releases messages to.
+type RelayConfig struct {
+	Host string
+	// Username and Password enable AUTH PLAIN.
+	Username string
+	Password string
+	// AllowedRecipients and BlockedRecipients are optional regular expressions.
+	AllowedRecipients string
+	BlockedRecipients string
+	ReturnPath        string
+	OverrideFrom      string
+	Port              int
+	StartTLS          bool
+	// AllowInsecure accepts invalid or self-signed certificates.
+	AllowInsecure bool
+	// All relays every received message automatically.
+	All bool
+}
+
+// Env returns the Mailpit environment variables for the relay configuration.
+func (rc RelayConfig) Env() map[string]string {
+	env := map[string]string{
+		"MP_SMTP_RELAY_HOST":           rc.Host,
+		"MP_SMTP_RELAY_PORT":           strconv.Itoa(rc.Port),
+		"MP_SMTP_RELAY_STARTTLS":       strconv.FormatBool(rc.StartTLS),
+		"MP_SMTP_RELAY_ALLOW_INSECURE": strconv.FormatBool(rc.AllowInsecure),
+		"MP_SMTP_RELAY_AUTH":           "none",
+	}
+
+	if rc.Username != "" {
+		env["MP_SMTP_RELAY_AUTH"] = "plain"
+		env["MP_SMTP_RELAY_USERNAME"] = rc.Username
+		env["MP_SMTP_RELAY_PASSWORD"] = rc.Password
+	}
+
+	optional := map[string]string{
+		"MP_SMTP_RELAY_ALLOWED_RECIPIENTS": rc.AllowedRecipients,
+		"MP_SMTP_RELAY_BLOCKED_RECIPIENTS": rc.BlockedRecipients,
+		"MP_SMTP_RELAY_RETURN_PATH":        rc.ReturnPath,
+		"MP_SMTP_RELAY_OVERRIDE_FROM":      rc.OverrideFrom,
+	}
+	for k, v := range optional {
+		if v != "" {
+			env[k] = v
+		}
+	}
+
+	if rc.All {
+		env["MP_SMTP_RELAY_ALL"] = "true"
+	}
+
+	return env
+}
+
+// MailpitOptions configures a Mailpit instance started with RunMailpit.
+type MailpitOptions struct {
+	// ClientConfig is the base configuration of Mailpit.Client. BaseURL is
+	// always set to the instance, and the UI credentials are used unless
+	// Username is set.
+	ClientConfig *mailpitclient.Config
+	// Env holds raw MP_* variables. They are applied before the typed
+	// options, which take precedence.
+	Env   map[string]string
+	Chaos *mailpitclient.ChaosTriggers
+	Relay *RelayConfig
+	// CA issues the certificates of WithGeneratedTLS and WithHTTPS; nil
+	// generates a new one.
+	CA    *TestCA
+	Image string
+	// Backend selects Docker or a local binary; see WithBackend.
+	Backend      Backend
+	Binary       string
+	SMTPUsername string
+	SMTPPassword string
+	UIUsername   string
+	UIPassword   string
+	SendUsername string
+	SendPassword string
+	// TLSCert and TLSKey are host paths of the SMTP certificate and key.
+	TLSCert        string
+	TLSKey         string
+	SpamAssassin   string
+	WebhookURL     string
+	MaxAge         time.Duration
+	StartupTimeout time.Duration
+	MaxMessages    int
+	RequireTLS     bool
+	ImplicitTLS    bool
+	GenerateTLS    bool
+	HTTPS          bool
+	EnableChaos    bool
+
+	// Files written by prepare.
+	uiAuthFile   string
+	sendAuthFile string
+	uiCert       string
+	uiKey        string
+}
+
+// MailpitOption configures RunMailpit.
+type MailpitOption func(*MailpitOptions)
+
+// WithImage sets the Mailpit image, including its tag.
+func WithImage(image string) MailpitOption {
+	return func(opts *MailpitOptions) {
+		opts.Image = image
+	}
+}
+
+// WithVersion runs the given tag of DefaultMailpitImage, e.g. "v1.27".
+func WithVersion(version string) MailpitOption {
+	return func(opts *MailpitOptions) {
+		opts.Image = DefaultMailpitImage + ":" + version
+	}
+}
+
+// WithEnv adds raw MP_* environment variables, for settings without a typed
+// option.
+func WithEnv(env map[string]string) MailpitOption {
+	return func(opts *MailpitOptions) {
+		if opts.Env == nil {
+			opts.Env = make(map[string]string, len(env))
+		}
+		maps.Copy(opts.Env, env)
+	}
+}
+
+// WithClientConfig sets the base configuration of Mailpit.Client.
+func WithClientConfig(config *mailpitclient.Config) MailpitOption {
+	return func(opts *MailpitOptions) {
+		opts.ClientConfig = config
+	}
+}
+
+// WithSMTPAuth requires SMTP clients to authenticate with the given
+// credentials. By default any credentials are accepted.
+func WithSMTPAuth(username, password string) MailpitOption {
+	return func(opts *MailpitOptions) {
+		opts.SMTPUsername = username
+		opts.SMTPPassword = password
+	}
+}
+
+// WithUIAuth protects the web UI and API with basic authentication, using a
+// generated bcrypt htpasswd file. The client is configured with the
+// credentials.
+func WithUIAuth(username, password string) MailpitOption {
+	return func(opts *MailpitOptions) {
+		opts.UIUsername = username
+		opts.UIPassword = password
+	}
+}
+
+// WithSMTPTLS enables STARTTLS with the certificate and key at the given host
+// paths. If required is true, Mailpit rejects mail sent without STARTTLS.
+func WithSMTPTLS(certFile, keyFile string, required bool) MailpitOption {
+	return func(opts *MailpitOptions) {
+		opts.TLSCert = certFile
+		opts.TLSKey = keyFile
+		opts.RequireTLS = required
+	}
+}
+
+// WithGeneratedTLS enables STARTTLS with a server certificate issued by ca
+// for the loopback addresses and the Docker host. A nil ca generates a new
+// one. Mailpit.TLSConfig trusts the CA, so clients verify the certificate.
+func WithGeneratedTLS(ca *TestCA, required bool) MailpitOption {
+	return func(opts *MailpitOptions) {
+		opts.GenerateTLS = true
+		opts.CA = ca
+		opts.RequireTLS = required
+	}
+}
+
+// WithImplicitTLS serves SMTP over TLS from the first byte instead of with
+// STARTTLS. It needs WithSMTPTLS or WithGeneratedTLS.
+func WithImplicitTLS() MailpitOption {
+	return func(opts *MailpitOptions) {
+		opts.ImplicitTLS = true
+	}
+}
+
+// WithChaos enables the chaos API. Non-nil triggers are set at startup.
+func WithChaos(triggers *mailpitclient.ChaosTriggers) MailpitOption {
+	return func(opts *MailpitOptions) {
+		opts.EnableChaos = true
+		opts.Chaos = triggers
+	}
+}
+
+// WithSpamAssassin enables SpamAssassin checks against the spamd server at
+// addr ("host:port"), or "postmark" for the Postmark API.
+func WithSpamAssassin(addr string) MailpitOption {
+	return func(opts *MailpitOptions) {
+		opts.SpamAssassin = addr
+	}
+}
+
+// WithRelay configures the SMTP server messages are released to. Use
+// SMTPSink.RelayConfig to relay to an in-process sink.
+func WithRelay(relay RelayConfig) MailpitOption {
+	return func(opts *MailpitOptions) {
+		opts.Relay = &relay
+	}
+}
+
+// WithWebhook makes Mailpit post a summary of every received message to url.
+// Use host.docker.internal to reach a server on the host from Docker, or
+// 127.0.0.1 with BackendBinary.
+func WithWebhook(url string) MailpitOption {
+	return func(opts *MailpitOptions) {
+		opts.WebhookURL = url
+	}
+}
+
+// WithMaxMessages limits the number of stored messages; older messages are
+// deleted.
+func WithMaxMessages(limit int) MailpitOption {
+	return func(opts *MailpitOptions) {
+		opts.MaxMessages = limit
+	}
+}
+
+// WithMaxAge deletes messages older than maxAge, rounded up to whole hours.
+func WithMaxAge(maxAge time.Duration) MailpitOption {
+	return func(opts *MailpitOptions) {
+		opts.MaxAge = maxAge
+	}
+}
+
+// WithStartupTimeout sets how long to wait for Mailpit to start.
+func WithStartupTimeout(timeout time.Duration) MailpitOption {
+	return func(opts *MailpitOptions) {
+		opts.StartupTimeout = timeout
+	}
+}
+
+// env returns the container environment and the files to copy into it.
+//
+//nolint:gocyclo // one branch per option
+func (o *MailpitOptions) env() (map[string]string, []testcontainers.ContainerFile, error) {
+	env := map[string]string{
+		"MP_SMTP_AUTH_ACCEPT_ANY":     "1",
+		"MP_SMTP_AUTH_ALLOW_INSECURE": "1",
+	}
+	maps.Copy(env, o.Env)
+
+	var files []testcontainers.ContainerFile
+
+	if o.SMTPUsername != "" {
+		delete(env, "MP_SMTP_AUTH_ACCEPT_ANY")
+		env["MP_SMTP_AUTH"] = o.SMTPUsername + ":" + o.SMTPPassword
+	}
+
+	// RunMailpit writes the credentials to htpasswd files with prepare.
+	switch {
+	case o.uiAuthFile != "":
+		files = append(files, testcontainers.ContainerFile{HostFilePath: o.uiAuthFile, ContainerFilePath: mailpitUIAuthPath, FileMode: 0o644})
+		env["MP_UI_AUTH_FILE"] = mailpitUIAuthPath
+	case o.UIUsername != "":
+		env["MP_UI_AUTH"] = o.UIUsername + ":" + o.UIPassword
+	}
+
+	switch {
+	case o.sendAuthFile != "":
+		files = append(files, testcontainers.ContainerFile{HostFilePath: o.sendAuthFile, ContainerFilePath: mailpitSendAuthPath, FileMode: 0o644})
+		env["MP_SEND_API_AUTH_FILE"] = mailpitSendAuthPath
+	case o.SendUsername != "":
+		env["MP_SEND_API_AUTH"] = o.SendUsername + ":" + o.SendPassword
+	}
+
+	if o.uiCert != "" {
+		files = append(files,
+			testcontainers.ContainerFile{HostFilePath: o.uiCert, ContainerFilePath: mailpitUICertPath, FileMode: 0o644},
+			testcontainers.ContainerFile{HostFilePath: o.uiKey, ContainerFilePath: mailpitUIKeyPath, FileMode: 0o644},
+		)
+		env["MP_UI_TLS_CERT"] = mailpitUICertPath
+		env["MP_UI_TLS_KEY"] = mailpitUIKeyPath
+	}
+
+	if o.TLSCert != "" || o.TLSKey != "" {
+		for _, path := range []string{o.TLSCert, o.TLSKey} {
+			if _, err := os.Stat(path); err != nil {
+				return nil, nil, fmt.Errorf("mailpit TLS: %w", err)
+			}
+		}
+
+		files = append(files,
+			testcontainers.ContainerFile{HostFilePath: o.TLSCert, ContainerFilePath: mailpitCertPath, FileMode: 0o644},
+			testcontainers.ContainerFile{HostFilePath: o.TLSKey, ContainerFilePath: mailpitKeyPath, FileMode: 0o644},
+		)
+		env["MP_SMTP_TLS_CERT"] = mailpitCertPath
+		env["MP_SMTP_TLS_KEY"] = mailpitKeyPath
+		env["MP_SMTP_REQUIRE_STARTTLS"] = strconv.FormatBool(o.RequireTLS)
+		if o.ImplicitTLS {
+			delete(env, "MP_SMTP_REQUIRE_STARTTLS")
+			env["MP_SMTP_REQUIRE_TLS"] = "true"
+		}
+		if o.RequireTLS || o.ImplicitTLS {
+			delete(env, "MP_SMTP_AUTH_ALLOW_INSECURE")
+		}
+	} else if o.ImplicitTLS {
+		return nil, nil, errImplicitTLSWithoutCert
+	}
+
+	if o.EnableChaos {
+		env["MP_ENABLE_CHAOS"] = "true"
+	}
+
+	if o.Chaos != nil {
+		if err := o.Chaos.Validate(); err != nil {
+			return nil, nil, err
+		}
+		if triggers := chaosTriggersEnv(o.Chaos); triggers != "" {
+			env["MP_CHAOS_TRIGGERS"] = triggers
+		}
+	}
+
+	if o.SpamAssassin != "" {
+		env["MP_ENABLE_SPAMASSASSIN"] = o.SpamAssassin
+	}
+
+	if o.Relay != nil {
+		maps.Copy(env, o.Relay.Env())
+	}
+
+	if o.WebhookURL != "" {
+		env["MP_WEBHOOK_URL"] = o.WebhookURL
+	}
+
+	if o.MaxMessages != 0 {
+		env["MP_MAX_MESSAGES"] = strconv.Itoa(o.MaxMessages)
+	}
+
+	if o.MaxAge > 0 {
+		env["MP_MAX_AGE"] = strconv.Itoa(int(math.Ceil(o.MaxAge.Hours()))) + "h"
+	}
+
+	return env, files, nil
+}
+
+// chaosTriggersEnv formats triggers as MP_CHAOS_TRIGGERS, e.g.
+// "Sender:451:50,Recipient:550:10". Disabled triggers are left out.
+func chaosTriggersEnv(triggers *mailpitclient.ChaosTriggers) string {
+	named := []struct {
+		name    string
+		trigger mailpitclient.ChaosTrigger
+	}{
+		{"Sender", triggers.Sender},
+		{"Recipient", triggers.Recipient},
+		{"Authentication", triggers.Authentication},
+	}
+
+	parts := make([]string, 0, len(named))
+	for _, n := range named {
+		if !n.trigger.Enabled() {
+			continue
+		}
+
+		code := n.trigger.ErrorCode
+		if code == 0 {
+			code = mailpitclient.DefaultChaosErrorCode
+		}
+		parts = append(parts, fmt.Sprintf("%s:%d:%d", n.name, code, n.trigger.Probability))
+	}
+
+	return strings.Join(parts, ",")
+}
+
+// request builds the container request for the options.
+func (o *MailpitOptions) request() (testcontainers.ContainerRequest, error) {
+	env, files, err := o.env()
+	if err != nil {
+		return testcontainers.ContainerRequest{}, err
+	}
+
+	ready := wait.ForHTTP("/api/v1/info").WithPort(mailpitHTTPPort).WithStartupTimeout(o.StartupTimeout)
+	if o.UIUsername != "" {
+		ready = ready.WithBasicAuth(o.UIUsername, o.UIPassword)
+	}
+	if o.HTTPS {
+		ready = ready.WithTLS(true, &tls.Config{RootCAs: o.CA.CertPool(), MinVersion: tls.VersionTLS12})
+	}
+
+	return testcontainers.ContainerRequest{
+		Image:        o.Image,
+		ExposedPorts: []string{mailpitSMTPPort, mailpitHTTPPort},
+		Env:          env,
+		Files:        files,
+		WaitingFor: wait.ForAll(
+			wait.ForListeningPort(mailpitSMTPPort),
+			wait.ForListeningPort(mailpitHTTPPort),
+			ready,
+		).WithDeadline(o.StartupTimeout),
+		HostConfigModifier: func(hc *container.HostConfig) {
+			hc.ExtraHosts = append(hc.ExtraHosts, dockerHostAlias+":host-gateway")
+		},
+	}, nil
+}
+
+// Mailpit is a running Mailpit instance started with RunMailpit.
+type Mailpit struct {
+	// Container is nil when Backend is BackendBinary.
+	Container testcontainers.Container
+	// Client is connected to the instance's API.
+	Client mailpitclient.Client
+	// SendClient uses the Send API credentials of WithSendAPIAuth; without
+	// them it is Client.
+	SendClient mailpitclient.Client
+	process    *mailpitProcess
+	Backend    Backend
+	fileDir    string
+	Host       string
+	SMTPPort   string
+	APIPort    string
+	options    MailpitOptions
+}
+
+// RunMailpit starts Mailpit and returns once it accepts SMTP and API
+// connections. Unlike GetTestSMTP it needs no testing.TB, so it can be used
+// from TestMain, benchmarks or development tools. It returns an error
+// matching ErrNoBackend when neither Docker nor a mailpit binary is
+// available. The caller must call Terminate.
+func RunMailpit(ctx context.Context, opts ...MailpitOption) (*Mailpit, error) {
+	options := MailpitOptions{
+		Image:          DefaultMailpitImage + ":" + DefaultMailpitVersion,
+		StartupTimeout: DefaultMailpitStartupTimeout,
+	}
+
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	backend, err := options.resolveBackend(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	fileDir, err := options.prepare()
+	if err != nil {
+		return nil, err
+	}
+
+	var mp *Mailpit
+	if backend == BackendBinary {
+		mp, err = runBinary(ctx, options)
+	} else {
+		mp, err = runContainer(ctx, options)
+	}
+
+	if err != nil {
+		if fileDir != "" {
+			_ = os.RemoveAll(fileDir)
+		}
+
+		return nil, err
+	}
+
+	mp.fileDir = fileDir
+
+	return mp, nil
+}
+
+// runContainer starts the Mailpit image with testcontainers.
+func runContainer(ctx context.Context, options MailpitOptions) (*Mailpit, error) {
+	req, err := options.request()
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
+		ContainerRequest: req,
+		Started:          true,
+	})
+	if err != nil {
+		if c != nil {
+			_ = c.Terminate(context.WithoutCancel(ctx))
+		}
+
+		return nil, fmt.Errorf("failed to start mailpit container: %w", err)
+	}
+
+	mp, err := connectMailpit(ctx, c, options)
+	if err != nil {
+		_ = c.Terminate(context.WithoutCancel(ctx))
+
+		return nil, err
+	}
+
+	return mp, nil
+}
+
+// NewMailpit starts a Mailpit instance that is terminated when the test
+// finishes, skipping the test when no backend is available. Unlike
+// GetTestSMTP, the instance is not shared with other tests.
+func NewMailpit(tb testing.TB, opts ...MailpitOption) *Mailpit {
+	tb.Helper()
+
+	mp, err := RunMailpit(tb.Context(), opts...)
+	if errors.Is(err, ErrNoBackend) {
+		tb.Skipf("Skipping: %v", err)
+
+		return nil
+	}
+	if err != nil {
+		tb.Fatalf("Failed to start mailpit: %v", err)
+
+		return nil
+	}
+
+	tb.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		if err := mp.Terminate(ctx); err != nil {
+			tb.Errorf("Failed to terminate mailpit: %v", err)
+		}
+	})
+
+	return mp
+}
+
+func connectMailpit(ctx context.Context, c testcontainers.Container, options MailpitOptions) (*Mailpit, error) {
+	host, err := c.Host(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get container host: %w", err)
+	}
+
+	smtpPort, err := c.MappedPort(ctx, mailpitSMTPPort)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get SMTP port: %w", err)
+	}
+
+	apiPort, err := c.MappedPort(ctx, mailpitHTTPPort)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get API port: %w", err)
+	}
+
+	mp := &Mailpit{
+		Container: c,
+		Backend:   BackendDocker,
+		Host:      host,
+		SMTPPort:  smtpPort.Port(),
+		APIPort:   apiPort.Port(),
+		options:   options,
+	}
+
+	if err = mp.connect(); err != nil {
+		return nil, err
+	}
+
+	return mp, nil
+}
+
+// connect creates Client and SendClient.
+func (m *Mailpit) connect() error {
+	var err error
+
+	m.Client, err = mailpitclient.NewClient(m.ClientConfig(m.options.ClientConfig))
+	if err != nil {
+		return fmt.Errorf("failed to create mailpit client: %w", err)
+	}
+
+	m.SendClient = m.Client
+	if m.options.SendUsername == "" {
+		return nil
+	}
+
+	config := m.ClientConfig(m.options.ClientConfig)
+	config.Username = m.options.SendUsername
+	config.Password = m.options.SendPassword
+
+	m.SendClient, err = mailpitclient.NewClient(config)
+	if err != nil {
+		_ = m.Client.Close()
+
+		return fmt.Errorf("failed to create mailpit send client: %w", err)
+	}
+
+	return nil
+}
+
+// close closes Client and SendClient.
+func (m *Mailpit) close() error {
+	err := m.Client.Close()
+	if m.SendClient != m.Client {
+		err = errors.Join(err, m.SendClient.Close())
+	}
+
+	return err
+}
+
+// SMTPAddr returns the host:port of the SMTP server.
+func (m *Mailpit) SMTPAddr() string {
+	return net.JoinHostPort(m.Host, m.SMTPPort)
+}
+
+// APIURL returns the base URL of the web UI and API.
+func (m *Mailpit) APIURL() string {
+	scheme := "http"
+	if m.options.HTTPS {
+		scheme = "https"
+	}
+
+	return scheme + "://" + net.JoinHostPort(m.Host, m.APIPort)
+}
+
+// MessageURL returns the web UI link of a message.
+func (m *Mailpit) MessageURL(id string) string {
+	webroot := strings.Trim(m.options.Env["MP_WEBROOT"], "/")
+	if webroot != "" {
+		webroot = "/" + webroot
+	}
+
+	return m.APIURL() + webroot + "/view/" + url.PathEscape(id)
+}
+
+// Logs returns the output of the container or process so far.
+func (m *Mailpit) Logs(ctx context.Context) (string, error) {
+	if m.process != nil {
+		return m.process.logs.String(), nil
+	}
+
+	r, err := m.Container.Logs(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to get mailpit logs: %w", err)
+	}
+	defer r.Close()
+
+	logs, err := io.ReadAll(r)
+	if err != nil {
+		return "", fmt.Errorf("failed to read mailpit logs: %w", err)
+	}
+
+	return string(logs), nil
+}
+
+// SMTPConfig returns the SMTP connection details, including the credentials
+// set with WithSMTPAuth.
+func (m *Mailpit) SMTPConfig() SMTPConfig {
+	encryption := "none"
+	switch {
+	case m.options.ImplicitTLS:
+		encryption = "tls"
+	case m.options.TLSCert != "":
+		encryption = "starttls"
+	}
+
+	port, _ := strconv.ParseUint(m.SMTPPort, 10, 16)
+
+	return SMTPConfig{
+		Host:       m.Host,
+		Port:       uint16(port),
+		Username:   m.options.SMTPUsername,
+		Password:   m.options.SMTPPassword,
+		AuthType:   "PLAIN",
+		Encryption: encryption,
+	}
+}
+
+// CertPool returns the pool trusting the generated CA, or nil unless
+// WithGeneratedTLS was used.
+func (m *Mailpit) CertPool() *x509.CertPool {
+	if m.options.CA == nil {
+		return nil
+	}
+
+	return m.options.CA.CertPool()
+}
+
+// TLSConfig returns a client configuration that verifies the SMTP server's
+// generated certificate for Host, or nil unless WithGeneratedTLS was used.
+func (m *Mailpit) TLSConfig() *tls.Config {
+	if m.options.CA == nil {
+		return nil
+	}
+
+	return m.options.CA.TLSConfig(m.Host)
+}
+
+// Terminate closes the client and stops and removes the container, or stops
+// the process and removes its database.
+func (m *Mailpit) Terminate(ctx context.Context) error {
+	var stopErr error
+	if m.process != nil {
+		stopErr = m.process.stop(ctx)
+	} else {
+		stopErr = m.Container.Terminate(ctx)
+	}
+
+	if m.fileDir != "" {
+		stopErr = errors.Join(stopErr, os.RemoveAll(m.fileDir))
+	}
+
+	return errors.Join(m.close(), stopErr)
+}
@@ -0,0 +1,297 @@
+package testing
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/CodeLieutenant/mailpitclient"
+)
+
+func TestMailpitOptions_Env(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	crt := filepath.Join(dir, "smtp.crt")
+	key := filepath.Join(dir, "smtp.key")
+	require.NoError(t, os.WriteFile(crt, []byte("cert"), 0o600))
+	require.NoError(t, os.WriteFile(key, []byte("key"), 0o600))
+
+	tests := []struct {
+		expected map[string]string
+		name     string
+		opts     []MailpitOption
+		files    int
+	}{
+		{
+			name: "defaults",
+			expected: map[string]string{
+				"MP_SMTP_AUTH_ACCEPT_ANY":     "1",
+				"MP_SMTP_AUTH_ALLOW_INSECURE": "1",
+			},
+		},
+		{
+			name: "auth",
+			opts: []MailpitOption{WithSMTPAuth("user", "pass"), WithUIAuth("admin", "secret")},
+			expected: map[string]string{
+				"MP_SMTP_AUTH":                "user:pass",
+				"MP_SMTP_AUTH_ALLOW_INSECURE": "1",
+				"MP_UI_AUTH":                  "admin:secret",
+			},
+		},
+		{
+			name:  "required TLS",
+			opts:  []MailpitOption{WithSMTPTLS(crt, key, true)},
+			files: 2,
+			expected: map[string]string{
+				"MP_SMTP_AUTH_ACCEPT_ANY":  "1",
+				"MP_SMTP_TLS_CERT":         mailpitCertPath,
+				"MP_SMTP_TLS_KEY":          mailpitKeyPath,
+				"MP_SMTP_REQUIRE_STARTTLS": "true",
+			},
+		},
+		{
+			name: "chaos",
+			opts: []MailpitOption{WithChaos(&mailpitclient.ChaosTriggers{
+				Sender:         mailpitclient.ChaosTrigger{ErrorCode: 451, Probability: 50},
+				Authentication: mailpitclient.ChaosTrigger{Probability: 10},
+			})},
+			expected: map[string]string{
+				"MP_SMTP_AUTH_ACCEPT_ANY":     "1",
+				"MP_SMTP_AUTH_ALLOW_INSECURE": "1",
+				"MP_ENABLE_CHAOS":             "true",
+				"MP_CHAOS_TRIGGERS":           "Sender:451:50,Authentication:451:10",
+			},
+		},
+		{
+			name: "chaos without triggers",
+			opts: []MailpitOption{WithChaos(nil)},
+			expected: map[string]string{
+				"MP_SMTP_AUTH_ACCEPT_ANY":     "1",
+				"MP_SMTP_AUTH_ALLOW_INSECURE": "1",
+				"MP_ENABLE_CHAOS":             "true",
+			},
+		},
+		{
+			name: "integrations and limits",
+			opts: []MailpitOption{
+				WithSpamAssassin("spamd:783"),
+				WithWebhook("http://host.docker.internal:9000/hook"),
+				WithMaxMessages(100),
+				WithMaxAge(90 * time.Minute),
+			},
+			expected: map[string]string{
+				"MP_SMTP_AUTH_ACCEPT_ANY":     "1",
+				"MP_SMTP_AUTH_ALLOW_INSECURE": "1",
+				"MP_ENABLE_SPAMASSASSIN":      "spamd:783",
+				"MP_WEBHOOK_URL":              "http://host.docker.internal:9000/hook",
+				"MP_MAX_MESSAGES":             "100",
+				"MP_MAX_AGE":                  "2h",
+			},
+		},
+		{
+			name: "relay",
+			opts: []MailpitOption{WithRelay(RelayConfig{Host: "relay.example.com", Port: 587, StartTLS: true, All: true})},
+			expected: map[string]string{
+				"MP_SMTP_AUTH_ACCEPT_ANY":      "1",
+				"MP_SMTP_AUTH_ALLOW_INSECURE":  "1",
+				"MP_SMTP_RELAY_HOST":           "relay.example.com",
+				"MP_SMTP_RELAY_PORT":           "587",
+				"MP_SMTP_RELAY_STARTTLS":       "true",
+				"MP_SMTP_RELAY_ALLOW_INSECURE": "false",
+				"MP_SMTP_RELAY_AUTH":           "none",
+				"MP_SMTP_RELAY_ALL":            "true",
+			},
+		},
+		{
+			name: "typed options take precedence over raw env",
+			opts: []MailpitOption{
+				WithWebhook("http://typed"),
+				WithEnv(map[string]string{"MP_WEBHOOK_URL": "http://raw", "MP_VERBOSE": "true"}),
+			},
+			expected: map[string]string{
+				"MP_SMTP_AUTH_ACCEPT_ANY":     "1",
+				"MP_SMTP_AUTH_ALLOW_INSECURE": "1",
+				"MP_WEBHOOK_URL":              "http://typed",
+				"MP_VERBOSE":                  "true",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var opts MailpitOptions
+			for _, opt := range tt.opts {
+				opt(&opts)
+			}
+
+			env, files, err := opts.env()
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, env)
+			require.Len(t, files, tt.files)
+		})
+	}
+}
+
+func TestMailpitOptions_GeneratedTLS(t *testing.T) {
+	t.Parallel()
+
+	var opts MailpitOptions
+	WithGeneratedTLS(nil, false)(&opts)
+	WithImplicitTLS()(&opts)
+
+	dir, err := opts.prepare()
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = os.RemoveAll(dir) })
+	require.NotNil(t, opts.CA)
+	require.FileExists(t, opts.TLSCert)
+	require.FileExists(t, opts.TLSKey)
+
+	env, files, err := opts.env()
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+	require.Equal(t, "true", env["MP_SMTP_REQUIRE_TLS"])
+	require.NotContains(t, env, "MP_SMTP_REQUIRE_STARTTLS")
+	require.NotContains(t, env, "MP_SMTP_AUTH_ALLOW_INSECURE")
+
+	mp := &Mailpit{Host: "localhost", SMTPPort: "1465", options: opts}
+	require.Equal(t, "tls", mp.SMTPConfig().Encryption)
+	require.Same(t, opts.CA.CertPool(), mp.CertPool())
+	require.Equal(t, "localhost", mp.TLSConfig().ServerName)
+
+	// A given CA is reused.
+	var reuse MailpitOptions
+	WithGeneratedTLS(opts.CA, true)(&reuse)
+	reuseDir, err := reuse.prepare()
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = os.RemoveAll(reuseDir) })
+	require.Same(t, opts.CA, reuse.CA)
+}
+
+func TestMailpitOptions_EnvErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		opt  MailpitOption
+	}{
+		{
+			name: "missing TLS files",
+			opt:  WithSMTPTLS(filepath.Join(t.TempDir(), "missing.crt"), filepath.Join(t.TempDir(), "missing.key"), false),
+		},
+		{
+			name: "implicit TLS without certificate",
+			opt:  WithImplicitTLS(),
+		},
+		{
+			name: "invalid chaos trigger",
+			opt:  WithChaos(&mailpitclient.ChaosTriggers{Sender: mailpitclient.ChaosTrigger{ErrorCode: 200, Probability: 50}}),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var opts MailpitOptions
+			tt.opt(&opts)
+
+			_, _, err := opts.env()
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestMailpitOptions_Request(t *testing.T) {
+	t.Parallel()
+
+	opts := MailpitOptions{StartupTimeout: time.Second}
+	WithVersion("v1.27")(&opts)
+
+	req, err := opts.request()
+	require.NoError(t, err)
+	require.Equal(t, "axllent/mailpit:v1.27", req.Image)
+	require.Equal(t, []string{mailpitSMTPPort, mailpitHTTPPort}, req.ExposedPorts)
+	require.NotNil(t, req.HostConfigModifier)
+}
+
+func TestMailpit_Config(t *testing.T) {
+	t.Parallel()
+
+	mp := &Mailpit{Host: "localhost", SMTPPort: "1025", APIPort: "8025"}
+	WithSMTPAuth("user", "pass")(&mp.options)
+	WithUIAuth("admin", "secret")(&mp.options)
+
+	require.Equal(t, "localhost:1025", mp.SMTPAddr())
+	require.Equal(t, "http://localhost:8025", mp.APIURL())
+	require.Equal(t, SMTPConfig{
+		Host:       "localhost",
+		Port:       1025,
+		Username:   "user",
+		Password:   "pass",
+		AuthType:   "PLAIN",
+		Encryption: "none",
+	}, mp.SMTPConfig())
+	require.Nil(t, mp.CertPool())
+	require.Nil(t, mp.TLSConfig())
+	require.Equal(t, "http://localhost:8025/view/a%2Fb", mp.MessageURL("a/b"))
+
+	WithEnv(map[string]string{"MP_WEBROOT": "/mailpit/"})(&mp.options)
+	require.Equal(t, "http://localhost:8025/mailpit/view/1", mp.MessageURL("1"))
+
+	config := mp.ClientConfig(nil)
+	require.Equal(t, "http://localhost:8025", config.BaseURL)
+	require.Equal(t, "admin", config.Username)
+	require.Equal(t, "secret", config.Password)
+}
+
+func TestMailpitOptions_PrepareAuth(t *testing.T) {
+	t.Parallel()
+
+	var opts MailpitOptions
+	WithUIAuth("admin", "secret")(&opts)
+	WithSendAPIAuth("sender", "send-secret")(&opts)
+	WithHTTPS(nil)(&opts)
+
+	dir, err := opts.prepare()
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = os.RemoveAll(dir) })
+
+	htpasswd, err := os.ReadFile(opts.uiAuthFile)
+	require.NoError(t, err)
+	user, hash, _ := strings.Cut(strings.TrimSpace(string(htpasswd)), ":")
+	require.Equal(t, "admin", user)
+	require.NoError(t, bcrypt.CompareHashAndPassword([]byte(hash), []byte("secret")))
+
+	env, files, err := opts.env()
+	require.NoError(t, err)
+	require.Len(t, files, 4)
+	require.Equal(t, mailpitUIAuthPath, env["MP_UI_AUTH_FILE"])
+	require.Equal(t, mailpitSendAuthPath, env["MP_SEND_API_AUTH_FILE"])
+	require.Equal(t, mailpitUICertPath, env["MP_UI_TLS_CERT"])
+	require.Equal(t, mailpitUIKeyPath, env["MP_UI_TLS_KEY"])
+	require.NotContains(t, env, "MP_UI_AUTH", "credentials are not passed in plain text")
+
+	binaryEnv, err := opts.binaryEnv()
+	require.NoError(t, err)
+	require.Equal(t, opts.uiAuthFile, binaryEnv["MP_UI_AUTH_FILE"])
+	require.Equal(t, opts.sendAuthFile, binaryEnv["MP_SEND_API_AUTH_FILE"])
+	require.Equal(t, opts.uiCert, binaryEnv["MP_UI_TLS_CERT"])
+
+	mp := &Mailpit{Host: "localhost", APIPort: "8025", options: opts}
+	config := mp.ClientConfig(&mailpitclient.Config{Username: "other", Password: "pass"})
+	require.Equal(t, "https://localhost:8025", config.BaseURL)
+	require.Equal(t, "other", config.Username, "explicit credentials are kept")
+
+	transport, ok := config.HTTPClient.Transport.(*http.Transport)
+	require.True(t, ok)
+	require.Same(t, opts.CA.CertPool(), transport.TLSClientConfig.RootCAs)
+}
@@ -0,0 +1,50 @@
+package testing
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/CodeLieutenant/mailpitclient"
+)
+
+// AssertLint runs mailpitclient.Lint against a message, records every check on
+// reporter (if not nil) and fails the test listing each violation found.
+func (ts *TestSMTP) AssertLint(tb testing.TB, reporter *mailpitclient.Reporter, id string, policy *mailpitclient.LintPolicy) *mailpitclient.LintReport {
+	tb.Helper()
+
+	report, err := mailpitclient.Lint(tb.Context(), ts.MailpitClient, id, policy)
+	if err != nil {
+		tb.Fatalf("Failed to lint message %s: %v", id, err)
+
+		return nil
+	}
+
+	if reporter != nil {
+		reporter.AddLintReport(report)
+	}
+
+	if !report.Passed() {
+		tb.Errorf("Message %s (%q) failed email checks:\n  %s", id, report.Subject, strings.Join(report.Failures(), "\n  "))
+	}
+
+	return report
+}
+
+// AssertMessage records the outcome of a custom assertion about a message on
+// reporter (if not nil) and reports a non-nil err as a test error.
+// It returns true if the assertion passed.
+func AssertMessage(tb testing.TB, reporter *mailpitclient.Reporter, msg *mailpitclient.MessageSummary, name string, err error) bool {
+	tb.Helper()
+
+	if reporter != nil {
+		reporter.AddAssertion(msg.ID, msg.Subject, name, err)
+	}
+
+	if err != nil {
+		tb.Errorf("Message %s (%q) failed %s: %v", msg.ID, msg.Subject, name, err)
+
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,700 @@
+package testing
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// smtpSinkCommandTimeout bounds how long the sink waits for the next command
+// or for message data before dropping the connection.
+const smtpSinkCommandTimeout = 30 * time.Second
+
+var errSMTPSinkQuit = errors.New("smtp sink: client quit")
+
+// SMTPEnvelope is a message received by an SMTPSink.
+type SMTPEnvelope struct {
+	ReceivedAt time.Time
+	From       string
+	// Username is the authenticated user, if the client used AUTH.
+	Username string
+	To       []string
+	// Data is the raw message as sent after the DATA command, with its line
+	// endings unchanged and only the SMTP dot-stuffing removed.
+	Data []byte
+	// TLS reports whether the message was received over a STARTTLS connection.
+	TLS bool
+}
+
+// Message parses the raw data as an RFC 5322 message.
+func (e *SMTPEnvelope) Message() (*mail.Message, error) {
+	return mail.ReadMessage(strings.NewReader(string(e.Data)))
+}
+
+// Header returns the first value of the named header, or an empty string if the
+// header is missing or the message cannot be parsed.
+func (e *SMTPEnvelope) Header(name string) string {
+	msg, err := e.Message()
+	if err != nil {
+		return ""
+	}
+
+	return msg.Header.Get(name)
+}
+
+// Subject returns the decoded Subject header.
+func (e *SMTPEnvelope) Subject() string {
+	subject := e.Header("Subject")
+	if decoded, err := new(mime.WordDecoder).DecodeHeader(subject); err == nil {
+		return decoded
+	}
+
+	return subject
+}
+
+// HasRecipient reports whether addr is one of the envelope recipients.
+// Matching is case-insensitive.
+func (e *SMTPEnvelope) HasRecipient(addr string) bool {
+	for _, to := range e.To {
+		if strings.EqualFold(to, addr) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// SMTPSinkOptions configures an SMTPSink.
+type SMTPSinkOptions struct {
+	TLSConfig *tls.Config
+	// ListenAddr is the address the sink listens on. Defaults to 127.0.0.1:0;
+	// use ":0" when the sink must be reachable from a Mailpit container.
+	ListenAddr string
+	Hostname   string
+	Username   string
+	Password   string
+	RequireTLS bool
+}
+
+// SMTPSinkOption configures an SMTPSink.
+type SMTPSinkOption func(*SMTPSinkOptions)
+
+// WithSMTPSinkListenAddr sets the address the sink listens on.
+func WithSMTPSinkListenAddr(addr string) SMTPSinkOption {
+	return func(opts *SMTPSinkOptions) {
+		opts.ListenAddr = addr
+	}
+}
+
+// WithSMTPSinkTLS enables STARTTLS using config. If required is true, MAIL is
+// rejected until the client has issued STARTTLS.
+func WithSMTPSinkTLS(config *tls.Config, required bool) SMTPSinkOption {
+	return func(opts *SMTPSinkOptions) {
+		opts.TLSConfig = config
+		opts.RequireTLS = required
+	}
+}
+
+// WithSMTPSinkAuth requires clients to authenticate with AUTH PLAIN or AUTH
+// LOGIN using the given credentials before sending mail.
+func WithSMTPSinkAuth(username, password string) SMTPSinkOption {
+	return func(opts *SMTPSinkOptions) {
+		opts.Username = username
+		opts.Password = password
+	}
+}
+
+// SMTPSink is a small in-process SMTP server that captures every message it
+// receives. It can be used as Mailpit's relay target to test message release,
+// or standalone to test code that sends mail.
+type SMTPSink struct {
+	listener net.Listener
+	notify   chan struct{}
+	conns    map[net.Conn]struct{}
+	opts     SMTPSinkOptions
+	messages []SMTPEnvelope
+	wg       sync.WaitGroup
+	mu       sync.Mutex
+	closed   bool
+}
+
+// NewSMTPSink starts an SMTPSink that is closed when the test finishes.
+func NewSMTPSink(tb testing.TB, opts ...SMTPSinkOption) *SMTPSink {
+	tb.Helper()
+
+	sink, err := StartSMTPSink(tb.Context(), opts...)
+	if err != nil {
+		tb.Fatalf("Failed to start SMTP sink: %v", err)
+
+		return nil
+	}
+
+	tb.Cleanup(func() {
+		if err := sink.Close(); err != nil {
+			tb.Errorf("Failed to close SMTP sink: %v", err)
+		}
+	})
+
+	return sink
+}
+
+// StartSMTPSink starts an SMTPSink outside of a test. The caller must Close it.
+func StartSMTPSink(ctx context.Context, opts ...SMTPSinkOption) (*SMTPSink, error) {
+	options := SMTPSinkOptions{
+		ListenAddr: "127.0.0.1:0",
+		Hostname:   "mailpit-sink.local",
+	}
+
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	var lc net.ListenConfig
+	listener, err := lc.Listen(ctx, "tcp", options.ListenAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	sink := &SMTPSink{
+		listener: listener,
+		notify:   make(chan struct{}),
+		conns:    make(map[net.Conn]struct{}),
+		opts:     options,
+	}
+
+	sink.wg.Go(sink.serve)
+
+	return sink, nil
+}
+
+// Addr returns the address the sink is listening on.
+func (s *SMTPSink) Addr() string {
+	return s.listener.Addr().String()
+}
+
+// Port returns the TCP port the sink is listening on.
+func (s *SMTPSink) Port() int {
+	_, port, _ := net.SplitHostPort(s.Addr())
+	p, _ := strconv.Atoi(port)
+
+	return p
+}
+
+// RelayConfig returns the relay configuration that makes Mailpit release
+// messages to the sink, for use with WithRelay. host is the address Mailpit
+// uses to reach the sink, e.g. "host.docker.internal" from a container.
+// allowedRecipients and blockedRecipients are optional regular expressions.
+func (s *SMTPSink) RelayConfig(host, allowedRecipients, blockedRecipients string) RelayConfig {
+	return RelayConfig{
+		Host:              host,
+		Port:              s.Port(),
+		StartTLS:          s.opts.TLSConfig != nil,
+		AllowInsecure:     true,
+		Username:          s.opts.Username,
+		Password:          s.opts.Password,
+		AllowedRecipients: allowedRecipients,
+		BlockedRecipients: blockedRecipients,
+	}
+}
+
+// RelayEnv returns the Mailpit environment variables that configure the sink
+// as the SMTP relay, see RelayConfig.
+func (s *SMTPSink) RelayEnv(host, allowedRecipients, blockedRecipients string) map[string]string {
+	return s.RelayConfig(host, allowedRecipients, blockedRecipients).Env()
+}
+
+// Messages returns a copy of all messages received so far.
+func (s *SMTPSink) Messages() []SMTPEnvelope {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := make([]SMTPEnvelope, len(s.messages))
+	copy(out, s.messages)
+
+	return out
+}
+
+// MessagesTo returns the received messages that have addr as a recipient.
+func (s *SMTPSink) MessagesTo(addr string) []SMTPEnvelope {
+	return envelopesTo(s.Messages(), addr)
+}
+
+func envelopesTo(envelopes []SMTPEnvelope, addr string) []SMTPEnvelope {
+	var out []SMTPEnvelope
+	for _, env := range envelopes {
+		if env.HasRecipient(addr) {
+			out = append(out, env)
+		}
+	}
+
+	return out
+}
+
+// Reset discards all received messages.
+func (s *SMTPSink) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.messages = nil
+}
+
+// WaitForMessages waits until at least expectedCount messages have been received.
+func (s *SMTPSink) WaitForMessages(tb testing.TB, expectedCount int, timeout time.Duration) []SMTPEnvelope {
+	tb.Helper()
+
+	messages, ok := s.waitFor(tb.Context(), timeout, func(messages []SMTPEnvelope) bool {
+		return len(messages) >= expectedCount
+	})
+	if !ok {
+		tb.Fatalf("Timeout waiting for %d messages on SMTP sink, received %d", expectedCount, len(messages))
+
+		return nil
+	}
+
+	return messages
+}
+
+// AssertReceived waits until a message for recipient has been received and
+// returns the first one.
+func (s *SMTPSink) AssertReceived(tb testing.TB, recipient string, timeout time.Duration) SMTPEnvelope {
+	tb.Helper()
+
+	messages, ok := s.waitFor(tb.Context(), timeout, func(messages []SMTPEnvelope) bool {
+		return len(envelopesTo(messages, recipient)) > 0
+	})
+	if !ok {
+		tb.Fatalf("SMTP sink did not receive a message for %s within %s", recipient, timeout)
+
+		return SMTPEnvelope{}
+	}
+
+	return envelopesTo(messages, recipient)[0]
+}
+
+// AssertNotReceived waits for the given duration and fails the test if a
+// message for recipient arrives, e.g. to verify relay block lists.
+func (s *SMTPSink) AssertNotReceived(tb testing.TB, recipient string, within time.Duration) {
+	tb.Helper()
+
+	if _, received := s.waitFor(tb.Context(), within, func(messages []SMTPEnvelope) bool {
+		return len(envelopesTo(messages, recipient)) > 0
+	}); received {
+		tb.Errorf("SMTP sink unexpectedly received a message for %s", recipient)
+	}
+}
+
+// Close stops the sink and waits for open connections to finish.
+func (s *SMTPSink) Close() error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+
+		return nil
+	}
+	s.closed = true
+	for conn := range s.conns {
+		_ = conn.Close()
+	}
+	s.mu.Unlock()
+
+	err := s.listener.Close()
+	s.wg.Wait()
+
+	return err
+}
+
+// waitFor blocks until done returns true for the received messages or the
+// timeout expires. It returns the messages at that point and whether done
+// was satisfied.
+func (s *SMTPSink) waitFor(ctx context.Context, timeout time.Duration, done func([]SMTPEnvelope) bool) ([]SMTPEnvelope, bool) {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	for {
+		s.mu.Lock()
+		messages := make([]SMTPEnvelope, len(s.messages))
+		copy(messages, s.messages)
+		notify := s.notify
+		s.mu.Unlock()
+
+		if done(messages) {
+			return messages, true
+		}
+
+		select {
+		case <-notify:
+		case <-timer.C:
+			return messages, false
+		case <-ctx.Done():
+			return messages, false
+		}
+	}
+}
+
+func (s *SMTPSink) store(env SMTPEnvelope) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.messages = append(s.messages, env)
+	close(s.notify)
+	s.notify = make(chan struct{})
+}
+
+func (s *SMTPSink) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		s.mu.Lock()
+		if s.closed {
+			s.mu.Unlock()
+			_ = conn.Close()
+
+			return
+		}
+		s.conns[conn] = struct{}{}
+		s.mu.Unlock()
+
+		s.wg.Go(func() {
+			defer func() {
+				s.mu.Lock()
+				delete(s.conns, conn)
+				s.mu.Unlock()
+				_ = conn.Close()
+			}()
+			s.handle(conn)
+		})
+	}
+}
+
+// smtpSession holds the state of a single SMTP connection.
+type smtpSession struct {
+	conn     net.Conn
+	text     *textproto.Conn
+	sink     *SMTPSink
+	from     string
+	username string
+	to       []string
+	hasMail  bool
+	tls      bool
+	authed   bool
+}
+
+func (s *SMTPSink) handle(conn net.Conn) {
+	sess := &smtpSession{conn: conn, text: textproto.NewConn(conn), sink: s}
+
+	_ = conn.SetDeadline(time.Now().Add(smtpSinkCommandTimeout))
+	if err := sess.reply(220, s.opts.Hostname+" ESMTP mailpitclient test sink"); err != nil {
+		return
+	}
+
+	for {
+		_ = conn.SetDeadline(time.Now().Add(smtpSinkCommandTimeout))
+
+		line, err := sess.text.ReadLine()
+		if err != nil {
+			return
+		}
+
+		verb, arg, _ := strings.Cut(line, " ")
+		if err = sess.command(strings.ToUpper(verb), strings.TrimSpace(arg)); err != nil {
+			return
+		}
+	}
+}
+
+func (sess *smtpSession) reply(code int, lines ...string) error {
+	for i, line := range lines {
+		sep := " "
+		if i < len(lines)-1 {
+			sep = "-"
+		}
+
+		if err := sess.text.PrintfLine("%d%s%s", code, sep, line); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (sess *smtpSession) reset() {
+	sess.from = ""
+	sess.to = nil
+	sess.hasMail = false
+}
+
+func (sess *smtpSession) command(verb, arg string) error {
+	opts := sess.sink.opts
+
+	switch verb {
+	case "HELO":
+		sess.reset()
+
+		return sess.reply(250, opts.Hostname)
+	case "EHLO":
+		sess.reset()
+
+		return sess.reply(250, sess.extensions()...)
+	case "STARTTLS":
+		return sess.startTLS()
+	case "AUTH":
+		return sess.auth(arg)
+	case "MAIL":
+		return sess.mail(arg)
+	case "RCPT":
+		return sess.rcpt(arg)
+	case "DATA":
+		return sess.data()
+	case "RSET":
+		sess.reset()
+
+		return sess.reply(250, "2.0.0 OK")
+	case "NOOP":
+		return sess.reply(250, "2.0.0 OK")
+	case "VRFY":
+		return sess.reply(252, "2.5.2 Cannot VRFY user")
+	case "QUIT":
+		_ = sess.reply(221, "2.0.0 Bye")
+
+		return errSMTPSinkQuit
+	default:
+		return sess.reply(502, "5.5.2 Command not recognized")
+	}
+}
+
+func (sess *smtpSession) extensions() []string {
+	opts := sess.sink.opts
+	ext := []string{opts.Hostname, "8BITMIME", "SMTPUTF8", "PIPELINING"}
+
+	if opts.TLSConfig != nil && !sess.tls {
+		ext = append(ext, "STARTTLS")
+	}
+
+	if opts.Username != "" && (sess.tls || !opts.RequireTLS) {
+		ext = append(ext, "AUTH PLAIN LOGIN")
+	}
+
+	return ext
+}
+
+func (sess *smtpSession) startTLS() error {
+	if sess.sink.opts.TLSConfig == nil || sess.tls {
+		return sess.reply(502, "5.5.1 STARTTLS not available")
+	}
+
+	if err := sess.reply(220, "2.0.0 Ready to start TLS"); err != nil {
+		return err
+	}
+
+	tlsConn := tls.Server(sess.conn, sess.sink.opts.TLSConfig)
+	if err := tlsConn.Handshake(); err != nil {
+		return err
+	}
+
+	// RFC 3207: the client must start over with EHLO after the handshake.
+	sess.conn = tlsConn
+	sess.text = textproto.NewConn(tlsConn)
+	sess.tls = true
+	sess.authed = false
+	sess.username = ""
+	sess.reset()
+
+	return nil
+}
+
+func (sess *smtpSession) auth(arg string) error {
+	opts := sess.sink.opts
+
+	switch {
+	case opts.Username == "":
+		return sess.reply(502, "5.5.1 AUTH not available")
+	case opts.RequireTLS && !sess.tls:
+		return sess.reply(530, "5.7.0 Must issue a STARTTLS command first")
+	case sess.authed:
+		return sess.reply(503, "5.5.1 Already authenticated")
+	}
+
+	mechanism, initial, _ := strings.Cut(arg, " ")
+
+	var username, password string
+
+	switch strings.ToUpper(mechanism) {
+	case "PLAIN":
+		resp, err := sess.challenge(initial, "")
+		if err != nil {
+			return err
+		}
+
+		// authzid \x00 authcid \x00 passwd
+		parts := strings.SplitN(resp, "\x00", 3)
+		if len(parts) == 3 {
+			username, password = parts[1], parts[2]
+		}
+	case "LOGIN":
+		var err error
+		if username, err = sess.challenge(initial, "Username:"); err != nil {
+			return err
+		}
+		if password, err = sess.challenge("", "Password:"); err != nil {
+			return err
+		}
+	default:
+		return sess.reply(504, "5.5.4 Unrecognized authentication type")
+	}
+
+	if username != opts.Username || password != opts.Password {
+		return sess.reply(535, "5.7.8 Authentication credentials invalid")
+	}
+
+	sess.authed = true
+	sess.username = username
+
+	return sess.reply(235, "2.7.0 Authentication successful")
+}
+
+// challenge returns the decoded initial response if one was sent, otherwise
+// it sends prompt as a 334 challenge and decodes the client's answer.
+func (sess *smtpSession) challenge(initial, prompt string) (string, error) {
+	if initial == "" {
+		if err := sess.reply(334, base64.StdEncoding.EncodeToString([]byte(prompt))); err != nil {
+			return "", err
+		}
+
+		line, err := sess.text.ReadLine()
+		if err != nil {
+			return "", err
+		}
+		initial = line
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(initial)
+	if err != nil {
+		return "", nil //nolint:nilerr // invalid encoding is reported as bad credentials
+	}
+
+	return string(decoded), nil
+}
+
+func (sess *smtpSession) mail(arg string) error {
+	opts := sess.sink.opts
+
+	switch {
+	case opts.RequireTLS && !sess.tls:
+		return sess.reply(530, "5.7.0 Must issue a STARTTLS command first")
+	case opts.Username != "" && !sess.authed:
+		return sess.reply(530, "5.7.0 Authentication required")
+	case sess.hasMail:
+		return sess.reply(503, "5.5.1 Sender already specified")
+	}
+
+	from, ok := parsePath(arg, "FROM:")
+	if !ok {
+		return sess.reply(501, "5.5.4 Syntax: MAIL FROM:<address>")
+	}
+
+	sess.from = from
+	sess.hasMail = true
+
+	return sess.reply(250, "2.1.0 OK")
+}
+
+func (sess *smtpSession) rcpt(arg string) error {
+	if !sess.hasMail {
+		return sess.reply(503, "5.5.1 Need MAIL before RCPT")
+	}
+
+	to, ok := parsePath(arg, "TO:")
+	if !ok || to == "" {
+		return sess.reply(501, "5.5.4 Syntax: RCPT TO:<address>")
+	}
+
+	sess.to = append(sess.to, to)
+
+	return sess.reply(250, "2.1.5 OK")
+}
+
+func (sess *smtpSession) data() error {
+	if len(sess.to) == 0 {
+		return sess.reply(503, "5.5.1 Need RCPT before DATA")
+	}
+
+	if err := sess.reply(354, "Start mail input; end with <CRLF>.<CRLF>"); err != nil {
+		return err
+	}
+
+	data, err := sess.readData()
+	if err != nil {
+		return err
+	}
+
+	sess.sink.store(SMTPEnvelope{
+		ReceivedAt: time.Now(),
+		From:       sess.from,
+		Username:   sess.username,
+		To:         sess.to,
+		Data:       data,
+		TLS:        sess.tls,
+	})
+	sess.reset()
+
+	return sess.reply(250, fmt.Sprintf("2.0.0 OK queued as %d", time.Now().UnixNano()))
+}
+
+// readData reads the message up to the terminating "." line. Unlike
+// textproto's DotReader it keeps the line endings as sent and only removes
+// the dot-stuffing, so Data is byte-for-byte the message the client sent.
+func (sess *smtpSession) readData() ([]byte, error) {
+	var data []byte
+
+	for {
+		line, err := sess.text.R.ReadBytes('\n')
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				err = io.ErrUnexpectedEOF
+			}
+
+			return nil, err
+		}
+
+		if string(line) == ".\r\n" || string(line) == ".\n" {
+			return data, nil
+		}
+
+		data = append(data, bytes.TrimPrefix(line, []byte("."))...)
+	}
+}
+
+// parsePath extracts the address from "FROM:<addr> PARAMS" style arguments.
+func parsePath(arg, prefix string) (string, bool) {
+	if len(arg) < len(prefix) || !strings.EqualFold(arg[:len(prefix)], prefix) {
+		return "", false
+	}
+
+	rest := strings.TrimSpace(arg[len(prefix):])
+	if !strings.HasPrefix(rest, "<") {
+		return "", false
+	}
+
+	end := strings.IndexByte(rest, '>')
+	if end < 0 {
+		return "", false
+	}
+
+	return rest[1:end], true
+}
@@ -0,0 +1,248 @@
+package testing
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const sinkTestMessage = "From: sender@example.com\r\n" +
+	"To: alice@example.com\r\n" +
+	"Subject: =?utf-8?q?Release_=E2=9C=93?=\r\n" +
+	"\r\n" +
+	"Hello from the sink test\r\n" +
+	".signature\r\n"
+
+// sinkTestTLS returns a server config with a self-signed certificate for
+// 127.0.0.1 and a pool trusting it.
+func sinkTestTLS(t *testing.T) (*tls.Config, *x509.CertPool) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "smtp sink test"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+
+	return &tls.Config{
+		Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}},
+		MinVersion:   tls.VersionTLS12,
+	}, pool
+}
+
+// sinkTestSend delivers sinkTestMessage, upgrading to TLS and authenticating
+// when the corresponding arguments are set.
+func sinkTestSend(t *testing.T, sink *SMTPSink, roots *x509.CertPool, auth smtp.Auth, to ...string) error {
+	t.Helper()
+
+	c, err := smtp.Dial(sink.Addr())
+	require.NoError(t, err)
+	defer c.Close()
+
+	if roots != nil {
+		if err = c.StartTLS(&tls.Config{ServerName: "127.0.0.1", RootCAs: roots, MinVersion: tls.VersionTLS12}); err != nil {
+			return err
+		}
+	}
+
+	if auth != nil {
+		if err = c.Auth(auth); err != nil {
+			return err
+		}
+	}
+
+	if err = c.Mail("sender@example.com"); err != nil {
+		return err
+	}
+
+	for _, rcpt := range to {
+		if err = c.Rcpt(rcpt); err != nil {
+			return err
+		}
+	}
+
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+
+	if _, err = w.Write([]byte(sinkTestMessage)); err != nil {
+		return err
+	}
+
+	if err = w.Close(); err != nil {
+		return err
+	}
+
+	return c.Quit()
+}
+
+func TestSMTPSink_Capture(t *testing.T) {
+	t.Parallel()
+
+	sink := NewSMTPSink(t)
+
+	require.NoError(t, sinkTestSend(t, sink, nil, nil, "alice@example.com", "bob@example.com"))
+
+	messages := sink.WaitForMessages(t, 1, 5*time.Second)
+	require.Len(t, messages, 1)
+
+	env := messages[0]
+	require.Equal(t, "sender@example.com", env.From)
+	require.Equal(t, []string{"alice@example.com", "bob@example.com"}, env.To)
+	require.Equal(t, "Release ✓", env.Subject())
+	require.Equal(t, sinkTestMessage, string(env.Data), "data is kept as sent, without the dot-stuffing")
+	require.False(t, env.TLS)
+	require.Empty(t, env.Username)
+
+	require.Equal(t, env, sink.AssertReceived(t, "BOB@example.com", time.Second))
+	sink.AssertNotReceived(t, "carol@example.com", 50*time.Millisecond)
+
+	sink.Reset()
+	require.Empty(t, sink.Messages())
+}
+
+func TestSMTPSink_RawData(t *testing.T) {
+	t.Parallel()
+
+	long := strings.Repeat("A", 3000) + strings.Repeat("B", 3000) + strings.Repeat("C", 3000)
+
+	tests := []struct {
+		name string
+		sent string
+		want string
+	}{
+		{
+			// A bare LF and trailing whitespace survive; the stuffed dot does not.
+			name: "line endings and dot stuffing",
+			sent: "Subject: raw\r\n\r\nbare\nline  \r\n..dot\r\n.\r\n",
+			want: "Subject: raw\r\n\r\nbare\nline  \r\n.dot\r\n",
+		},
+		{
+			name: "line longer than the read buffer",
+			sent: "Subject: long\r\n\r\n" + long + "\r\n.\r\n",
+			want: "Subject: long\r\n\r\n" + long + "\r\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			sink := NewSMTPSink(t)
+
+			conn, err := net.Dial("tcp", sink.Addr())
+			require.NoError(t, err)
+			defer conn.Close()
+
+			text := textproto.NewConn(conn)
+			_, _, err = text.ReadResponse(220)
+			require.NoError(t, err)
+
+			for _, cmd := range []struct {
+				line string
+				code int
+			}{
+				{"HELO client", 250},
+				{"MAIL FROM:<sender@example.com>", 250},
+				{"RCPT TO:<alice@example.com>", 250},
+				{"DATA", 354},
+			} {
+				require.NoError(t, text.PrintfLine("%s", cmd.line))
+				_, _, err = text.ReadResponse(cmd.code)
+				require.NoError(t, err, cmd.line)
+			}
+
+			_, err = conn.Write([]byte(tt.sent))
+			require.NoError(t, err)
+			_, _, err = text.ReadResponse(250)
+			require.NoError(t, err)
+
+			env := sink.AssertReceived(t, "alice@example.com", time.Second)
+			require.Equal(t, tt.want, string(env.Data))
+		})
+	}
+}
+
+func TestSMTPSink_StartTLSAndAuth(t *testing.T) {
+	t.Parallel()
+
+	serverTLS, roots := sinkTestTLS(t)
+	sink := NewSMTPSink(t, WithSMTPSinkTLS(serverTLS, true), WithSMTPSinkAuth("relay", "secret"))
+
+	t.Run("requires TLS", func(t *testing.T) {
+		t.Parallel()
+
+		err := sinkTestSend(t, sink, nil, nil, "alice@example.com")
+		require.ErrorContains(t, err, "STARTTLS")
+	})
+
+	t.Run("requires auth", func(t *testing.T) {
+		t.Parallel()
+
+		err := sinkTestSend(t, sink, roots, nil, "alice@example.com")
+		require.ErrorContains(t, err, "Authentication required")
+	})
+
+	t.Run("rejects bad credentials", func(t *testing.T) {
+		t.Parallel()
+
+		err := sinkTestSend(t, sink, roots, smtp.PlainAuth("", "relay", "wrong", "127.0.0.1"), "alice@example.com")
+		require.ErrorContains(t, err, "535")
+	})
+
+	t.Run("delivers with TLS and auth", func(t *testing.T) {
+		t.Parallel()
+
+		require.NoError(t, sinkTestSend(t, sink, roots, smtp.PlainAuth("", "relay", "secret", "127.0.0.1"), "dave@example.com"))
+
+		env := sink.AssertReceived(t, "dave@example.com", 5*time.Second)
+		require.True(t, env.TLS)
+		require.Equal(t, "relay", env.Username)
+	})
+}
+
+func TestSMTPSink_RelayEnv(t *testing.T) {
+	t.Parallel()
+
+	sink := NewSMTPSink(t, WithSMTPSinkAuth("relay", "secret"))
+
+	env := sink.RelayEnv("host.docker.internal", `@example\.com$`, "")
+	require.Equal(t, "host.docker.internal", env["MP_SMTP_RELAY_HOST"])
+	require.Equal(t, "plain", env["MP_SMTP_RELAY_AUTH"])
+	require.Equal(t, "relay", env["MP_SMTP_RELAY_USERNAME"])
+	require.Equal(t, "false", env["MP_SMTP_RELAY_STARTTLS"])
+	require.Equal(t, `@example\.com$`, env["MP_SMTP_RELAY_ALLOWED_RECIPIENTS"])
+	require.NotContains(t, env, "MP_SMTP_RELAY_BLOCKED_RECIPIENTS")
+	require.NotEmpty(t, env["MP_SMTP_RELAY_PORT"])
+}
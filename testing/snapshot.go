@@ -0,0 +1,129 @@
+package testing
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/CodeLieutenant/mailpitclient"
+)
+
+// UpdateSnapshotsEnv makes AssertGolden and AssertImage (re)write their
+// golden files instead of comparing against them, e.g.
+// MAILPIT_UPDATE_SNAPSHOTS=1 go test ./...
+const UpdateSnapshotsEnv = "MAILPIT_UPDATE_SNAPSHOTS"
+
+// updateSnapshots reports whether golden files should be rewritten. Only
+// UpdateSnapshotsEnv is consulted: a flag would clash with the -update flag
+// of the package under test.
+func updateSnapshots() bool {
+	update, err := strconv.ParseBool(os.Getenv(UpdateSnapshotsEnv))
+
+	return err == nil && update
+}
+
+// SnapshotDir is the directory golden files are read from and written to,
+// relative to the package under test.
+const SnapshotDir = "testdata"
+
+// AssertSnapshot renders the message with mailpitclient.MessageSnapshot and
+// compares it against testdata/<name>.golden, failing the test with a line
+// diff on mismatch. Set MAILPIT_UPDATE_SNAPSHOTS=1 to (re)write the golden
+// file.
+// opts may be nil.
+func AssertSnapshot(tb testing.TB, c mailpitclient.Client, id, name string, opts *mailpitclient.SnapshotOptions) {
+	tb.Helper()
+
+	got, err := mailpitclient.MessageSnapshot(tb.Context(), c, id, opts)
+	if err != nil {
+		tb.Fatalf("Failed to render snapshot of message %s: %v", id, err)
+
+		return
+	}
+
+	AssertGolden(tb, name, got)
+}
+
+// AssertSnapshot is AssertSnapshot using the TestSMTP client.
+func (ts *TestSMTP) AssertSnapshot(tb testing.TB, id, name string, opts *mailpitclient.SnapshotOptions) {
+	tb.Helper()
+
+	AssertSnapshot(tb, ts.MailpitClient, id, name, opts)
+}
+
+// AssertGolden compares got against testdata/<name>.golden, or writes it
+// there when MAILPIT_UPDATE_SNAPSHOTS is set.
+func AssertGolden(tb testing.TB, name, got string) {
+	tb.Helper()
+
+	path := filepath.Join(SnapshotDir, filepath.FromSlash(name)+".golden")
+
+	if updateSnapshots() {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			tb.Fatalf("Failed to create snapshot directory: %v", err)
+
+			return
+		}
+
+		if err := os.WriteFile(path, []byte(got), 0o600); err != nil {
+			tb.Fatalf("Failed to write snapshot %s: %v", path, err)
+		}
+
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		tb.Fatalf("Failed to read snapshot %s (set %s=1 to create it): %v", path, UpdateSnapshotsEnv, err)
+
+		return
+	}
+
+	if string(want) != got {
+		tb.Errorf("Snapshot %s does not match (set %s=1 to accept):\n%s", path, UpdateSnapshotsEnv, lineDiff(string(want), got))
+	}
+}
+
+// lineDiff returns a minimal line-based diff of want and got, prefixing
+// removed lines with "-" and added lines with "+".
+func lineDiff(want, got string) string {
+	a := strings.Split(want, "\n")
+	b := strings.Split(got, "\n")
+
+	// Longest common subsequence table; golden files are small.
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var out strings.Builder
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			fmt.Fprintf(&out, "  %s\n", a[i])
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			fmt.Fprintf(&out, "- %s\n", a[i])
+			i++
+		default:
+			fmt.Fprintf(&out, "+ %s\n", b[j])
+			j++
+		}
+	}
+
+	return out.String()
+}
@@ -0,0 +1,105 @@
+package testing
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/CodeLieutenant/mailpitclient"
+)
+
+func TestAssertSnapshot(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/message/msg-1" {
+			w.WriteHeader(http.StatusNotFound)
+
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(mailpitclient.Message{
+			ID:      "msg-1",
+			Subject: "Welcome aboard",
+			From:    mailpitclient.Address{Name: "App", Address: "noreply@example.com"},
+			To:      []mailpitclient.Address{{Address: "alice@example.com"}},
+			Text:    "Welcome! Sent Mon, 06 Jan 2025 10:11:12 +0000\n",
+			HTML:    `<h1>Welcome!</h1><p><a href="https://example.com/start?id=msg-1">Get started</a></p>`,
+		})
+	}))
+	t.Cleanup(server.Close)
+
+	c, err := mailpitclient.NewClient(&mailpitclient.Config{
+		BaseURL:    server.URL,
+		APIPath:    "/api/v1",
+		HTTPClient: &http.Client{Timeout: 5 * time.Second},
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = c.Close() })
+
+	ts := &TestSMTP{MailpitClient: c}
+	ts.AssertSnapshot(t, "msg-1", "welcome", nil)
+}
+
+func TestAssertGolden_Mismatch(t *testing.T) {
+	t.Parallel()
+
+	name := "mismatch"
+	path := filepath.Join(SnapshotDir, name+".golden")
+	require.NoError(t, os.WriteFile(path, []byte("a\nb\nc\n"), 0o600))
+	t.Cleanup(func() { _ = os.Remove(path) })
+
+	rec := &recordingTB{TB: t}
+	AssertGolden(rec, name, "a\nB\nc\n")
+	require.True(t, rec.failed)
+	require.Contains(t, rec.msg, "  a\n- b\n+ B\n  c\n")
+}
+
+//nolint:paralleltest // sets UpdateSnapshotsEnv
+func TestAssertGolden_Update(t *testing.T) {
+	t.Setenv(UpdateSnapshotsEnv, "1")
+
+	name := "update"
+	path := filepath.Join(SnapshotDir, name+".golden")
+	t.Cleanup(func() { _ = os.Remove(path) })
+
+	AssertGolden(t, name, "fresh\n")
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, "fresh\n", string(data))
+
+	t.Setenv(UpdateSnapshotsEnv, "false")
+	require.False(t, updateSnapshots())
+	require.Nil(t, flag.Lookup("update"), "no -update flag is registered by the package")
+}
+
+func TestLineDiff(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, "  same\n", lineDiff("same", "same"))
+	require.Equal(t, "- old\n+ new\n  tail\n", lineDiff("old\ntail", "new\ntail"))
+	require.Equal(t, "  head\n+ added\n", lineDiff("head", "head\nadded"))
+}
+
+// recordingTB captures Errorf calls instead of failing the test.
+type recordingTB struct {
+	testing.TB
+
+	msg    string
+	failed bool
+}
+
+func (r *recordingTB) Errorf(format string, args ...any) {
+	r.failed = true
+	r.msg = fmt.Sprintf(format, args...)
+}
@@ -69,21 +69,38 @@
 //
 // The package uses a container pool to optimize test performance while maintaining isolation:
 //
-// - Container Pool: Reuses Mailpit containers across tests for efficiency
+// - Container Pool: Reuses Mailpit containers, started with RunMailpit, across tests for efficiency
 // - Automatic Cleanup: Containers are properly cleaned up after test completion
-// - TLS Support: Containers are pre-configured with TLS certificates for HTTPS testing
+// - TLS Support: Each pool generates an ephemeral CA and issues the containers' SMTP certificates
 // - Port Management: Dynamically assigned ports prevent conflicts
 //
 // Container pool size can be configured via environment variable:
 //
 //	export TEST_SMTP_POOL_SIZE=10  # Default is 5
 //
+// # Backends
+//
+// Mailpit runs in Docker by default. Where no Docker daemon is available, a
+// locally installed mailpit binary is started instead, on free loopback ports
+// with a temporary database; TestSMTP.Container is nil in that case. The
+// backend is selected with WithBackend or TEST_SMTP_BACKEND (auto, docker or
+// binary), and the binary is looked up in PATH unless WithBinary or
+// TEST_SMTP_MAILPIT_BINARY names it:
+//
+//	testSMTP := GetTestSMTP(t, WithMailpitOptions(WithBackend(BackendBinary)))
+//
+// When the selected backend is unavailable, GetTestSMTP and NewMailpit skip
+// the test with the reason, and RunMailpit returns an error matching
+// ErrNoBackend. Relay targets and webhook receivers on the host are reached
+// through host.docker.internal in Docker and 127.0.0.1 with the binary.
+//
 // # TestSMTP Structure
 //
 // The TestSMTP struct provides everything needed for e2e testing:
 //
 //	type TestSMTP struct {
 //		Container     testcontainers.Container  // Docker container instance
+//		Mailpit       *Mailpit                 // Pooled RunMailpit instance
 //		MailpitClient mailpitclient.Client     // Pre-configured mailpit client
 //		SMTPPort      string                   // Mapped SMTP port (1025)
 //		APIPort       string                   // Mapped API port (8025)
@@ -110,6 +127,88 @@
 //
 //	messages := testSMTP.WaitForMessages(t, 2, 10*time.Second)
 //
+// ## AssertLint
+// Runs the HTML, link and SpamAssassin checks and fails the test on violations.
+// Passing a *mailpitclient.Reporter collects the results for CI reports:
+//
+//	reporter := mailpitclient.NewReporter()
+//	testSMTP.AssertLint(t, reporter, messages[0].ID, mailpitclient.DefaultLintPolicy())
+//	AssertMessage(t, reporter, &messages[0], "has reset link", checkResetLink(messages[0]))
+//
+//	// e.g. in TestMain
+//	f, _ := os.OpenFile(os.Getenv("GITHUB_STEP_SUMMARY"), os.O_APPEND|os.O_WRONLY, 0o644)
+//	_ = reporter.WriteMarkdown(f)
+//
+// ## SMTPSink
+// An in-process SMTP server that captures what it receives, with optional
+// STARTTLS and AUTH. Use it as Mailpit's relay target to test message release
+// without outside network access, or standalone to test code that sends mail:
+//
+//	sink := NewSMTPSink(t, WithSMTPSinkListenAddr(":0"))
+//	env := sink.RelayEnv("host.docker.internal", `@example\.com$`, "")
+//	testSMTP := GetTestSMTP(t, WithMailPitEnv(env))
+//
+//	// ... release a message through testSMTP.MailpitClient
+//	received := sink.AssertReceived(t, "stakeholder@example.com", 10*time.Second)
+//	require.Equal(t, "Weekly report", received.Subject())
+//	sink.AssertNotReceived(t, "someone@other.org", time.Second)
+//
+// ## AssertSnapshot
+// Renders a message with mailpitclient.MessageSnapshot and compares it against
+// testdata/<name>.golden. Set MAILPIT_UPDATE_SNAPSHOTS=1 to write the golden
+// files:
+//
+//	testSMTP.AssertSnapshot(t, messages[0].ID, "password-reset", nil)
+//
+//	// MAILPIT_UPDATE_SNAPSHOTS=1 go test ./... -run TestPasswordReset
+//
+// ## AssertMessageImage
+// Compares an image part (by file name, content ID or part ID) or its Mailpit
+// thumbnail against testdata/<name>.png with a perceptual diff. On failure the
+// actual and diff images are written to VisualFailureDir:
+//
+//	testSMTP.AssertMessageImage(t, messages[0].ID, "logo.png", "brand-logo", nil)
+//	testSMTP.AssertMessageThumbnail(t, messages[0].ID, "banner.jpg", "banner", nil)
+//
+// ## RunMailpit
+// Starts a dedicated Mailpit container without a testing.TB, e.g. from
+// TestMain, a benchmark or a development tool. Typed options cover SMTP and UI
+// authentication, STARTTLS, chaos triggers, SpamAssassin, relaying, webhooks,
+// storage limits and the image version; WithEnv passes any other MP_* setting:
+//
+//	mp, err := RunMailpit(ctx,
+//		WithVersion("v1.27"),
+//		WithSMTPAuth("user", "pass"),
+//		WithChaos(&mailpitclient.ChaosTriggers{
+//			Sender: mailpitclient.ChaosTrigger{ErrorCode: 451, Probability: 50},
+//		}),
+//		WithRelay(sink.RelayConfig("host.docker.internal", "", "")),
+//	)
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	defer mp.Terminate(context.Background())
+//
+//	messages, err := mp.Client.ListMessages(ctx, nil)
+//
+// NewMailpit does the same for a single test and terminates the container in
+// its cleanup. GetTestSMTP accepts the same options through WithMailpitOptions
+// and then starts a dedicated instance instead of using the pool:
+//
+//	testSMTP := GetTestSMTP(t, WithMailpitOptions(WithMaxMessages(10)))
+//
+// ## DumpOnFailure
+// When a test fails, writes what Mailpit received during the test and its
+// logs: a summary table with web UI links, and each message's headers and
+// text body. With a directory, the dump goes to <dir>/<test name> and
+// includes the .eml sources; otherwise a shortened dump goes to the test
+// output. Enable it per test, or for every test with TEST_SMTP_DUMP_DIR:
+//
+//	testSMTP := GetTestSMTP(t, WithDumpOnFailure("artifacts/mailpit"))
+//
+//	// or, for a TestSMTP obtained elsewhere
+//	testSMTP.DumpOnFailure(t, "")
+//
 // # SMTP Configuration
 //
 // The SMTPConfig provides SMTP server connection details:
@@ -130,6 +229,39 @@
 //		require.NoError(t, err)
 //	}
 //
+// ## TLS
+// The SMTP certificate is issued by a CA generated in memory for the pool, so
+// no certificate files are needed. TestSMTP.TLSConfig trusts the CA and
+// verifies the host name; TestSMTP.CertPool holds the CA for custom configs:
+//
+//	c, err := smtp.Dial(addr)
+//	require.NoError(t, err)
+//	require.NoError(t, c.StartTLS(testSMTP.TLSConfig))
+//
+// For implicit TLS, start a dedicated instance:
+//
+//	mp := NewMailpit(t, WithGeneratedTLS(nil, false), WithImplicitTLS())
+//	conn, err := tls.Dial("tcp", mp.SMTPAddr(), mp.TLSConfig())
+//
+// WithMailPitCert and WithMailPitKey use existing certificate files instead,
+// e.g. from make mkcert-generate; TLSConfig and CertPool are then nil.
+//
+// ## Authenticated and HTTPS APIs
+// WithMailPitUIAuth protects the API with basic authentication through a
+// generated bcrypt htpasswd file, WithMailPitSendAPIAuth gives the Send API
+// its own credentials, and WithMailPitHTTPS serves the API over HTTPS with a
+// certificate from the pool's CA. Such tests get a dedicated container, and
+// the clients are configured to match:
+//
+//	testSMTP := GetTestSMTP(t,
+//		WithMailPitUIAuth("admin", "secret"),
+//		WithMailPitSendAPIAuth("sender", "send-secret"),
+//		WithMailPitHTTPS(),
+//	)
+//
+//	info, err := testSMTP.MailpitClient.GetServerInfo(ctx) // https, admin:secret
+//	sent, err := testSMTP.SendClient.SendMessage(ctx, req) // sender:send-secret
+//
 // # Complete E2E Test Example
 //
 // Here's a comprehensive example showing proper e2e test structure:
@@ -203,6 +335,9 @@
 // The testing package supports these environment variables:
 //
 //	TEST_SMTP_POOL_SIZE=5    # Container pool size (default: 5)
+//	TEST_SMTP_BACKEND=auto   # auto, docker or binary (default: auto)
+//	TEST_SMTP_MAILPIT_BINARY=/usr/local/bin/mailpit  # mailpit binary (default: looked up in PATH)
+//	TEST_SMTP_DUMP_DIR=artifacts/mailpit  # dump failed tests' mailboxes here (empty: test output)
 //
 // # Dependencies
 //
@@ -210,7 +345,6 @@
 // - Docker daemon running
 // - testcontainers-go library
 // - Mailpit Docker image (axllent/mailpit:latest)
-// - TLS certificates in certs/ directory (generated via make mkcert-generate)
 //
 // This testing framework ensures reliable, fast, and isolated e2e testing for the
 // mailpitclient library while maintaining production-level quality standards.
@@ -218,29 +352,31 @@ package testing
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
 	"log"
-	"net"
 	"net/http"
 	"os"
-	"path/filepath"
 	"strconv"
 	"sync"
 	"testing"
 	"time"
 
 	"github.com/testcontainers/testcontainers-go"
-	"github.com/testcontainers/testcontainers-go/wait"
 
 	"github.com/CodeLieutenant/mailpitclient"
 )
 
-// SMTPContainerPool manages a pool of SMTP containers
+// SMTPContainerPool manages a pool of Mailpit instances started with RunMailpit
 type SMTPContainerPool struct {
-	available  chan testcontainers.Container
-	containers []testcontainers.Container
-	maxSize    int
-	created    int
-	mu         sync.RWMutex
+	available  chan *Mailpit
+	containers []*Mailpit
+	// ca issues the SMTP certificates of the pool's containers
+	ca      *TestCA
+	maxSize int
+	created int
+	mu      sync.RWMutex
 }
 
 var (
@@ -259,12 +395,23 @@ type SMTPConfig struct {
 
 // TestSMTP holds the test SMTP resources
 type TestSMTP struct {
-	Container     testcontainers.Container
+	Container testcontainers.Container
+	// Mailpit is the pooled instance. Use MailpitClient, which is closed when
+	// the test finishes, rather than Mailpit.Client.
+	Mailpit       *Mailpit
 	MailpitClient mailpitclient.Client
-	SMTPPort      string
-	APIPort       string
-	Host          string
-	SMTPConfig    SMTPConfig
+	// SendClient uses the credentials of WithMailPitSendAPIAuth; without them
+	// it is MailpitClient.
+	SendClient mailpitclient.Client
+	// CertPool and TLSConfig trust the pool's generated CA, so SMTP clients
+	// verify the server certificate. Both are nil when WithMailPitCert and
+	// WithMailPitKey supply the certificate.
+	CertPool   *x509.CertPool
+	TLSConfig  *tls.Config
+	SMTPPort   string
+	APIPort    string
+	Host       string
+	SMTPConfig SMTPConfig
 }
 
 type TestSMTPOptions struct {
@@ -274,6 +421,14 @@ type TestSMTPOptions struct {
 	MailpitEnv          map[string]string
 	MailpitKey          string
 	MailpitCert         string
+	UIUsername          string
+	UIPassword          string
+	SendUsername        string
+	SendPassword        string
+	DumpDir             string
+	MailpitOptions      []MailpitOption
+	HTTPS               bool
+	DumpOnFailure       bool
 }
 
 type Option func(*TestSMTPOptions)
@@ -290,37 +445,79 @@ func WithSMTPConfig(smtpConfig *SMTPConfig) Option {
 	}
 }
 
+// WithMailPitImage runs a different Mailpit image. The test gets a dedicated
+// container.
 func WithMailPitImage(mailPitImage string) Option {
 	return func(opts *TestSMTPOptions) {
 		opts.MailpitImage = mailPitImage
 	}
 }
 
+// WithMailPitEnv sets extra MP_* variables. The test gets a dedicated
+// container.
 func WithMailPitEnv(mailPitEnv map[string]string) Option {
 	return func(opts *TestSMTPOptions) {
 		opts.MailpitEnv = mailPitEnv
 	}
 }
 
+// WithMailPitKey sets the SMTP TLS key file, used with WithMailPitCert. The
+// test gets a dedicated container.
 func WithMailPitKey(mailPitKey string) Option {
 	return func(opts *TestSMTPOptions) {
 		opts.MailpitKey = mailPitKey
 	}
 }
 
+// WithMailPitCert sets the SMTP TLS certificate file, used with
+// WithMailPitKey. The test gets a dedicated container.
 func WithMailPitCert(mailPitCert string) Option {
 	return func(opts *TestSMTPOptions) {
 		opts.MailpitCert = mailPitCert
 	}
 }
 
+// WithMailpitOptions applies typed RunMailpit options, such as WithChaos or
+// WithSMTPAuth. The test gets a dedicated container.
+func WithMailpitOptions(opts ...MailpitOption) Option {
+	return func(o *TestSMTPOptions) {
+		o.MailpitOptions = append(o.MailpitOptions, opts...)
+	}
+}
+
+// WithMailPitUIAuth protects the API with basic authentication. The test gets
+// a dedicated container and MailpitClient is configured with the credentials.
+func WithMailPitUIAuth(username, password string) Option {
+	return func(o *TestSMTPOptions) {
+		o.UIUsername = username
+		o.UIPassword = password
+	}
+}
+
+// WithMailPitSendAPIAuth protects the Send API with separate credentials,
+// which TestSMTP.SendClient uses. The test gets a dedicated container.
+func WithMailPitSendAPIAuth(username, password string) Option {
+	return func(o *TestSMTPOptions) {
+		o.SendUsername = username
+		o.SendPassword = password
+	}
+}
+
+// WithMailPitHTTPS serves the API over HTTPS with a certificate issued by the
+// pool's CA, which MailpitClient trusts. The test gets a dedicated container.
+func WithMailPitHTTPS() Option {
+	return func(o *TestSMTPOptions) {
+		o.HTTPS = true
+	}
+}
+
 // GetTestSMTP returns a configured SMTP test environment with mailpit container.
-// It uses a singleton container for efficiency and proper resource management.
+// With default options it borrows a container from a shared pool for
+// efficiency; options that change the Mailpit configuration start a dedicated
+// container that is terminated when the test finishes.
 func GetTestSMTP(tb testing.TB, opts ...Option) *TestSMTP {
 	tb.Helper()
 
-	ctx := tb.Context()
-
 	testOpts := TestSMTPOptions{
 		MailPitClientConfig: &mailpitclient.Config{
 			APIPath:    "/api/v1",
@@ -332,65 +529,127 @@ func GetTestSMTP(tb testing.TB, opts ...Option) *TestSMTP {
 		},
 	}
 
+	if dir, ok := os.LookupEnv(DumpDirEnv); ok {
+		testOpts.DumpOnFailure = true
+		testOpts.DumpDir = dir
+	}
+
 	for _, opt := range opts {
 		opt(&testOpts)
 	}
 
-	// Use pooled container for parallel testing support
-	container := getSMTPContainerFromPool(tb,
-		testOpts.MailpitImage,
-		testOpts.MailpitEnv,
-		testOpts.MailpitKey,
-		testOpts.MailpitCert,
-	)
-	tb.Cleanup(func() {
-		releaseSMTPContainerToPool(container)
-	})
+	var mp *Mailpit
+	if testOpts.dedicated() {
+		// Pooled containers are shared and keep their configuration, so a
+		// test asking for anything but the defaults gets its own.
+		initSMTPContainerPool(tb)
+		mp = NewMailpit(tb, testOpts.mailpitOptions(smtpContainerPool.ca)...)
+	} else {
+		// Use pooled container for parallel testing support
+		mp = getSMTPContainerFromPool(tb)
+		tb.Cleanup(func() {
+			releaseSMTPContainerToPool(mp)
+		})
+	}
 
-	// Get the mapped ports
-	smtpPort, err := container.MappedPort(ctx, "1025")
-	if err != nil {
-		tb.Fatalf("Failed to get SMTP port: %v", err)
+	testOpts.SMTPConfig.Host = mp.Host
+	testOpts.SMTPConfig.Port = mp.SMTPConfig().Port
+
+	clientConfig := mp.ClientConfig(testOpts.MailPitClientConfig)
+	mailpitClient := newTestClient(tb, clientConfig)
+
+	sendClient := mailpitClient
+	if testOpts.SendUsername != "" {
+		sendConfig := *clientConfig
+		sendConfig.Username = testOpts.SendUsername
+		sendConfig.Password = testOpts.SendPassword
+		sendClient = newTestClient(tb, &sendConfig)
 	}
 
-	apiPort, err := container.MappedPort(ctx, "8025")
-	if err != nil {
-		tb.Fatalf("Failed to get API port: %v", err)
+	testSMTP := &TestSMTP{
+		Container:     mp.Container,
+		Mailpit:       mp,
+		CertPool:      mp.CertPool(),
+		TLSConfig:     mp.TLSConfig(),
+		SMTPConfig:    *testOpts.SMTPConfig,
+		MailpitClient: mailpitClient,
+		SendClient:    sendClient,
+		SMTPPort:      mp.SMTPPort,
+		APIPort:       mp.APIPort,
+		Host:          mp.Host,
 	}
 
-	// Get the container host
-	host, err := container.Host(ctx)
-	if err != nil {
-		tb.Fatalf("Failed to get container host: %v", err)
+	// Registered last so it runs before the clients are closed and the
+	// container is released.
+	if testOpts.DumpOnFailure {
+		testSMTP.DumpOnFailure(tb, testOpts.DumpDir)
 	}
 
-	testOpts.MailPitClientConfig.BaseURL = "http://" + net.JoinHostPort(host, apiPort.Port())
-	testOpts.SMTPConfig.Host = host
-	// #nosec G115
-	testOpts.SMTPConfig.Port = uint16(smtpPort.Int())
+	return testSMTP
+}
+
+// newTestClient creates a client that is closed when the test finishes.
+func newTestClient(tb testing.TB, config *mailpitclient.Config) mailpitclient.Client {
+	tb.Helper()
 
-	mailpitClient, err := mailpitclient.NewClient(testOpts.MailPitClientConfig)
+	client, err := mailpitclient.NewClient(config)
 	if err != nil {
 		tb.Fatalf("Failed to create mailpit client: %v", err)
 	}
 
 	// Setup per-test cleanup
 	tb.Cleanup(func() {
-		if mailpitClient != nil {
-			if err = mailpitClient.Close(); err != nil {
-				tb.Errorf("Failed to close mailpit client: %v", err)
-			}
+		if err = client.Close(); err != nil {
+			tb.Errorf("Failed to close mailpit client: %v", err)
 		}
 	})
 
-	return &TestSMTP{
-		Container:     container,
-		SMTPConfig:    *testOpts.SMTPConfig,
-		MailpitClient: mailpitClient,
-		SMTPPort:      smtpPort.Port(),
-		APIPort:       apiPort.Port(),
-		Host:          host,
+	return client
+}
+
+// dedicated reports whether the options change the Mailpit configuration and
+// so need a container that is not shared through the pool.
+func (o *TestSMTPOptions) dedicated() bool {
+	return o.UIUsername != "" || o.SendUsername != "" || o.HTTPS ||
+		o.MailpitImage != "" || len(o.MailpitEnv) > 0 ||
+		o.MailpitCert != "" || o.MailpitKey != "" ||
+		len(o.MailpitOptions) > 0
+}
+
+// mailpitOptions translates the options into RunMailpit options, with the
+// pool's defaults and a certificate issued by the pool's CA.
+func (o *TestSMTPOptions) mailpitOptions(ca *TestCA) []MailpitOption {
+	env := map[string]string{
+		"MP_SMTP_REQUIRE_STARTTLS": "false", // Allow both TLS and non-TLS connections
+		"MP_ENABLE_SPAMASSASSIN":   "true",
+		"MP_SMTP_8BITMIME":         "1", // Enable 8BITMIME support
+	}
+
+	opts := []MailpitOption{WithEnv(env), WithEnv(o.MailpitEnv)}
+
+	if o.MailpitImage != "" {
+		opts = append(opts, WithImage(o.MailpitImage))
+	}
+
+	if o.MailpitCert != "" && o.MailpitKey != "" {
+		opts = append(opts, WithSMTPTLS(o.MailpitCert, o.MailpitKey, false))
+	} else {
+		opts = append(opts, WithGeneratedTLS(ca, false))
+	}
+
+	if o.UIUsername != "" {
+		opts = append(opts, WithUIAuth(o.UIUsername, o.UIPassword))
+	}
+
+	if o.SendUsername != "" {
+		opts = append(opts, WithSendAPIAuth(o.SendUsername, o.SendPassword))
 	}
+
+	if o.HTTPS {
+		opts = append(opts, WithHTTPS(ca))
+	}
+
+	return append(opts, o.MailpitOptions...)
 }
 
 // initSMTPContainerPool initializes the SMTP container pool structure (lazy creation)
@@ -412,19 +671,26 @@ func initSMTPContainerPool(tb testing.TB) {
 		}
 	}
 
+	ca, err := NewTestCA()
+	if err != nil {
+		tb.Fatalf("Failed to generate test CA: %v", err)
+	}
+
 	smtpContainerPool = &SMTPContainerPool{
-		containers: make([]testcontainers.Container, 0, poolSize),
-		available:  make(chan testcontainers.Container, poolSize),
+		ca:         ca,
+		containers: make([]*Mailpit, 0, poolSize),
+		available:  make(chan *Mailpit, poolSize),
 		maxSize:    poolSize,
 		created:    0,
 	}
 }
 
 // getSMTPContainerFromPool gets a container from the pool, creating one lazily if needed
-func getSMTPContainerFromPool(tb testing.TB, image string, envs map[string]string, keyPath, crtPath string) testcontainers.Container {
+func getSMTPContainerFromPool(tb testing.TB) *Mailpit {
 	tb.Helper()
 
 	initSMTPContainerPool(tb)
+	opts := (&TestSMTPOptions{}).mailpitOptions(smtpContainerPool.ca)
 
 	// Try to get an available container first (non-blocking)
 	select {
@@ -442,88 +708,26 @@ func getSMTPContainerFromPool(tb testing.TB, image string, envs map[string]strin
 	}
 	smtpContainerPool.mu.Unlock()
 
-	//nolint:nestif
 	if canCreate {
 		// Create a new container lazily
-		ctx := tb.Context()
-
-		// Get project root and certificates directory
-		certsPath := filepath.Join(projectRootDir(tb), "certs")
-
-		if image == "" {
-			image = "axllent/mailpit:latest"
-		}
-
-		defaultEnv := map[string]string{
-			"MP_SMTP_REQUIRE_STARTTLS":    "false", // Allow both TLS and non-TLS connections
-			"MP_ENABLE_SPAMASSASSIN":      "true",
-			"MP_SMTP_AUTH_ACCEPT_ANY":     "1",
-			"MP_SMTP_AUTH_ALLOW_INSECURE": "1",
-			"MP_SMTP_8BITMIME":            "1", // Enable 8BITMIME support
-		}
-
-		for k, v := range envs {
-			defaultEnv[k] = v
-		}
-
-		if keyPath == "" {
-			keyPath = filepath.Join(certsPath, "smtp.key")
-		}
-
-		if crtPath == "" {
-			crtPath = filepath.Join(certsPath, "smtp.crt")
-		}
-
-		files := make([]testcontainers.ContainerFile, 0, 2)
-		if _, err := os.Stat(crtPath); err == nil {
-			files = append(files, testcontainers.ContainerFile{
-				HostFilePath:      crtPath,
-				ContainerFilePath: "/certs/smtp.crt",
-			})
-
-			defaultEnv["MP_SMTP_TLS_CERT"] = "/certs/smtp.crt"
-		}
-
-		if _, err := os.Stat(keyPath); err == nil {
-			files = append(files, testcontainers.ContainerFile{
-				HostFilePath:      keyPath,
-				ContainerFilePath: "/certs/smtp.key",
-			})
-
-			defaultEnv["MP_SMTP_TLS_KEY"] = "/certs/smtp.key"
-		}
-
-		// Create mailpit container request
-		req := testcontainers.ContainerRequest{
-			Image:        image,
-			ExposedPorts: []string{"1025/tcp", "8025/tcp"},
-			WaitingFor: wait.ForAll(
-				wait.ForListeningPort("1025/tcp"),
-				wait.ForListeningPort("8025/tcp"),
-				wait.ForHTTP("/api/v1/info").WithPort("8025/tcp").WithStartupTimeout(30*time.Second),
-			),
-			Env:   defaultEnv,
-			Files: files,
-		}
-
-		// Start the container
-		container, err := testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
-			ContainerRequest: req,
-			Started:          true,
-		})
+		mp, err := RunMailpit(tb.Context(), opts...)
 		if err != nil {
 			// Decrement counter on failure
 			smtpContainerPool.mu.Lock()
 			smtpContainerPool.created--
 			smtpContainerPool.mu.Unlock()
+
+			if errors.Is(err, ErrNoBackend) {
+				tb.Skipf("Skipping: %v", err)
+			}
 			tb.Fatalf("Failed to start mailpit container: %v", err)
 		}
 
 		smtpContainerPool.mu.Lock()
-		smtpContainerPool.containers = append(smtpContainerPool.containers, container)
+		smtpContainerPool.containers = append(smtpContainerPool.containers, mp)
 		smtpContainerPool.mu.Unlock()
 
-		return container
+		return mp
 	}
 
 	// Wait for an available container (blocking)
@@ -538,8 +742,8 @@ func getSMTPContainerFromPool(tb testing.TB, image string, envs map[string]strin
 }
 
 // releaseSMTPContainerToPool returns a container to the pool
-func releaseSMTPContainerToPool(container testcontainers.Container) {
-	smtpContainerPool.available <- container
+func releaseSMTPContainerToPool(mp *Mailpit) {
+	smtpContainerPool.available <- mp
 }
 
 // ClearMessages is a helper function to clear all messages from mailpit
@@ -552,7 +756,7 @@ func (ts *TestSMTP) ClearMessages(tb testing.TB) {
 }
 
 // GetMessages is a helper function to retrieve all messages from mailpit
-func (ts *TestSMTP) GetMessages(tb testing.TB) []mailpitclient.Message {
+func (ts *TestSMTP) GetMessages(tb testing.TB) []mailpitclient.MessageSummary {
 	tb.Helper()
 	resp, err := ts.MailpitClient.ListMessages(tb.Context(), nil)
 	if err != nil {
@@ -563,7 +767,7 @@ func (ts *TestSMTP) GetMessages(tb testing.TB) []mailpitclient.Message {
 }
 
 // WaitForMessages waits for the expected number of messages to arrive
-func (ts *TestSMTP) WaitForMessages(tb testing.TB, expectedCount int, timeout time.Duration) []mailpitclient.Message {
+func (ts *TestSMTP) WaitForMessages(tb testing.TB, expectedCount int, timeout time.Duration) []mailpitclient.MessageSummary {
 	tb.Helper()
 
 	ctx, cancel := context.WithTimeout(tb.Context(), timeout)
@@ -602,91 +806,13 @@ func CleanupSMTPContainers() {
 	close(smtpContainerPool.available)
 
 	// Terminate all containers
-	for _, c := range smtpContainerPool.containers {
-		go func(container testcontainers.Container) {
-			if err := container.Terminate(ctx); err != nil {
+	for _, mp := range smtpContainerPool.containers {
+		go func(mp *Mailpit) {
+			if err := mp.Terminate(ctx); err != nil {
 				log.Printf("Failed to terminate container: %v", err)
 			}
-		}(c)
+		}(mp)
 	}
 
 	smtpContainerPool = nil
 }
-
-const gomod = "go.mod"
-
-var (
-	projectRootDirCache   = make(map[string]string, 10)
-	projectRootDirCacheMu sync.RWMutex
-)
-
-func projectRootDir(tb testing.TB) string {
-	tb.Helper()
-	originalWorkingDir := workingDir(tb)
-	wd := originalWorkingDir
-
-	projectRootDirCacheMu.RLock()
-	if dir, ok := projectRootDirCache[originalWorkingDir]; ok {
-		projectRootDirCacheMu.RUnlock()
-
-		return dir
-	}
-	projectRootDirCacheMu.RUnlock()
-
-	for entries, err := os.ReadDir(wd); err == nil; {
-		for _, entry := range entries {
-			if entry.Name() == gomod {
-				projectRootDirCacheMu.Lock()
-				projectRootDirCache[originalWorkingDir] = wd
-				projectRootDirCacheMu.Unlock()
-
-				return wd
-			}
-		}
-
-		if wd == "/" {
-			tb.Error("got to FS Root, file not found")
-			tb.FailNow()
-		}
-
-		wd, err = getAbsolutePath(filepath.Join(wd, ".."))
-		if err != nil {
-			tb.Errorf("failed to get absolute path from %s", filepath.Join(wd, ".."))
-			tb.FailNow()
-		}
-
-		entries, err = os.ReadDir(wd)
-	}
-
-	tb.Errorf("%s not found", gomod)
-	tb.FailNow()
-
-	return ""
-}
-
-func workingDir(tb testing.TB) string {
-	tb.Helper()
-	wd, err := os.Getwd()
-	if err != nil {
-		tb.Error(err)
-		tb.FailNow()
-	}
-
-	return wd
-}
-
-// getAbsolutePath Returns absolute path string for a given directory and error if directory doesent exist
-func getAbsolutePath(path string) (string, error) {
-	var err error
-
-	if !filepath.IsAbs(path) {
-		path, err = filepath.Abs(path)
-		if err != nil {
-			return "", err
-		}
-
-		return path, nil
-	}
-
-	return path, err
-}
@@ -0,0 +1,80 @@
+package testing
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/CodeLieutenant/mailpitclient"
+)
+
+func TestGetTestSMTP_Secured(t *testing.T) {
+	t.Parallel()
+
+	testSMTP := GetTestSMTP(t,
+		WithMailPitUIAuth("admin", "secret"),
+		WithMailPitSendAPIAuth("sender", "send-secret"),
+		WithMailPitHTTPS(),
+		WithMailpitOptions(
+			WithBackend(BackendBinary),
+			WithBinary(os.Args[0]),
+			WithEnv(map[string]string{fakeMailpitEnv: "fake"}),
+			WithStartupTimeout(10*time.Second),
+		),
+	)
+
+	smtpContainerPool.mu.RLock()
+	require.NotContains(t, smtpContainerPool.containers, testSMTP.Mailpit, "dedicated instances are not pooled")
+	require.Same(t, smtpContainerPool.ca, testSMTP.Mailpit.options.CA)
+	smtpContainerPool.mu.RUnlock()
+	require.NotNil(t, testSMTP.TLSConfig)
+
+	info, err := testSMTP.MailpitClient.GetServerInfo(t.Context())
+	require.NoError(t, err)
+	require.Equal(t, "fake", info.Version)
+
+	send := &mailpitclient.SendMessageRequest{
+		From:    mailpitclient.Address{Address: "sender@example.com"},
+		To:      []mailpitclient.Address{{Address: "to@example.com"}},
+		Subject: "Hi",
+	}
+	_, err = testSMTP.MailpitClient.SendMessage(t.Context(), send)
+	require.Error(t, err)
+
+	sent, err := testSMTP.SendClient.SendMessage(t.Context(), send)
+	require.NoError(t, err)
+	require.Equal(t, "sent", sent.ID)
+}
+
+func TestTestSMTPOptions_Dedicated(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		opt  Option
+		want bool
+	}{
+		{"defaults", func(*TestSMTPOptions) {}, false},
+		{"client config", WithMailPitClientConfig(&mailpitclient.Config{}), false},
+		{"ui auth", WithMailPitUIAuth("u", "p"), true},
+		{"send auth", WithMailPitSendAPIAuth("u", "p"), true},
+		{"https", WithMailPitHTTPS(), true},
+		{"image", WithMailPitImage("axllent/mailpit:v1.27"), true},
+		{"env", WithMailPitEnv(map[string]string{"MP_MAX_MESSAGES": "5"}), true},
+		{"cert", WithMailPitCert("cert.pem"), true},
+		{"key", WithMailPitKey("key.pem"), true},
+		{"mailpit options", WithMailpitOptions(WithMaxMessages(5)), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var opts TestSMTPOptions
+			tt.opt(&opts)
+			require.Equal(t, tt.want, opts.dedicated())
+		})
+	}
+}
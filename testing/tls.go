@@ -0,0 +1,187 @@
+package testing
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const testCAValidity = 24 * time.Hour
+
+// TestCA is an ephemeral certificate authority, kept in memory, that issues
+// server certificates for test servers. Clients trust it through CertPool or
+// TLSConfig, so TLS is verified without InsecureSkipVerify.
+type TestCA struct {
+	cert    *x509.Certificate
+	key     *ecdsa.PrivateKey
+	pool    *x509.CertPool
+	certPEM []byte
+}
+
+// NewTestCA generates a CA valid for a day.
+func NewTestCA() (*TestCA, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate CA key: %w", err)
+	}
+
+	serial, err := randomSerial()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{Organization: []string{"mailpitclient testing"}, CommonName: "mailpitclient test CA"},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(testCAValidity),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		MaxPathLenZero:        true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create CA certificate: %w", err)
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse CA certificate: %w", err)
+	}
+
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+
+	return &TestCA{
+		cert:    cert,
+		key:     key,
+		pool:    pool,
+		certPEM: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+	}, nil
+}
+
+// CertPool returns a pool containing only the CA certificate.
+func (ca *TestCA) CertPool() *x509.CertPool {
+	return ca.pool
+}
+
+// CertPEM returns the PEM-encoded CA certificate.
+func (ca *TestCA) CertPEM() []byte {
+	return ca.certPEM
+}
+
+// TLSConfig returns a client configuration that trusts the CA and verifies
+// serverName.
+func (ca *TestCA) TLSConfig(serverName string) *tls.Config {
+	return &tls.Config{
+		RootCAs:    ca.pool,
+		ServerName: serverName,
+		MinVersion: tls.VersionTLS12,
+	}
+}
+
+// IssueServerCert returns a PEM-encoded server certificate and key for hosts,
+// which may be DNS names or IP addresses.
+func (ca *TestCA) IssueServerCert(hosts ...string) ([]byte, []byte, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to generate server key: %w", err)
+	}
+
+	serial, err := randomSerial()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{Organization: []string{"mailpitclient testing"}},
+		NotBefore:    ca.cert.NotBefore,
+		NotAfter:     ca.cert.NotAfter,
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+
+	for _, host := range hosts {
+		if ip := net.ParseIP(host); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, host)
+		}
+	}
+
+	if len(hosts) > 0 {
+		template.Subject.CommonName = hosts[0]
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create server certificate: %w", err)
+	}
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to encode server key: %w", err)
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+		nil
+}
+
+// WriteServerCert issues a server certificate for hosts and writes it and its
+// key to smtp.crt and smtp.key in dir.
+func (ca *TestCA) WriteServerCert(dir string, hosts ...string) (string, string, error) {
+	certPEM, keyPEM, err := ca.IssueServerCert(hosts...)
+	if err != nil {
+		return "", "", err
+	}
+
+	certFile := filepath.Join(dir, "smtp.crt")
+	keyFile := filepath.Join(dir, "smtp.key")
+
+	if err = os.WriteFile(certFile, certPEM, 0o600); err != nil {
+		return "", "", fmt.Errorf("failed to write server certificate: %w", err)
+	}
+
+	if err = os.WriteFile(keyFile, keyPEM, 0o600); err != nil {
+		return "", "", fmt.Errorf("failed to write server key: %w", err)
+	}
+
+	return certFile, keyFile, nil
+}
+
+// serverCertHosts are the names a test server is reached by: the loopback
+// addresses, the Docker host alias and the host of a remote DOCKER_HOST.
+func serverCertHosts() []string {
+	hosts := []string{"localhost", "127.0.0.1", "::1", dockerHostAlias}
+
+	if u, err := url.Parse(os.Getenv("DOCKER_HOST")); err == nil && u.Scheme == "tcp" && u.Hostname() != "" {
+		hosts = append(hosts, u.Hostname())
+	}
+
+	return hosts
+}
+
+func randomSerial() (*big.Int, error) {
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate serial number: %w", err)
+	}
+
+	return serial, nil
+}
@@ -0,0 +1,104 @@
+package testing
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTestCA_IssueServerCert(t *testing.T) {
+	t.Parallel()
+
+	ca, err := NewTestCA()
+	require.NoError(t, err)
+
+	certPEM, keyPEM, err := ca.IssueServerCert("localhost", "127.0.0.1")
+	require.NoError(t, err)
+
+	block, _ := pem.Decode(certPEM)
+	require.NotNil(t, block)
+	cert, err := x509.ParseCertificate(block.Bytes)
+	require.NoError(t, err)
+	require.Equal(t, []string{"localhost"}, cert.DNSNames)
+	require.Len(t, cert.IPAddresses, 1)
+
+	pair, err := tls.X509KeyPair(certPEM, keyPEM)
+	require.NoError(t, err)
+
+	l, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{Certificates: []tls.Certificate{pair}, MinVersion: tls.VersionTLS12})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = l.Close() })
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			_ = conn.(*tls.Conn).Handshake() //nolint:forcetypeassert // tls.Listen returns TLS connections
+			_ = conn.Close()
+		}
+	}()
+
+	tests := []struct {
+		name       string
+		config     *tls.Config
+		shouldFail bool
+	}{
+		{name: "trusted IP", config: ca.TLSConfig("127.0.0.1")},
+		{name: "trusted name", config: ca.TLSConfig("localhost")},
+		{name: "wrong name", config: ca.TLSConfig("example.com"), shouldFail: true},
+		{name: "system roots", config: &tls.Config{ServerName: "localhost", MinVersion: tls.VersionTLS12}, shouldFail: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			dialer := &tls.Dialer{Config: tt.config}
+			conn, err := dialer.DialContext(t.Context(), "tcp", l.Addr().String())
+			if tt.shouldFail {
+				require.Error(t, err)
+
+				return
+			}
+
+			require.NoError(t, err)
+			require.NoError(t, conn.Close())
+		})
+	}
+}
+
+func TestTestCA_WriteServerCert(t *testing.T) {
+	t.Parallel()
+
+	ca, err := NewTestCA()
+	require.NoError(t, err)
+	require.True(t, ca.CertPool().Equal(ca.TLSConfig("localhost").RootCAs))
+
+	block, _ := pem.Decode(ca.CertPEM())
+	require.NotNil(t, block)
+	caCert, err := x509.ParseCertificate(block.Bytes)
+	require.NoError(t, err)
+	require.True(t, caCert.IsCA)
+
+	certFile, keyFile, err := ca.WriteServerCert(t.TempDir(), serverCertHosts()...)
+	require.NoError(t, err)
+
+	pair, err := tls.LoadX509KeyPair(certFile, keyFile)
+	require.NoError(t, err)
+
+	_, err = pair.Leaf.Verify(x509.VerifyOptions{
+		DNSName: dockerHostAlias,
+		Roots:   ca.CertPool(),
+	})
+	require.NoError(t, err)
+
+	info, err := os.Stat(keyFile)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+}
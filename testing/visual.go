@@ -0,0 +1,159 @@
+package testing
+
+import (
+	"context"
+	"errors"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/CodeLieutenant/mailpitclient"
+)
+
+// VisualFailureDir is where AssertImage writes the actual and diff images of
+// a failed comparison, relative to the package under test. Add it to
+// .gitignore and upload it as a CI artefact.
+var VisualFailureDir = filepath.Join(SnapshotDir, "failures")
+
+// AssertImage compares actual against the baseline testdata/<name>.png using
+// mailpitclient.CompareImages. On mismatch it writes <name>.actual.png and
+// <name>.diff.png (differing pixels in red) to VisualFailureDir and fails the
+// test. Set MAILPIT_UPDATE_SNAPSHOTS=1 to (re)write the baseline. opts may be
+// nil.
+func AssertImage(tb testing.TB, name string, actual image.Image, opts *mailpitclient.ImageCompareOptions) *mailpitclient.ImageDiff {
+	tb.Helper()
+
+	path := filepath.Join(SnapshotDir, filepath.FromSlash(name)+".png")
+
+	if updateSnapshots() {
+		if err := writePNG(path, actual); err != nil {
+			tb.Fatalf("Failed to write baseline %s: %v", path, err)
+		}
+
+		return nil
+	}
+
+	baseline, err := readImage(path)
+	if err != nil {
+		tb.Fatalf("Failed to read baseline %s (set %s=1 to create it): %v", path, UpdateSnapshotsEnv, err)
+
+		return nil
+	}
+
+	diff := mailpitclient.CompareImages(baseline, actual, opts)
+
+	actualPath := filepath.Join(VisualFailureDir, filepath.FromSlash(name)+".actual.png")
+	diffPath := filepath.Join(VisualFailureDir, filepath.FromSlash(name)+".diff.png")
+
+	if diff.Match {
+		_ = os.Remove(actualPath)
+		_ = os.Remove(diffPath)
+
+		return diff
+	}
+
+	if err := errors.Join(writePNG(actualPath, actual), writePNG(diffPath, diff.Diff)); err != nil {
+		tb.Logf("Failed to write image diff: %v", err)
+	}
+
+	tb.Errorf("Image %s does not match baseline %s: %s\n  actual: %s\n  diff:   %s", name, path, diff, actualPath, diffPath)
+
+	return diff
+}
+
+// AssertMessageImage fetches an image part of a message, such as an inline
+// logo, and compares it with AssertImage. part is the part's file name,
+// content ID or part ID (see mailpitclient.Message.FindPart).
+func AssertMessageImage(tb testing.TB, c mailpitclient.Client, messageID, part, name string, opts *mailpitclient.ImageCompareOptions) *mailpitclient.ImageDiff {
+	tb.Helper()
+
+	return assertPartImage(tb, c, messageID, part, name, opts, mailpitclient.MessagePartImage)
+}
+
+// AssertMessageThumbnail compares the thumbnail Mailpit renders for an image
+// part of a message with AssertImage. Thumbnails are small enough to store
+// as baselines while still catching a replaced or broken banner.
+func AssertMessageThumbnail(tb testing.TB, c mailpitclient.Client, messageID, part, name string, opts *mailpitclient.ImageCompareOptions) *mailpitclient.ImageDiff {
+	tb.Helper()
+
+	return assertPartImage(tb, c, messageID, part, name, opts, mailpitclient.MessagePartThumbnail)
+}
+
+// AssertMessageImage is AssertMessageImage using the TestSMTP client.
+func (ts *TestSMTP) AssertMessageImage(tb testing.TB, messageID, part, name string, opts *mailpitclient.ImageCompareOptions) *mailpitclient.ImageDiff {
+	tb.Helper()
+
+	return AssertMessageImage(tb, ts.MailpitClient, messageID, part, name, opts)
+}
+
+// AssertMessageThumbnail is AssertMessageThumbnail using the TestSMTP client.
+func (ts *TestSMTP) AssertMessageThumbnail(tb testing.TB, messageID, part, name string, opts *mailpitclient.ImageCompareOptions) *mailpitclient.ImageDiff {
+	tb.Helper()
+
+	return AssertMessageThumbnail(tb, ts.MailpitClient, messageID, part, name, opts)
+}
+
+type partImageFunc func(ctx context.Context, c mailpitclient.Client, messageID, partID string) (image.Image, error)
+
+func assertPartImage(
+	tb testing.TB,
+	c mailpitclient.Client,
+	messageID, part, name string,
+	opts *mailpitclient.ImageCompareOptions,
+	fetch partImageFunc,
+) *mailpitclient.ImageDiff {
+	tb.Helper()
+
+	msg, err := c.GetMessage(tb.Context(), messageID)
+	if err != nil {
+		tb.Fatalf("Failed to get message %s: %v", messageID, err)
+
+		return nil
+	}
+
+	p, err := msg.FindPart(part)
+	if err != nil {
+		tb.Fatalf("Failed to find image part: %v", err)
+
+		return nil
+	}
+
+	img, err := fetch(tb.Context(), c, messageID, p.PartID)
+	if err != nil {
+		tb.Fatalf("Failed to fetch image %s of message %s: %v", part, messageID, err)
+
+		return nil
+	}
+
+	return AssertImage(tb, name, img, opts)
+}
+
+func readImage(path string) (image.Image, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return mailpitclient.DecodeImage(data)
+}
+
+func writePNG(path string, img image.Image) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if err := png.Encode(f, img); err != nil {
+		_ = f.Close()
+
+		return err
+	}
+
+	return f.Close()
+}
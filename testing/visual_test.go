@@ -0,0 +1,103 @@
+package testing
+
+import (
+	"bytes"
+	"encoding/json"
+	"image"
+	"image/color"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/CodeLieutenant/mailpitclient"
+)
+
+// visualTestLogo returns a 16×16 logo: a coloured square on white.
+func visualTestLogo(fill color.Color) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, 16, 16))
+	for y := range 16 {
+		for x := range 16 {
+			c := color.Color(color.White)
+			if x >= 4 && x < 12 && y >= 4 && y < 12 {
+				c = fill
+			}
+			img.Set(x, y, c)
+		}
+	}
+
+	return img
+}
+
+func TestAssertMessageImage(t *testing.T) {
+	t.Parallel()
+
+	var logo bytes.Buffer
+	require.NoError(t, png.Encode(&logo, visualTestLogo(color.RGBA{R: 220, G: 40, B: 40, A: 255})))
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/message/msg-1":
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(mailpitclient.Message{
+				ID:     "msg-1",
+				Inline: mailpitclient.AttachmentList{{PartID: "2", FileName: "logo.png", ContentID: "logo@example.com"}},
+			})
+		case "/api/v1/message/msg-1/part/2", "/api/v1/message/msg-1/part/2/thumb":
+			_, _ = w.Write(logo.Bytes())
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	c, err := mailpitclient.NewClient(&mailpitclient.Config{
+		BaseURL:    server.URL,
+		APIPath:    "/api/v1",
+		HTTPClient: &http.Client{Timeout: 5 * time.Second},
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = c.Close() })
+
+	ts := &TestSMTP{MailpitClient: c}
+	ts.AssertMessageImage(t, "msg-1", "logo.png", "logo", nil)
+	ts.AssertMessageThumbnail(t, "msg-1", "<logo@example.com>", "logo", nil)
+}
+
+func TestAssertImage_Mismatch(t *testing.T) {
+	t.Parallel()
+
+	name := "logo-mismatch"
+	baseline := filepath.Join(SnapshotDir, name+".png")
+	require.NoError(t, writePNG(baseline, visualTestLogo(color.RGBA{R: 220, G: 40, B: 40, A: 255})))
+	t.Cleanup(func() {
+		_ = os.Remove(baseline)
+		_ = os.RemoveAll(VisualFailureDir)
+	})
+
+	rec := &recordingTB{TB: t}
+	diff := AssertImage(rec, name, visualTestLogo(color.RGBA{G: 160, A: 255}), nil)
+	require.True(t, rec.failed)
+	require.Contains(t, rec.msg, "64 of 256 pixels differ")
+	require.False(t, diff.Match)
+
+	written, err := readImage(filepath.Join(VisualFailureDir, name+".diff.png"))
+	require.NoError(t, err)
+	require.Equal(t, color.RGBA{R: 255, A: 255}, color.RGBAModel.Convert(written.At(8, 8)))
+
+	_, err = os.Stat(filepath.Join(VisualFailureDir, name+".actual.png"))
+	require.NoError(t, err)
+
+	// A passing run removes the stale failure images.
+	rec = &recordingTB{TB: t}
+	AssertImage(rec, name, visualTestLogo(color.RGBA{R: 220, G: 40, B: 40, A: 255}), nil)
+	require.False(t, rec.failed)
+
+	_, err = os.Stat(filepath.Join(VisualFailureDir, name+".actual.png"))
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
@@ -2,6 +2,8 @@ package mailpitclient
 
 import (
 	"encoding/json"
+	"fmt"
+	"net/http"
 	"net/url"
 	"strconv"
 	"time"
@@ -35,24 +37,46 @@ func (al *AttachmentList) UnmarshalJSON(data []byte) error {
 }
 
 // Message represents an email message in Mailpit.
+// It mirrors the detailed message returned by the /message/{ID} endpoint.
 type Message struct {
-	Date        time.Time      `json:"Date"`
-	Created     time.Time      `json:"Created"`
-	From        Address        `json:"From"`
-	MessageID   string         `json:"MessageID"`
-	ID          string         `json:"ID"`
-	HTML        string         `json:"HTML"`
-	Text        string         `json:"Text"`
-	Subject     string         `json:"Subject"`
-	Cc          []Address      `json:"Cc,omitempty"`
-	ReplyTo     []Address      `json:"ReplyTo,omitempty"`
-	Bcc         []Address      `json:"Bcc,omitempty"`
-	Inline      AttachmentList `json:"Inline,omitempty"`
-	Attachments AttachmentList `json:"Attachments,omitempty"`
-	Tags        []string       `json:"Tags,omitempty"`
-	To          []Address      `json:"To"`
-	Size        int            `json:"Size"`
-	Read        bool           `json:"Read"`
+	Date            time.Time       `json:"Date"`
+	Created         time.Time       `json:"Created"`
+	From            Address         `json:"From"`
+	ListUnsubscribe ListUnsubscribe `json:"ListUnsubscribe"`
+	MessageID       string          `json:"MessageID"`
+	ID              string          `json:"ID"`
+	HTML            string          `json:"HTML"`
+	Text            string          `json:"Text"`
+	Subject         string          `json:"Subject"`
+	ReturnPath      string          `json:"ReturnPath"`
+	Username        string          `json:"Username,omitempty"`
+	Cc              []Address       `json:"Cc,omitempty"`
+	ReplyTo         []Address       `json:"ReplyTo,omitempty"`
+	Bcc             []Address       `json:"Bcc,omitempty"`
+	Inline          AttachmentList  `json:"Inline,omitempty"`
+	Attachments     AttachmentList  `json:"Attachments,omitempty"`
+	Tags            []string        `json:"Tags,omitempty"`
+	To              []Address       `json:"To"`
+	Size            int             `json:"Size"`
+	Read            bool            `json:"Read"`
+}
+
+// AttachmentCount returns the number of regular (non-inline) attachments.
+func (m *Message) AttachmentCount() int {
+	return len(m.Attachments)
+}
+
+// InlineCount returns the number of inline parts, such as embedded images.
+func (m *Message) InlineCount() int {
+	return len(m.Inline)
+}
+
+// ListUnsubscribe represents the parsed List-Unsubscribe header of a message.
+type ListUnsubscribe struct {
+	Header     string   `json:"Header"`
+	HeaderPost string   `json:"HeaderPost"`
+	Errors     string   `json:"Errors"`
+	Links      []string `json:"Links"`
 }
 
 // Address represents an email address with optional name.
@@ -63,21 +87,31 @@ type Address struct {
 
 // Attachment represents an email attachment.
 type Attachment struct {
-	PartID      string `json:"PartID"`
-	FileName    string `json:"FileName"`
-	ContentType string `json:"ContentType"`
-	Size        int    `json:"Size"`
+	Checksums   AttachmentChecksums `json:"Checksums"`
+	PartID      string              `json:"PartID"`
+	FileName    string              `json:"FileName"`
+	ContentType string              `json:"ContentType"`
+	ContentID   string              `json:"ContentID,omitempty"`
+	Size        int                 `json:"Size"`
+}
+
+// AttachmentChecksums holds the checksums Mailpit calculates for an attachment.
+type AttachmentChecksums struct {
+	MD5    string `json:"MD5"`
+	SHA1   string `json:"SHA1"`
+	SHA256 string `json:"SHA256"`
 }
 
-// MessagesResponse represents the response from the messages API.
+// MessagesResponse represents the response from the messages and search APIs.
 type MessagesResponse struct {
-	Tags          []string  `json:"tags"`
-	Messages      []Message `json:"messages"`
-	Total         int       `json:"total"`
-	Unread        int       `json:"unread"`
-	Count         int       `json:"count"`
-	Start         int       `json:"start"`
-	MessagesCount int       `json:"messages_count"`
+	Tags           []string         `json:"tags"`
+	Messages       []MessageSummary `json:"messages"`
+	Total          int              `json:"total"`
+	Unread         int              `json:"unread"`
+	Count          int              `json:"count"`
+	Start          int              `json:"start"`
+	MessagesCount  int              `json:"messages_count"`
+	MessagesUnread int              `json:"messages_unread"`
 }
 
 // ServerInfo represents server information and status.
@@ -187,66 +221,151 @@ func (opts *SearchOptions) ToURLValues() url.Values {
 }
 
 // MessageSummary represents a lightweight version of a message for listings.
+// Unlike Message, Attachments is a count rather than a list of parts.
 type MessageSummary struct {
-	Date    time.Time `json:"Date"`
-	Created time.Time `json:"Created"`
-	From    Address   `json:"From"`
-	ID      string    `json:"ID"`
-	Subject string    `json:"Subject"`
-	To      []Address `json:"To"`
-	Tags    []string  `json:"Tags,omitempty"`
-	Size    int       `json:"Size"`
-	Read    bool      `json:"Read"`
+	Created     time.Time `json:"Created"`
+	From        Address   `json:"From"`
+	ID          string    `json:"ID"`
+	MessageID   string    `json:"MessageID"`
+	Subject     string    `json:"Subject"`
+	Snippet     string    `json:"Snippet"`
+	Username    string    `json:"Username,omitempty"`
+	To          []Address `json:"To"`
+	Cc          []Address `json:"Cc,omitempty"`
+	Bcc         []Address `json:"Bcc,omitempty"`
+	ReplyTo     []Address `json:"ReplyTo,omitempty"`
+	Tags        []string  `json:"Tags,omitempty"`
+	Attachments int       `json:"Attachments"`
+	Size        int       `json:"Size"`
+	Read        bool      `json:"Read"`
 }
 
 // HTMLCheckResponse represents response from HTML check endpoint.
+// Mailpit reports which HTML and CSS features used by a message are
+// unsupported or only partially supported by popular email clients.
 type HTMLCheckResponse struct {
-	Errors   []HTMLCheckError `json:"errors,omitempty"`
-	Warnings []HTMLCheckError `json:"warnings,omitempty"`
+	Platforms map[string][]string `json:"Platforms,omitempty"`
+	Warnings  []HTMLCheckWarning  `json:"Warnings,omitempty"`
+	Total     HTMLCheckTotal      `json:"Total"`
 }
 
-// HTMLCheckError represents an HTML validation error or warning.
-type HTMLCheckError struct {
-	Type         string `json:"type"`
-	Message      string `json:"message"`
-	Extract      string `json:"extract"`
-	LastLine     int    `json:"lastLine"`
-	FirstColumn  int    `json:"firstColumn"`
-	LastColumn   int    `json:"lastColumn"`
-	HiliteStart  int    `json:"hiliteStart"`
-	HiliteLength int    `json:"hiliteLength"`
+// HTMLCheckTotal represents the overall HTML compatibility of a message.
+// Supported, Partial and Unsupported are percentages.
+type HTMLCheckTotal struct {
+	Nodes       int     `json:"Nodes"`
+	Tests       int     `json:"Tests"`
+	Supported   float64 `json:"Supported"`
+	Partial     float64 `json:"Partial"`
+	Unsupported float64 `json:"Unsupported"`
 }
 
+// HTMLCheckWarning represents a single HTML or CSS feature that is not fully
+// supported by one or more email clients.
+type HTMLCheckWarning struct {
+	NotesByNumber map[string]string `json:"NotesByNumber,omitempty"`
+	Slug          string            `json:"Slug"`
+	Title         string            `json:"Title"`
+	Description   string            `json:"Description"`
+	Category      string            `json:"Category"`
+	URL           string            `json:"URL"`
+	Keywords      string            `json:"Keywords"`
+	Tags          []string          `json:"Tags,omitempty"`
+	Results       []HTMLCheckResult `json:"Results"`
+	Score         HTMLCheckScore    `json:"Score"`
+}
+
+// HTMLCheckResult represents the support of a feature in a single email client.
+type HTMLCheckResult struct {
+	Name       string `json:"Name"`
+	Platform   string `json:"Platform"`
+	Family     string `json:"Family"`
+	Version    string `json:"Version"`
+	Support    string `json:"Support"`
+	NoteNumber string `json:"NoteNumber,omitempty"`
+}
+
+// HTMLCheckScore represents the support percentages of a single feature.
+type HTMLCheckScore struct {
+	Found       int     `json:"Found"`
+	Supported   float64 `json:"Supported"`
+	Partial     float64 `json:"Partial"`
+	Unsupported float64 `json:"Unsupported"`
+}
+
+// HTML check support values reported in HTMLCheckResult.Support.
+const (
+	HTMLCheckSupportYes     = "yes"
+	HTMLCheckSupportPartial = "partial"
+	HTMLCheckSupportNo      = "no"
+)
+
 // LinkCheckResponse represents response from link check endpoint.
 type LinkCheckResponse struct {
-	Links []LinkCheck `json:"links,omitempty"`
+	Links  []LinkCheck `json:"Links,omitempty"`
+	Errors int         `json:"Errors"`
 }
 
 // LinkCheck represents a checked link.
 type LinkCheck struct {
-	Status any    `json:"status"`
-	URL    string `json:"url"`
-	Error  string `json:"error,omitempty"`
+	URL        string `json:"URL"`
+	Status     string `json:"Status"`
+	Error      string `json:"Error,omitempty"`
+	StatusCode int    `json:"StatusCode"`
 }
 
-// SpamAssassinCheckResponse represents response from SpamAssassin check endpoint.
-type SpamAssassinCheckResponse struct {
-	Symbols []SpamAssassinSymbol `json:"symbols,omitempty"`
-	Report  []SpamAssassinReport `json:"report,omitempty"`
-	Score   float64              `json:"score"`
+// UnmarshalJSON handles both the current link check format, where Status is
+// the HTTP status text and StatusCode the numeric code, and older responses
+// that returned the numeric code in Status.
+func (lc *LinkCheck) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		URL        string          `json:"URL"`
+		Error      string          `json:"Error"`
+		Status     json.RawMessage `json:"Status"`
+		StatusCode int             `json:"StatusCode"`
+	}
+
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	*lc = LinkCheck{
+		URL:        raw.URL,
+		Error:      raw.Error,
+		StatusCode: raw.StatusCode,
+	}
+
+	if len(raw.Status) == 0 || string(raw.Status) == "null" {
+		return nil
+	}
+
+	var code int
+	if err := json.Unmarshal(raw.Status, &code); err == nil {
+		if lc.StatusCode == 0 {
+			lc.StatusCode = code
+		}
+
+		lc.Status = http.StatusText(code)
+
+		return nil
+	}
+
+	return json.Unmarshal(raw.Status, &lc.Status)
 }
 
-// SpamAssassinSymbol represents a SpamAssassin symbol.
-type SpamAssassinSymbol struct {
-	Name        string  `json:"name"`
-	Description string  `json:"description"`
-	Score       float64 `json:"score"`
+// SpamAssassinCheckResponse represents response from SpamAssassin check endpoint.
+type SpamAssassinCheckResponse struct {
+	Error string             `json:"Error,omitempty"`
+	Rules []SpamAssassinRule `json:"Rules,omitempty"`
+	Score float64            `json:"Score"`
+	// Flagged reports whether SpamAssassin itself classified the message as spam.
+	Flagged bool `json:"IsSpam"`
 }
 
-// SpamAssassinReport represents a SpamAssassin report line.
-type SpamAssassinReport struct {
-	Description string  `json:"description"`
-	Score       float64 `json:"score"`
+// SpamAssassinRule represents a SpamAssassin rule that matched a message.
+type SpamAssassinRule struct {
+	Name        string  `json:"Name"`
+	Description string  `json:"Description"`
+	Score       float64 `json:"Score"`
 }
 
 // ReleaseMessageRequest represents a request to release a message.
@@ -306,23 +425,104 @@ type MessageRelay struct {
 }
 
 // ChaosResponse represents response from chaos endpoints.
+// Mailpit only serves these endpoints when chaos is enabled, so a successfully
+// decoded response always has Enabled set. On the wire it is the bare
+// triggers object; see MarshalJSON and UnmarshalJSON.
 type ChaosResponse struct {
-	Enabled  bool          `json:"enabled"`
-	Triggers ChaosTriggers `json:"triggers"`
+	Triggers ChaosTriggers `json:"-"`
+	Enabled  bool          `json:"-"`
+}
+
+// MarshalJSON encodes the triggers object like the chaos endpoints return it,
+// so the response round-trips through UnmarshalJSON.
+func (cr ChaosResponse) MarshalJSON() ([]byte, error) {
+	return json.Marshal(cr.Triggers)
+}
+
+// UnmarshalJSON decodes the triggers object returned by the chaos endpoints.
+func (cr *ChaosResponse) UnmarshalJSON(data []byte) error {
+	var triggers ChaosTriggers
+	if err := json.Unmarshal(data, &triggers); err != nil {
+		return err
+	}
+
+	*cr = ChaosResponse{
+		Triggers: triggers,
+		Enabled:  true,
+	}
+
+	return nil
 }
 
 // ChaosTriggers represents chaos testing triggers configuration.
+// Every trigger is always sent to Mailpit: a zero ChaosTrigger explicitly
+// disables that trigger rather than being omitted from the request.
 type ChaosTriggers struct {
-	AcceptConnections float64 `json:"accept_connections,omitempty"`
-	RejectSenders     float64 `json:"reject_senders,omitempty"`
-	RejectRecipients  float64 `json:"reject_recipients,omitempty"`
-	RejectAuth        float64 `json:"reject_auth,omitempty"`
-	RejectData        float64 `json:"reject_data,omitempty"`
-	DelayConnections  float64 `json:"delay_connections,omitempty"`
-	DelayAuth         float64 `json:"delay_auth,omitempty"`
-	DelayMailFrom     float64 `json:"delay_mail_from,omitempty"`
-	DelayRcptTo       float64 `json:"delay_rcpt_to,omitempty"`
-	DelayData         float64 `json:"delay_data,omitempty"`
+	// Sender is triggered on MAIL FROM.
+	Sender ChaosTrigger `json:"Sender"`
+	// Recipient is triggered on RCPT TO.
+	Recipient ChaosTrigger `json:"Recipient"`
+	// Authentication is triggered on AUTH.
+	Authentication ChaosTrigger `json:"Authentication"`
+}
+
+// ChaosTrigger represents a single chaos trigger. When triggered, the SMTP
+// command fails with ErrorCode. Probability is a percentage from 0 to 100.
+type ChaosTrigger struct {
+	ErrorCode   int `json:"ErrorCode"`
+	Probability int `json:"Probability"`
+}
+
+// DefaultChaosErrorCode is the SMTP error code Mailpit uses for a trigger
+// when none is specified.
+const DefaultChaosErrorCode = 451
+
+// MarshalJSON encodes the trigger, substituting DefaultChaosErrorCode for an
+// unset error code so that a zero trigger is accepted by Mailpit.
+func (ct ChaosTrigger) MarshalJSON() ([]byte, error) {
+	type trigger ChaosTrigger
+
+	if ct.ErrorCode == 0 {
+		ct.ErrorCode = DefaultChaosErrorCode
+	}
+
+	return json.Marshal(trigger(ct))
+}
+
+// Enabled reports whether the trigger can fire.
+func (ct ChaosTrigger) Enabled() bool {
+	return ct.Probability > 0
+}
+
+// Validate checks that the probability is a percentage and that the error
+// code is an SMTP 4xx or 5xx code. An unset error code is allowed.
+func (ct ChaosTrigger) Validate() error {
+	return ct.validate("chaos trigger")
+}
+
+func (ct ChaosTrigger) validate(name string) error {
+	if ct.Probability < 0 || ct.Probability > 100 {
+		return NewValidationError(fmt.Sprintf("%s probability must be between 0 and 100, got %d", name, ct.Probability))
+	}
+
+	if ct.ErrorCode != 0 && (ct.ErrorCode < 400 || ct.ErrorCode > 599) {
+		return NewValidationError(fmt.Sprintf("%s error code must be an SMTP 4xx or 5xx code, got %d", name, ct.ErrorCode))
+	}
+
+	return nil
+}
+
+// Validate checks every trigger, see ChaosTrigger.Validate.
+func (ct *ChaosTriggers) Validate() error {
+	if err := ct.Sender.validate("Sender trigger"); err != nil {
+		return err
+	}
+
+	if err := ct.Recipient.validate("Recipient trigger"); err != nil {
+		return err
+	}
+
+	return ct.Authentication.validate("Authentication trigger")
 }
 
 // EventsResponse represents response from message events endpoint.
@@ -351,3 +351,167 @@ func TestURLValues_Integration(t *testing.T) {
 	require.Contains(t, finalURL, "start=10")
 	require.Contains(t, finalURL, "limit=25")
 }
+
+func TestMessagesResponse_UnmarshalJSON(t *testing.T) {
+	t.Parallel()
+
+	data := `{
+		"total": 3,
+		"unread": 1,
+		"messages_count": 2,
+		"messages_unread": 1,
+		"start": 0,
+		"tags": ["signup"],
+		"messages": [
+			{
+				"ID": "abc",
+				"MessageID": "abc@example.com",
+				"Read": false,
+				"From": {"Name": "Sender", "Address": "sender@example.com"},
+				"To": [{"Name": "", "Address": "rcpt@example.com"}],
+				"Subject": "Welcome",
+				"Created": "2025-01-02T03:04:05Z",
+				"Username": "smtp-user",
+				"Tags": ["signup"],
+				"Size": 4096,
+				"Attachments": 2,
+				"Snippet": "Hello there"
+			}
+		]
+	}`
+
+	var resp MessagesResponse
+	require.NoError(t, json.Unmarshal([]byte(data), &resp))
+
+	require.Equal(t, 3, resp.Total)
+	require.Equal(t, 2, resp.MessagesCount)
+	require.Equal(t, 1, resp.MessagesUnread)
+	require.Len(t, resp.Messages, 1)
+
+	summary := resp.Messages[0]
+	require.Equal(t, "abc", summary.ID)
+	require.Equal(t, "abc@example.com", summary.MessageID)
+	require.Equal(t, "Hello there", summary.Snippet)
+	require.Equal(t, "smtp-user", summary.Username)
+	require.Equal(t, 2, summary.Attachments)
+	require.Equal(t, 4096, summary.Size)
+	require.Equal(t, "rcpt@example.com", summary.To[0].Address)
+	require.False(t, summary.Created.IsZero())
+}
+
+func TestMessage_UnmarshalJSON(t *testing.T) {
+	t.Parallel()
+
+	data := `{
+		"ID": "abc",
+		"Subject": "Newsletter",
+		"ReturnPath": "bounce@example.com",
+		"Username": "smtp-user",
+		"ListUnsubscribe": {
+			"Header": "<https://example.com/unsubscribe>",
+			"HeaderPost": "List-Unsubscribe=One-Click",
+			"Errors": "",
+			"Links": ["https://example.com/unsubscribe"]
+		},
+		"Inline": [{"PartID": "1.1", "FileName": "logo.png", "ContentType": "image/png", "ContentID": "logo", "Size": 10}],
+		"Attachments": [{
+			"PartID": "2",
+			"FileName": "invoice.pdf",
+			"ContentType": "application/pdf",
+			"Size": 2048,
+			"Checksums": {"MD5": "m", "SHA1": "s1", "SHA256": "s256"}
+		}]
+	}`
+
+	var msg Message
+	require.NoError(t, json.Unmarshal([]byte(data), &msg))
+
+	require.Equal(t, "bounce@example.com", msg.ReturnPath)
+	require.Equal(t, "smtp-user", msg.Username)
+	require.Equal(t, []string{"https://example.com/unsubscribe"}, msg.ListUnsubscribe.Links)
+	require.Equal(t, "List-Unsubscribe=One-Click", msg.ListUnsubscribe.HeaderPost)
+	require.Equal(t, 1, msg.InlineCount())
+	require.Equal(t, "logo", msg.Inline[0].ContentID)
+	require.Equal(t, 1, msg.AttachmentCount())
+	require.Equal(t, "s256", msg.Attachments[0].Checksums.SHA256)
+}
+
+func TestChaosTriggers_Validate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		triggers    ChaosTriggers
+		expectError bool
+	}{
+		{
+			name:     "zero value",
+			triggers: ChaosTriggers{},
+		},
+		{
+			name: "valid triggers",
+			triggers: ChaosTriggers{
+				Sender:         ChaosTrigger{ErrorCode: 421, Probability: 100},
+				Recipient:      ChaosTrigger{ErrorCode: 550, Probability: 30},
+				Authentication: ChaosTrigger{Probability: 1},
+			},
+		},
+		{
+			name:        "probability above 100",
+			triggers:    ChaosTriggers{Sender: ChaosTrigger{Probability: 101}},
+			expectError: true,
+		},
+		{
+			name:        "negative probability",
+			triggers:    ChaosTriggers{Recipient: ChaosTrigger{Probability: -1}},
+			expectError: true,
+		},
+		{
+			name:        "non-error SMTP code",
+			triggers:    ChaosTriggers{Authentication: ChaosTrigger{ErrorCode: 250, Probability: 10}},
+			expectError: true,
+		},
+		{
+			name:        "code outside SMTP range",
+			triggers:    ChaosTriggers{Recipient: ChaosTrigger{ErrorCode: 600, Probability: 10}},
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := tt.triggers.Validate()
+			if tt.expectError {
+				var mailpitErr *Error
+				require.ErrorAs(t, err, &mailpitErr)
+				require.Equal(t, ErrorTypeValidation, mailpitErr.Type)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestChaosResponse_JSONRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	// As returned by Mailpit, with every error code set.
+	want := ChaosResponse{
+		Triggers: ChaosTriggers{
+			Sender:         ChaosTrigger{ErrorCode: 451, Probability: 50},
+			Recipient:      ChaosTrigger{ErrorCode: 550, Probability: 0},
+			Authentication: ChaosTrigger{ErrorCode: 535, Probability: 10},
+		},
+		Enabled: true,
+	}
+
+	data, err := json.Marshal(want)
+	require.NoError(t, err)
+	require.NotContains(t, string(data), "Enabled", "encoded like the chaos endpoints respond")
+
+	var got ChaosResponse
+	require.NoError(t, json.Unmarshal(data, &got))
+	require.Equal(t, want, got)
+}
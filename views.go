@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 )
 
 // GetMessageHTML retrieves the HTML view of a specific message.
@@ -13,9 +14,9 @@ func (c *client) GetMessageHTML(ctx context.Context, id string) (string, error)
 		return "", NewValidationError("message ID cannot be empty")
 	}
 
-	endpoint := fmt.Sprintf("/view/%s.html", id)
+	endpoint := fmt.Sprintf("/view/%s.html", url.PathEscape(id))
 
-	resp, err := c.makeRequest(ctx, http.MethodGet, endpoint, nil)
+	resp, err := c.makeWebRequest(ctx, http.MethodGet, endpoint)
 	if err != nil {
 		return "", err
 	}
@@ -39,9 +40,9 @@ func (c *client) GetMessageText(ctx context.Context, id string) (string, error)
 		return "", NewValidationError("message ID cannot be empty")
 	}
 
-	endpoint := fmt.Sprintf("/view/%s.txt", id)
+	endpoint := fmt.Sprintf("/view/%s.txt", url.PathEscape(id))
 
-	resp, err := c.makeRequest(ctx, http.MethodGet, endpoint, nil)
+	resp, err := c.makeWebRequest(ctx, http.MethodGet, endpoint)
 	if err != nil {
 		return "", err
 	}
@@ -65,9 +66,9 @@ func (c *client) GetMessageRaw(ctx context.Context, id string) (string, error) {
 		return "", NewValidationError("message ID cannot be empty")
 	}
 
-	endpoint := fmt.Sprintf("/view/%s.raw", id)
+	endpoint := fmt.Sprintf("/view/%s.raw", url.PathEscape(id))
 
-	resp, err := c.makeRequest(ctx, http.MethodGet, endpoint, nil)
+	resp, err := c.makeWebRequest(ctx, http.MethodGet, endpoint)
 	if err != nil {
 		return "", err
 	}
@@ -94,9 +95,9 @@ func (c *client) GetMessagePartHTML(ctx context.Context, messageID, partID strin
 		return "", NewValidationError("part ID cannot be empty")
 	}
 
-	endpoint := fmt.Sprintf("/view/%s/part/%s.html", messageID, partID)
+	endpoint := fmt.Sprintf("/view/%s/part/%s.html", url.PathEscape(messageID), url.PathEscape(partID))
 
-	resp, err := c.makeRequest(ctx, http.MethodGet, endpoint, nil)
+	resp, err := c.makeWebRequest(ctx, http.MethodGet, endpoint)
 	if err != nil {
 		return "", err
 	}
@@ -123,9 +124,9 @@ func (c *client) GetMessagePartText(ctx context.Context, messageID, partID strin
 		return "", NewValidationError("part ID cannot be empty")
 	}
 
-	endpoint := fmt.Sprintf("/view/%s/part/%s.text", messageID, partID)
+	endpoint := fmt.Sprintf("/view/%s/part/%s.text", url.PathEscape(messageID), url.PathEscape(partID))
 
-	resp, err := c.makeRequest(ctx, http.MethodGet, endpoint, nil)
+	resp, err := c.makeWebRequest(ctx, http.MethodGet, endpoint)
 	if err != nil {
 		return "", err
 	}
@@ -149,7 +150,7 @@ func (c *client) GetMessageEvents(ctx context.Context, id string) (*EventsRespon
 		return nil, NewValidationError("message ID cannot be empty")
 	}
 
-	endpoint := fmt.Sprintf("/message/%s/events", id)
+	endpoint := fmt.Sprintf("/message/%s/events", url.PathEscape(id))
 
 	resp, err := c.makeRequest(ctx, http.MethodGet, endpoint, nil)
 	if err != nil {
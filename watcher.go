@@ -0,0 +1,655 @@
+package mailpitclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// defaultWatchPollInterval is how often the watcher polls when the event
+	// stream is not available.
+	defaultWatchPollInterval = time.Second
+
+	// defaultWatchResyncInterval is how often the watcher polls as a safety net
+	// while the event stream is connected.
+	defaultWatchResyncInterval = 30 * time.Second
+
+	// defaultWatchReconnectDelay is the delay between event stream reconnects.
+	defaultWatchReconnectDelay = 5 * time.Second
+
+	// defaultWatchMaxAttempts is how often a message is retried before the
+	// watcher skips it.
+	defaultWatchMaxAttempts = 5
+
+	watchPageSize = 50
+)
+
+// WatchFilter selects the messages a handler receives. Empty fields match
+// every message; all non-empty fields must match.
+type WatchFilter struct {
+	// Query is a Mailpit search query evaluated by the server.
+	Query string
+	// Tag matches messages carrying the tag, case-insensitively.
+	Tag string
+	// Recipient matches messages sent To, Cc or Bcc the address, case-insensitively.
+	Recipient string
+}
+
+func (f *WatchFilter) matches(msg *MessageSummary, queryMatches map[string]map[string]struct{}) bool {
+	if f.Tag != "" && !containsFold(msg.Tags, f.Tag) {
+		return false
+	}
+
+	if f.Recipient != "" && !hasRecipient(msg, f.Recipient) {
+		return false
+	}
+
+	if f.Query != "" {
+		if _, ok := queryMatches[f.Query][msg.ID]; !ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+func containsFold(values []string, s string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func hasRecipient(msg *MessageSummary, addr string) bool {
+	for _, list := range [][]Address{msg.To, msg.Cc, msg.Bcc} {
+		for _, a := range list {
+			if strings.EqualFold(a.Address, addr) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// MessageHandler handles a message delivered by a Watcher. Returning an error
+// causes the message to be delivered again on the next sync.
+type MessageHandler func(ctx context.Context, msg *MessageSummary) error
+
+// WatchCursor records how far a Watcher has processed the mailbox. Messages
+// created before Since have been handled, as have the messages created exactly
+// at Since whose IDs are listed in Seen.
+type WatchCursor struct {
+	Since time.Time `json:"since"`
+	Seen  []string  `json:"seen,omitempty"`
+}
+
+// IsZero reports whether the cursor has never been set.
+func (wc *WatchCursor) IsZero() bool {
+	return wc.Since.IsZero() && len(wc.Seen) == 0
+}
+
+func (wc *WatchCursor) handled(msg *MessageSummary) bool {
+	if msg.Created.Before(wc.Since) {
+		return true
+	}
+
+	if msg.Created.Equal(wc.Since) {
+		for _, id := range wc.Seen {
+			if id == msg.ID {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func (wc *WatchCursor) advance(msg *MessageSummary) {
+	if msg.Created.After(wc.Since) {
+		wc.Since = msg.Created
+		wc.Seen = nil
+	}
+
+	wc.Seen = append(wc.Seen, msg.ID)
+}
+
+// CursorStore persists a WatchCursor so that a restarted Watcher resumes where
+// it left off. Load returns a zero cursor if none has been saved.
+type CursorStore interface {
+	Load(ctx context.Context) (WatchCursor, error)
+	Save(ctx context.Context, cursor WatchCursor) error
+}
+
+// FileCursorStore stores the cursor as JSON in a file.
+type FileCursorStore struct {
+	path string
+}
+
+// NewFileCursorStore creates a CursorStore backed by the file at path.
+func NewFileCursorStore(path string) *FileCursorStore {
+	return &FileCursorStore{path: path}
+}
+
+// Load reads the cursor from the file. A missing file yields a zero cursor.
+func (s *FileCursorStore) Load(_ context.Context) (WatchCursor, error) {
+	var cursor WatchCursor
+
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return cursor, nil
+	}
+	if err != nil {
+		return cursor, err
+	}
+
+	if err = json.Unmarshal(data, &cursor); err != nil {
+		return cursor, &Error{
+			Type:    ErrorTypeResponse,
+			Message: "failed to parse watch cursor " + s.path,
+			Cause:   err,
+		}
+	}
+
+	return cursor, nil
+}
+
+// Save atomically replaces the file with the cursor.
+func (s *FileCursorStore) Save(_ context.Context, cursor WatchCursor) error {
+	data, err := json.Marshal(cursor)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err = tmp.Write(data); err != nil {
+		_ = tmp.Close()
+
+		return err
+	}
+
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), s.path)
+}
+
+// memoryCursorStore keeps the cursor for the lifetime of the Watcher only.
+type memoryCursorStore struct {
+	cursor WatchCursor
+	mu     sync.Mutex
+}
+
+func (s *memoryCursorStore) Load(_ context.Context) (WatchCursor, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.cursor, nil
+}
+
+func (s *memoryCursorStore) Save(_ context.Context, cursor WatchCursor) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.cursor = cursor
+
+	return nil
+}
+
+// WatcherOption configures a Watcher.
+type WatcherOption func(*Watcher)
+
+// WithWatchPollInterval sets how often the mailbox is polled when the event
+// stream is unavailable. Defaults to one second.
+func WithWatchPollInterval(d time.Duration) WatcherOption {
+	return func(w *Watcher) {
+		w.pollInterval = d
+	}
+}
+
+// WithWatchResyncInterval sets how often the mailbox is polled while the event
+// stream is connected, to catch events missed during reconnects.
+// Defaults to 30 seconds.
+func WithWatchResyncInterval(d time.Duration) WatcherOption {
+	return func(w *Watcher) {
+		w.resyncInterval = d
+	}
+}
+
+// WithWatchCursorStore persists the cursor in store. By default the cursor is
+// kept in memory and a new Watcher starts from the newest message.
+func WithWatchCursorStore(store CursorStore) WatcherOption {
+	return func(w *Watcher) {
+		w.store = store
+	}
+}
+
+// WithWatchWebSocket enables or disables the Mailpit event stream. When
+// disabled, the watcher only polls. Enabled by default.
+func WithWatchWebSocket(enabled bool) WatcherOption {
+	return func(w *Watcher) {
+		w.websocket = enabled
+	}
+}
+
+// WithWatchExistingMessages delivers the messages already in the mailbox when
+// the watcher starts without a saved cursor.
+func WithWatchExistingMessages() WatcherOption {
+	return func(w *Watcher) {
+		w.existing = true
+	}
+}
+
+// WithWatchMaxAttempts sets how often the handlers are run for a message that
+// keeps failing before the watcher skips it, reporting ErrWatchMessageSkipped
+// to the error handler. n <= 0 retries forever, so a failing message blocks
+// every message after it. Defaults to 5.
+func WithWatchMaxAttempts(n int) WatcherOption {
+	return func(w *Watcher) {
+		w.maxAttempts = n
+	}
+}
+
+// WithWatchErrorHandler receives errors that do not stop the watcher, such as
+// handler failures, skipped messages, failed polls and event stream
+// disconnects.
+func WithWatchErrorHandler(fn func(error)) WatcherOption {
+	return func(w *Watcher) {
+		w.onError = fn
+	}
+}
+
+type watchHandler struct {
+	handler MessageHandler
+	filter  WatchFilter
+}
+
+// Watcher watches a Mailpit instance and dispatches new messages to handlers.
+//
+// The watcher listens to the Mailpit event stream (websocket) to react to new
+// messages immediately and falls back to polling ListMessages when the stream
+// is unavailable or the client does not implement EventStreamer. Messages are
+// delivered oldest first, at least once: a message is only recorded in the
+// cursor after every matching handler returned nil, and a failing message is
+// retried, together with the messages after it, on the next sync, until
+// WithWatchMaxAttempts gives up on it.
+type Watcher struct {
+	client         Client
+	store          CursorStore
+	onError        func(error)
+	wake           chan struct{}
+	attempts       map[string]int
+	handlers       []watchHandler
+	pollInterval   time.Duration
+	resyncInterval time.Duration
+	reconnectDelay time.Duration
+	maxAttempts    int
+	mu             sync.Mutex
+	streaming      atomic.Bool
+	websocket      bool
+	existing       bool
+}
+
+// NewWatcher creates a Watcher for c.
+func NewWatcher(c Client, opts ...WatcherOption) (*Watcher, error) {
+	if c == nil {
+		return nil, NewValidationError("client cannot be nil")
+	}
+
+	w := &Watcher{
+		client:         c,
+		store:          &memoryCursorStore{},
+		wake:           make(chan struct{}, 1),
+		pollInterval:   defaultWatchPollInterval,
+		resyncInterval: defaultWatchResyncInterval,
+		reconnectDelay: defaultWatchReconnectDelay,
+		maxAttempts:    defaultWatchMaxAttempts,
+		attempts:       make(map[string]int),
+		websocket:      true,
+	}
+
+	for _, opt := range opts {
+		opt(w)
+	}
+
+	if w.pollInterval <= 0 || w.resyncInterval <= 0 {
+		return nil, NewConfigError("watch intervals must be positive")
+	}
+
+	return w, nil
+}
+
+// Handle registers h for the messages matching filter.
+func (w *Watcher) Handle(filter WatchFilter, h MessageHandler) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.handlers = append(w.handlers, watchHandler{filter: filter, handler: h})
+}
+
+// Run watches the mailbox until ctx is cancelled and returns ctx.Err().
+// It returns early only if the cursor cannot be loaded or initialised.
+// Run must not be called concurrently.
+func (w *Watcher) Run(ctx context.Context) error {
+	cursor, err := w.loadCursor(ctx)
+	if err != nil {
+		return err
+	}
+
+	if es, ok := w.client.(EventStreamer); ok && w.websocket {
+		var wg sync.WaitGroup
+		defer wg.Wait()
+
+		streamCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		wg.Go(func() { w.stream(streamCtx, es) })
+	}
+
+	for {
+		if cursor, err = w.sync(ctx, cursor); err != nil {
+			w.reportError(err)
+		}
+
+		interval := w.pollInterval
+		if w.streaming.Load() {
+			interval = w.resyncInterval
+		}
+
+		timer := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+
+			return ctx.Err()
+		case <-w.wake:
+			timer.Stop()
+		case <-timer.C:
+		}
+	}
+}
+
+// Sync processes the messages received since the last saved cursor once,
+// which is useful in tests that do not run the watcher in the background.
+// Like Run, the first Sync without a saved cursor only records the newest
+// message unless WithWatchExistingMessages is set.
+// Sync must not be called while Run is running.
+func (w *Watcher) Sync(ctx context.Context) error {
+	cursor, err := w.loadCursor(ctx)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.sync(ctx, cursor)
+
+	return err
+}
+
+// loadCursor loads the saved cursor. Without one, the cursor is positioned
+// after the newest existing message, unless existing messages are delivered.
+func (w *Watcher) loadCursor(ctx context.Context) (WatchCursor, error) {
+	cursor, err := w.store.Load(ctx)
+	if err != nil || !cursor.IsZero() || w.existing {
+		return cursor, err
+	}
+
+	resp, err := w.client.ListMessages(ctx, &ListOptions{Limit: watchPageSize})
+	if err != nil {
+		return cursor, err
+	}
+
+	for i := range resp.Messages {
+		msg := &resp.Messages[i]
+		if cursor.Since.IsZero() || !msg.Created.Before(cursor.Since) {
+			cursor.advance(msg)
+		}
+	}
+
+	// An empty mailbox still needs a non-zero cursor, otherwise the messages
+	// arriving before the next load would be skipped as existing ones.
+	if cursor.IsZero() {
+		cursor.Since = time.Unix(0, 0).UTC()
+	}
+
+	return cursor, w.store.Save(ctx, cursor)
+}
+
+// sync delivers the new messages to the handlers and returns the updated
+// cursor. It stops at the first message a handler fails on.
+func (w *Watcher) sync(ctx context.Context, cursor WatchCursor) (WatchCursor, error) {
+	messages, err := w.newMessages(ctx, &cursor)
+	if err != nil || len(messages) == 0 {
+		return cursor, err
+	}
+
+	w.mu.Lock()
+	handlers := append([]watchHandler(nil), w.handlers...)
+	w.mu.Unlock()
+
+	queryMatches, err := w.queryMatches(ctx, handlers, messages[0].Created)
+	if err != nil {
+		return cursor, err
+	}
+
+	for i := range messages {
+		msg := &messages[i]
+
+		if err = w.dispatch(ctx, handlers, queryMatches, msg); err != nil {
+			if !w.exhausted(msg.ID) {
+				return cursor, err
+			}
+
+			w.reportError(fmt.Errorf("%w %s after %d attempts: %w", ErrWatchMessageSkipped, msg.ID, w.maxAttempts, err))
+		}
+
+		cursor.advance(msg)
+		if err = w.store.Save(ctx, cursor); err != nil {
+			return cursor, err
+		}
+	}
+
+	return cursor, nil
+}
+
+// dispatch runs the matching handlers for msg, stopping at the first failure.
+func (w *Watcher) dispatch(
+	ctx context.Context,
+	handlers []watchHandler,
+	queryMatches map[string]map[string]struct{},
+	msg *MessageSummary,
+) error {
+	for _, h := range handlers {
+		if !h.filter.matches(msg, queryMatches) {
+			continue
+		}
+
+		if err := h.handler(ctx, msg); err != nil {
+			return fmt.Errorf("mailpit: watch handler failed for message %s: %w", msg.ID, err)
+		}
+	}
+
+	delete(w.attempts, msg.ID)
+
+	return nil
+}
+
+// exhausted records a failed attempt for the message and reports whether the
+// watcher should give up on it.
+func (w *Watcher) exhausted(id string) bool {
+	w.attempts[id]++
+	if w.maxAttempts <= 0 || w.attempts[id] < w.maxAttempts {
+		return false
+	}
+
+	delete(w.attempts, id)
+
+	return true
+}
+
+// newMessages returns the messages not yet handled according to cursor,
+// oldest first.
+func (w *Watcher) newMessages(ctx context.Context, cursor *WatchCursor) ([]MessageSummary, error) {
+	var (
+		out  []MessageSummary
+		seen = make(map[string]struct{})
+	)
+
+	for start := 0; ; start += watchPageSize {
+		resp, err := w.client.ListMessages(ctx, &ListOptions{Start: start, Limit: watchPageSize})
+		if err != nil {
+			return nil, err
+		}
+
+		done := len(resp.Messages) < watchPageSize
+		for i := range resp.Messages {
+			msg := &resp.Messages[i]
+			if msg.Created.Before(cursor.Since) {
+				done = true
+
+				continue
+			}
+
+			if _, dup := seen[msg.ID]; dup || cursor.handled(msg) {
+				continue
+			}
+
+			seen[msg.ID] = struct{}{}
+			out = append(out, *msg)
+		}
+
+		if done {
+			break
+		}
+	}
+
+	// Mailpit lists newest first; deliver oldest first.
+	for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+		out[i], out[j] = out[j], out[i]
+	}
+
+	return out, nil
+}
+
+// queryMatches runs each distinct handler query and returns the IDs of the
+// matching messages created at or after since.
+func (w *Watcher) queryMatches(ctx context.Context, handlers []watchHandler, since time.Time) (map[string]map[string]struct{}, error) {
+	matches := make(map[string]map[string]struct{})
+
+	for _, h := range handlers {
+		query := h.filter.Query
+		if query == "" {
+			continue
+		}
+		if _, ok := matches[query]; ok {
+			continue
+		}
+
+		ids := make(map[string]struct{})
+		matches[query] = ids
+
+		for start := 0; ; start += watchPageSize {
+			resp, err := w.client.SearchMessages(ctx, query, &SearchOptions{Start: start, Limit: watchPageSize})
+			if err != nil {
+				return nil, err
+			}
+
+			done := len(resp.Messages) < watchPageSize
+			for i := range resp.Messages {
+				if resp.Messages[i].Created.Before(since) {
+					done = true
+
+					continue
+				}
+				ids[resp.Messages[i].ID] = struct{}{}
+			}
+
+			if done {
+				break
+			}
+		}
+	}
+
+	return matches, nil
+}
+
+// stream listens to the Mailpit event stream and wakes the sync loop on every
+// new message, reconnecting until ctx is cancelled.
+func (w *Watcher) stream(ctx context.Context, es EventStreamer) {
+	for {
+		err := w.streamOnce(ctx, es)
+		if errors.Is(err, errors.ErrUnsupported) {
+			// Keep polling at the poll interval.
+			return
+		}
+		if err != nil && ctx.Err() == nil {
+			w.reportError(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(w.reconnectDelay):
+		}
+	}
+}
+
+func (w *Watcher) streamOnce(ctx context.Context, es EventStreamer) error {
+	ws, err := es.OpenEventStream(ctx)
+	if err != nil {
+		return err
+	}
+
+	stop := context.AfterFunc(ctx, func() { _ = ws.Close() })
+	defer stop()
+	defer ws.Close()
+
+	w.streaming.Store(true)
+	defer w.streaming.Store(false)
+
+	// Catch up on anything that arrived while the stream was down.
+	w.notify()
+
+	for {
+		data, err := ws.ReadMessage()
+		if err != nil {
+			return err
+		}
+
+		var event struct {
+			Type string `json:"Type"`
+		}
+		if json.Unmarshal(data, &event) == nil && event.Type == "new" {
+			w.notify()
+		}
+	}
+}
+
+func (w *Watcher) notify() {
+	select {
+	case w.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (w *Watcher) reportError(err error) {
+	if w.onError != nil {
+		w.onError(err)
+	}
+}
@@ -0,0 +1,394 @@
+package mailpitclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/websocket"
+)
+
+// watchServer is a fake Mailpit mailbox with an optional event stream.
+type watchServer struct {
+	events   chan string
+	messages []MessageSummary // oldest first
+	mu       sync.Mutex
+	stream   bool
+}
+
+func (s *watchServer) add(id string, tags []string, to string) {
+	s.mu.Lock()
+	msg := MessageSummary{
+		ID:      id,
+		Created: time.Date(2025, 1, 1, 0, 0, len(s.messages), 0, time.UTC),
+		Tags:    tags,
+		To:      []Address{{Address: to}},
+	}
+	s.messages = append(s.messages, msg)
+	s.mu.Unlock()
+
+	if s.stream {
+		s.events <- `{"Type":"new","Data":{"ID":"` + id + `"}}`
+	}
+}
+
+// page returns the messages newest first, paginated like Mailpit.
+func (s *watchServer) page(r *http.Request, match func(MessageSummary) bool) MessagesResponse {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var all []MessageSummary
+	for i := len(s.messages) - 1; i >= 0; i-- {
+		if match(s.messages[i]) {
+			all = append(all, s.messages[i])
+		}
+	}
+
+	start, limit := 0, watchPageSize
+	if v := r.URL.Query().Get("start"); v != "" {
+		_ = json.Unmarshal([]byte(v), &start)
+	}
+	if v := r.URL.Query().Get("limit"); v != "" {
+		_ = json.Unmarshal([]byte(v), &limit)
+	}
+
+	resp := MessagesResponse{Total: len(all)}
+	if start < len(all) {
+		resp.Messages = all[start:min(start+limit, len(all))]
+	}
+
+	return resp
+}
+
+func newWatchTestClient(t *testing.T, state *watchServer) Client {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/events":
+			if !state.stream {
+				w.WriteHeader(http.StatusNotFound)
+
+				return
+			}
+			serveWatchEvents(w, r, state.events)
+		case "/api/v1/messages":
+			_ = json.NewEncoder(w).Encode(state.page(r, func(MessageSummary) bool { return true }))
+		case "/api/v1/search":
+			// The fake only understands "tag:<name>" queries.
+			tag := strings.TrimPrefix(r.URL.Query().Get("query"), "tag:")
+			_ = json.NewEncoder(w).Encode(state.page(r, func(m MessageSummary) bool {
+				return containsFold(m.Tags, tag)
+			}))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	c, err := NewClient(&Config{
+		BaseURL:    server.URL,
+		APIPath:    "/api/v1",
+		MaxRetries: 0,
+		HTTPClient: &http.Client{Timeout: 5 * time.Second},
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = c.Close() })
+
+	return c
+}
+
+// serveWatchEvents upgrades the connection and writes every event as a text
+// message until the client goes away.
+func serveWatchEvents(w http.ResponseWriter, r *http.Request, events chan string) {
+	websocket.Handler(func(conn *websocket.Conn) {
+		closed := make(chan struct{})
+		go func() {
+			defer close(closed)
+			var discard []byte
+			_ = websocket.Message.Receive(conn, &discard)
+		}()
+
+		for {
+			select {
+			case <-closed:
+				return
+			case event := <-events:
+				_ = websocket.Message.Send(conn, event)
+			}
+		}
+	}).ServeHTTP(w, r)
+}
+
+func recordingHandler(mu *sync.Mutex, got *[]string) MessageHandler {
+	return func(_ context.Context, msg *MessageSummary) error {
+		mu.Lock()
+		defer mu.Unlock()
+
+		*got = append(*got, msg.ID)
+
+		return nil
+	}
+}
+
+func TestWatcher_SyncDeliversNewMessagesOnce(t *testing.T) {
+	t.Parallel()
+
+	state := &watchServer{}
+	state.add("existing", nil, "a@example.com")
+	c := newWatchTestClient(t, state)
+
+	w, err := NewWatcher(c, WithWatchWebSocket(false))
+	require.NoError(t, err)
+
+	var (
+		mu  sync.Mutex
+		got []string
+	)
+	w.Handle(WatchFilter{}, recordingHandler(&mu, &got))
+
+	// The first sync only records the newest existing message.
+	require.NoError(t, w.Sync(t.Context()))
+	require.Empty(t, got)
+
+	for i := range watchPageSize + 5 {
+		state.add("new-"+string(rune('a'+i%26))+string(rune('a'+i/26)), nil, "a@example.com")
+	}
+
+	require.NoError(t, w.Sync(t.Context()))
+	require.Len(t, got, watchPageSize+5)
+	require.Equal(t, "new-aa", got[0], "messages are delivered oldest first")
+
+	require.NoError(t, w.Sync(t.Context()))
+	require.Len(t, got, watchPageSize+5, "messages are not delivered twice")
+}
+
+func TestWatcher_Filters(t *testing.T) {
+	t.Parallel()
+
+	state := &watchServer{}
+	c := newWatchTestClient(t, state)
+
+	w, err := NewWatcher(c, WithWatchWebSocket(false), WithWatchExistingMessages())
+	require.NoError(t, err)
+
+	var (
+		mu                 sync.Mutex
+		byTag, byRcpt, byQ []string
+		byTagAndRecipient  []string
+	)
+	w.Handle(WatchFilter{Tag: "Login"}, recordingHandler(&mu, &byTag))
+	w.Handle(WatchFilter{Recipient: "BOT@example.com"}, recordingHandler(&mu, &byRcpt))
+	w.Handle(WatchFilter{Query: "tag:reset"}, recordingHandler(&mu, &byQ))
+	w.Handle(WatchFilter{Tag: "login", Recipient: "bot@example.com"}, recordingHandler(&mu, &byTagAndRecipient))
+
+	state.add("m1", []string{"login"}, "bot@example.com")
+	state.add("m2", []string{"reset"}, "bot@example.com")
+	state.add("m3", []string{"login"}, "human@example.com")
+
+	require.NoError(t, w.Sync(t.Context()))
+	require.Equal(t, []string{"m1", "m3"}, byTag)
+	require.Equal(t, []string{"m1", "m2"}, byRcpt)
+	require.Equal(t, []string{"m2"}, byQ)
+	require.Equal(t, []string{"m1"}, byTagAndRecipient)
+}
+
+func TestWatcher_AtLeastOnce(t *testing.T) {
+	t.Parallel()
+
+	state := &watchServer{}
+	c := newWatchTestClient(t, state)
+
+	w, err := NewWatcher(c, WithWatchWebSocket(false), WithWatchExistingMessages())
+	require.NoError(t, err)
+
+	errBusy := errors.New("busy") // nolint:err113
+	attempts := map[string]int{}
+	w.Handle(WatchFilter{}, func(_ context.Context, msg *MessageSummary) error {
+		attempts[msg.ID]++
+		if msg.ID == "m1" && attempts[msg.ID] == 1 {
+			return errBusy
+		}
+
+		return nil
+	})
+
+	state.add("m1", nil, "a@example.com")
+	state.add("m2", nil, "a@example.com")
+
+	err = w.Sync(t.Context())
+	require.ErrorIs(t, err, errBusy)
+	require.Equal(t, map[string]int{"m1": 1}, attempts, "later messages wait for the failed one")
+
+	require.NoError(t, w.Sync(t.Context()))
+	require.Equal(t, map[string]int{"m1": 2, "m2": 1}, attempts)
+}
+
+func TestWatcher_SkipsAfterMaxAttempts(t *testing.T) {
+	t.Parallel()
+
+	state := &watchServer{}
+	c := newWatchTestClient(t, state)
+
+	var reported []error
+	w, err := NewWatcher(c,
+		WithWatchWebSocket(false),
+		WithWatchExistingMessages(),
+		WithWatchMaxAttempts(2),
+		WithWatchErrorHandler(func(err error) { reported = append(reported, err) }),
+	)
+	require.NoError(t, err)
+
+	errPoison := errors.New("poison") // nolint:err113
+	var delivered []string
+	w.Handle(WatchFilter{}, func(_ context.Context, msg *MessageSummary) error {
+		if msg.ID == "m1" {
+			return errPoison
+		}
+		delivered = append(delivered, msg.ID)
+
+		return nil
+	})
+
+	state.add("m1", nil, "a@example.com")
+	state.add("m2", nil, "a@example.com")
+
+	require.ErrorIs(t, w.Sync(t.Context()), errPoison)
+	require.Empty(t, delivered)
+
+	require.NoError(t, w.Sync(t.Context()), "the second failure skips the message")
+	require.Equal(t, []string{"m2"}, delivered)
+	require.Len(t, reported, 1)
+	require.ErrorIs(t, reported[0], ErrWatchMessageSkipped)
+	require.ErrorIs(t, reported[0], errPoison)
+}
+
+func TestWatcher_PersistedCursor(t *testing.T) {
+	t.Parallel()
+
+	state := &watchServer{}
+	c := newWatchTestClient(t, state)
+	store := NewFileCursorStore(filepath.Join(t.TempDir(), "cursor.json"))
+
+	state.add("m1", nil, "a@example.com")
+
+	var (
+		mu  sync.Mutex
+		got []string
+	)
+
+	first, err := NewWatcher(c, WithWatchWebSocket(false), WithWatchCursorStore(store), WithWatchExistingMessages())
+	require.NoError(t, err)
+	first.Handle(WatchFilter{}, recordingHandler(&mu, &got))
+	require.NoError(t, first.Sync(t.Context()))
+
+	state.add("m2", nil, "a@example.com")
+
+	// A restarted watcher resumes after m1.
+	second, err := NewWatcher(c, WithWatchWebSocket(false), WithWatchCursorStore(store), WithWatchExistingMessages())
+	require.NoError(t, err)
+	second.Handle(WatchFilter{}, recordingHandler(&mu, &got))
+	require.NoError(t, second.Sync(t.Context()))
+
+	require.Equal(t, []string{"m1", "m2"}, got)
+
+	cursor, err := store.Load(t.Context())
+	require.NoError(t, err)
+	require.Equal(t, []string{"m2"}, cursor.Seen)
+}
+
+func TestWatcher_Run(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		opts []WatcherOption
+		// stream enables the fake event stream; without it the watcher polls.
+		stream bool
+		// cached wraps the client in a CachingClient.
+		cached bool
+	}{
+		{
+			name:   "websocket",
+			stream: true,
+			opts:   []WatcherOption{WithWatchPollInterval(time.Hour), WithWatchResyncInterval(time.Hour)},
+		},
+		{
+			name:   "websocket through a caching client",
+			stream: true,
+			cached: true,
+			opts:   []WatcherOption{WithWatchPollInterval(time.Hour), WithWatchResyncInterval(time.Hour)},
+		},
+		{
+			name: "polling fallback",
+			opts: []WatcherOption{WithWatchPollInterval(20 * time.Millisecond)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			state := &watchServer{stream: tt.stream, events: make(chan string, 10)}
+			c := newWatchTestClient(t, state)
+			if tt.cached {
+				cc, err := NewCachingClient(c)
+				require.NoError(t, err)
+				c = cc
+			}
+
+			w, err := NewWatcher(c, tt.opts...)
+			require.NoError(t, err)
+
+			delivered := make(chan string, 1)
+			w.Handle(WatchFilter{Recipient: "bot@example.com"}, func(_ context.Context, msg *MessageSummary) error {
+				delivered <- msg.ID
+
+				return nil
+			})
+
+			ctx, cancel := context.WithCancel(t.Context())
+			done := make(chan error, 1)
+			go func() { done <- w.Run(ctx) }()
+
+			// Let the watcher position its cursor and connect before sending.
+			require.Eventually(t, func() bool {
+				return !tt.stream || w.streaming.Load()
+			}, 5*time.Second, 10*time.Millisecond)
+			time.Sleep(50 * time.Millisecond)
+
+			state.add("magic-link", nil, "bot@example.com")
+
+			select {
+			case id := <-delivered:
+				require.Equal(t, "magic-link", id)
+			case <-time.After(5 * time.Second):
+				t.Fatal("message was not delivered")
+			}
+
+			cancel()
+			require.ErrorIs(t, <-done, context.Canceled)
+		})
+	}
+}
+
+func TestNewWatcher_Validation(t *testing.T) {
+	t.Parallel()
+
+	_, err := NewWatcher(nil)
+	require.Error(t, err)
+
+	c, err := NewClient(&Config{BaseURL: "http://localhost:8025"})
+	require.NoError(t, err)
+
+	_, err = NewWatcher(c, WithWatchPollInterval(0))
+	require.Error(t, err)
+}
@@ -0,0 +1,115 @@
+package mailpitclient
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"golang.org/x/net/websocket"
+)
+
+// maxWebsocketMessage bounds the size of a single websocket message.
+const maxWebsocketMessage = 16 << 20
+
+// EventStream is an open connection to Mailpit's event stream.
+type EventStream interface {
+	// ReadMessage returns the raw JSON of the next event.
+	ReadMessage() ([]byte, error)
+	Close() error
+}
+
+// EventStreamer is implemented by clients that can open Mailpit's event
+// stream, such as those returned by NewClient and NewCachingClient. Watcher
+// uses it to react to new messages immediately; clients wrapping another
+// Client should implement it by delegating to the wrapped client. An error
+// matching errors.ErrUnsupported means the stream is not available at all.
+type EventStreamer interface {
+	OpenEventStream(ctx context.Context) (EventStream, error)
+}
+
+// OpenEventStream opens the Mailpit event stream websocket. The handshake is
+// always made over HTTP/1.1 with the TLS settings of the configured
+// http.Transport; proxies and custom round trippers are not used. A server
+// that refuses the upgrade yields an error matching errors.ErrUnsupported.
+func (c *client) OpenEventStream(ctx context.Context) (EventStream, error) {
+	config, err := c.eventsConfig()
+	if err != nil {
+		return nil, &Error{
+			Type:    ErrorTypeRequest,
+			Message: fmt.Sprintf("failed to create websocket request: %v", err),
+			Cause:   err,
+		}
+	}
+
+	conn, err := config.DialContext(ctx)
+	if err != nil {
+		var dialErr *websocket.DialError
+		var protocolErr *websocket.ProtocolError
+		if errors.As(err, &dialErr) && errors.As(dialErr.Err, &protocolErr) {
+			return nil, &Error{
+				Type:    ErrorTypeResponse,
+				Message: fmt.Sprintf("websocket upgrade refused: %v", err),
+				Cause:   fmt.Errorf("%w: %w", errors.ErrUnsupported, err),
+			}
+		}
+
+		return nil, &Error{
+			Type:    ErrorTypeNetwork,
+			Message: fmt.Sprintf("websocket dial failed: %v", err),
+			Cause:   err,
+		}
+	}
+	conn.MaxPayloadBytes = maxWebsocketMessage
+
+	return &wsStream{conn: conn}, nil
+}
+
+// eventsConfig describes the Mailpit event stream websocket, which lives next
+// to the versioned API rather than under it.
+func (c *client) eventsConfig() (*websocket.Config, error) {
+	location := "ws" + strings.TrimPrefix(c.rootURL, "http") + "/api/events"
+
+	config, err := websocket.NewConfig(location, c.rootURL)
+	if err != nil {
+		return nil, err
+	}
+
+	req := http.Request{Header: config.Header}
+	c.setHeaders(&req)
+
+	if t, ok := c.config.HTTPClient.Transport.(*http.Transport); ok && t.TLSClientConfig != nil {
+		config.TlsConfig = t.TLSClientConfig.Clone()
+	}
+	if config.TlsConfig != nil {
+		// The upgrade only exists in HTTP/1.1, so never negotiate h2.
+		config.TlsConfig.NextProtos = nil
+	} else {
+		config.TlsConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+
+	return config, nil
+}
+
+// wsStream adapts a websocket connection to EventStream.
+type wsStream struct {
+	conn *websocket.Conn
+}
+
+// ReadMessage returns the payload of the next text or binary message. Pings
+// are answered by the websocket connection.
+func (ws *wsStream) ReadMessage() ([]byte, error) {
+	var message []byte
+	if err := websocket.Message.Receive(ws.conn, &message); err != nil {
+		return nil, err
+	}
+
+	return message, nil
+}
+
+// Close closes the underlying connection.
+func (ws *wsStream) Close() error {
+	return ws.conn.Close()
+}
@@ -0,0 +1,89 @@
+package mailpitclient
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_OpenEventStream(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		// tls serves HTTPS with HTTP/2 enabled, which the upgrade must avoid.
+		tls bool
+		// stream serves the event stream; otherwise /api/events is a 404.
+		stream      bool
+		unsupported bool
+	}{
+		{name: "http", stream: true},
+		{name: "https with http2", tls: true, stream: true},
+		{name: "upgrade refused", unsupported: true},
+		{name: "upgrade refused over https", tls: true, unsupported: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			events := make(chan string, 1)
+			server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if user, pass, ok := r.BasicAuth(); !ok || user != "user" || pass != "pass" {
+					w.WriteHeader(http.StatusUnauthorized)
+
+					return
+				}
+				if r.URL.Path != "/mail/api/events" || !tt.stream {
+					w.WriteHeader(http.StatusNotFound)
+
+					return
+				}
+				serveWatchEvents(w, r, events)
+			}))
+			if tt.tls {
+				server.EnableHTTP2 = true
+				server.StartTLS()
+			} else {
+				server.Start()
+			}
+			t.Cleanup(server.Close)
+
+			httpClient := server.Client()
+			httpClient.Timeout = 5 * time.Second
+
+			c, err := NewClient(&Config{
+				BaseURL:    server.URL,
+				WebRoot:    "/mail",
+				APIPath:    "/api/v1",
+				Username:   "user",
+				Password:   "pass",
+				HTTPClient: httpClient,
+			})
+			require.NoError(t, err)
+			t.Cleanup(func() { _ = c.Close() })
+
+			es, ok := c.(EventStreamer)
+			require.True(t, ok)
+
+			stream, err := es.OpenEventStream(t.Context())
+			if tt.unsupported {
+				require.ErrorIs(t, err, errors.ErrUnsupported)
+
+				return
+			}
+			require.NoError(t, err)
+			t.Cleanup(func() { _ = stream.Close() })
+
+			events <- `{"Type":"new"}`
+
+			data, err := stream.ReadMessage()
+			require.NoError(t, err)
+			require.JSONEq(t, `{"Type":"new"}`, string(data))
+		})
+	}
+}